@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"expvar"
+	"flag"
 	"log"
 	"net"
 	"net/http"
@@ -12,8 +14,10 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/a1mart/kafkaesque/internal/draupnir"
 	"github.com/a1mart/kafkaesque/internal/generated/messaging"
 	"github.com/a1mart/kafkaesque/internal/server"
+	"github.com/a1mart/kafkaesque/internal/urd"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
@@ -45,7 +49,7 @@ func swaggerServicesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func runGRPCServer(network string, addr string) *grpc.Server {
+func runGRPCServer(network string, addr string, srv *server.Server) *grpc.Server {
 	// Set up the gRPC server
 	lis, err := net.Listen(network, addr)
 	if err != nil {
@@ -55,9 +59,6 @@ func runGRPCServer(network string, addr string) *grpc.Server {
 	// Create a new gRPC server
 	s := grpc.NewServer()
 
-	// Create server instance with topic tracking
-	srv := server.NewServer(8, 3, 5)
-
 	// grpcServer := grpc.NewServer(
 	// 	grpc.ChainUnaryInterceptor(
 	// 		grpcmiddleware.LoggingUnaryInterceptor,
@@ -72,6 +73,7 @@ func runGRPCServer(network string, addr string) *grpc.Server {
 	// Register the service implementation (use &server{} to pass the instance)
 	messaging.RegisterMessagingServiceServer(s, srv)
 	messaging.RegisterAdminServiceServer(s, srv)
+	messaging.RegisterSchemaRegistryServiceServer(s, srv)
 	// Register reflection service on gRPC server
 	reflection.Register(s)
 
@@ -104,6 +106,11 @@ func runHTTPServer(grpcAddr string, httpAddr string) *http.Server {
 		log.Fatalf("Failed to register AuthService: %v", err)
 	}
 
+	err = messaging.RegisterSchemaRegistryServiceHandlerFromEndpoint(ctx, mux, grpcAddr, opts)
+	if err != nil {
+		log.Fatalf("Failed to register SchemaRegistryService: %v", err)
+	}
+
 	// Start the HTTP server with the REST API
 	log.Printf("HTTP server listening on %s", httpAddr)
 	// Create a multiplexer for HTTP routes
@@ -124,6 +131,9 @@ func runHTTPServer(grpcAddr string, httpAddr string) *http.Server {
 	// Serve Swagger UI
 	mainMux.Handle("/swagger/", http.StripPrefix("/swagger", http.FileServer(http.Dir("./pkg/swagger/swagger-ui"))))
 
+	// Broker metrics, such as the bytes retention has reclaimed per topic
+	mainMux.Handle("/debug/vars", expvar.Handler())
+
 	server := &http.Server{Addr: httpAddr, Handler: mainMux}
 	go func() {
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -137,7 +147,45 @@ func main() {
 	grpcAddr := "localhost:50051"
 	httpAddr := "localhost:8080"
 
-	grpcServer := runGRPCServer("tcp", grpcAddr)
+	cfg := server.DefaultConfig()
+	fsync := flag.String("fsync", "interval", "Log fsync policy: always, interval or never")
+	flag.StringVar(&cfg.DataDir, "data-dir", "data", "Directory for topic logs; empty keeps messages in memory only")
+	flag.DurationVar(&cfg.Log.SyncInterval, "fsync-interval", cfg.Log.SyncInterval, "Time between fsyncs under the interval policy")
+	flag.IntVar(&cfg.BufferSize, "buffer-size", cfg.BufferSize, "Ring buffer slots per partition")
+	flag.BoolVar(&cfg.AllowDeleteTopics, "allow-delete-topics", false, "Allow DeleteTopic to remove topics and their data")
+	flag.IntVar(&cfg.PausedLag, "paused-lag", cfg.PausedLag, "Unread messages per partition a paused consumer group may hold back before producers stop waiting for it")
+	flag.DurationVar(&cfg.RetentionInterval, "retention-check-interval", cfg.RetentionInterval, "Time between deletions of messages past their topic's retention limits")
+	flag.DurationVar(&cfg.TransactionTimeout, "transaction-timeout", cfg.TransactionTimeout, "Abort transactions not committed within this long, unless BeginTransaction sets a timeout")
+	flag.IntVar(&cfg.DedupWindow, "dedup-window", cfg.DedupWindow, "Newest messages per partition checked for duplicates retried by producers; 0 turns deduplication off")
+	flag.DurationVar(&cfg.CompactionInterval, "compaction-interval", cfg.CompactionInterval, "Time between compactions of topics with the compact cleanup policy")
+	flag.Int64Var(&cfg.DispatchRate, "dispatch-rate", cfg.DispatchRate, "Bytes per second consumers may read altogether, shared between consumer groups by weight; 0 means unlimited")
+
+	// Topic defaults, used wherever CreateTopic leaves a setting unset
+	overflow := flag.String("default-overflow-policy", cfg.Topics.OverflowPolicy.String(), "Overflow policy of new topics: block, drop_newest, drop_oldest or fail")
+	flag.IntVar(&cfg.Topics.Partitions, "default-partitions", cfg.Topics.Partitions, "Partitions of new topics")
+	flag.DurationVar(&cfg.Topics.Retention, "default-retention", cfg.Topics.Retention, "Age after which messages are deleted; 0 keeps them forever")
+	flag.Int64Var(&cfg.Topics.RetentionBytes, "default-retention-bytes", cfg.Topics.RetentionBytes, "Partition size beyond which the oldest messages are deleted; 0 means unlimited")
+	flag.IntVar(&cfg.Topics.MaxMessageBytes, "max-message-bytes", cfg.Topics.MaxMessageBytes, "Largest message a topic accepts by default; 0 means unlimited")
+	flag.StringVar(&cfg.Topics.CleanupPolicy, "default-cleanup-policy", cfg.Topics.CleanupPolicy, "Cleanup policy of new topics: delete or compact")
+	flag.DurationVar(&cfg.Topics.DeleteRetention, "default-delete-retention", cfg.Topics.DeleteRetention, "How long compacted topics keep tombstones before removing their keys")
+	flag.Parse()
+
+	policy, err := urd.ParseSyncPolicy(*fsync)
+	if err != nil {
+		log.Fatalf("Invalid -fsync: %v", err)
+	}
+	cfg.Log.Sync = policy
+	if cfg.Topics.OverflowPolicy, err = draupnir.ParseOverflowPolicy(*overflow); err != nil {
+		log.Fatalf("Invalid -default-overflow-policy: %v", err)
+	}
+
+	// Create server instance with topic tracking, recovering any persisted topics
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		log.Fatalf("Failed to start broker: %v", err)
+	}
+
+	grpcServer := runGRPCServer("tcp", grpcAddr, srv)
 	httpServer := runHTTPServer(grpcAddr, httpAddr)
 
 	// Signal handling for graceful shutdown
@@ -161,5 +209,11 @@ func main() {
 	log.Println("Shutting down gRPC server...")
 	grpcServer.Stop() // More reliable immediate shutdown
 
+	// Flush topic logs and consumer offsets
+	log.Println("Closing broker storage...")
+	if err := srv.Close(); err != nil {
+		log.Printf("Error closing broker storage: %v", err)
+	}
+
 	log.Println("Servers shut down successfully")
 }
@@ -1,131 +1,476 @@
 package draupnir
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/a1mart/kafkaesque/internal/generated/messaging"
 )
 
+// OverflowPolicy decides what Put does when the write cursor would lap the slowest consumer group
+type OverflowPolicy int
+
+const (
+	OverflowBlock      OverflowPolicy = iota // Wait for consumers to free slots, up to the overflow timeout
+	OverflowDropNewest                       // Discard the messages being written
+	OverflowDropOldest                       // Advance lagging consumer groups past the slots being reclaimed
+	OverflowFail                             // Return ErrFull immediately
+)
+
+// DefaultOverflowTimeout bounds how long a blocked producer waits for free slots
+const DefaultOverflowTimeout = 5 * time.Second
+
+var (
+	ErrFull    = errors.New("ring buffer full")
+	ErrDropped = errors.New("ring buffer full, messages dropped")
+	ErrTimeout = errors.New("timed out waiting for ring buffer capacity")
+)
+
+// ParseOverflowPolicy resolves "block", "drop_newest", "drop_oldest" or "fail"
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch s {
+	case "block", "":
+		return OverflowBlock, nil
+	case "drop_newest":
+		return OverflowDropNewest, nil
+	case "drop_oldest":
+		return OverflowDropOldest, nil
+	case "fail":
+		return OverflowFail, nil
+	default:
+		return 0, fmt.Errorf("unknown overflow policy %q", s)
+	}
+}
+
+// String returns the config name of the policy
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDropNewest:
+		return "drop_newest"
+	case OverflowDropOldest:
+		return "drop_oldest"
+	case OverflowFail:
+		return "fail"
+	default:
+		return "block"
+	}
+}
+
 // RingBuffer struct with advanced features
 type RingBuffer struct {
 	size         int
-	buffer       []interface{}
-	writeCursor  int64
-	ReadCursors  []int64
-	available    []int32 // Use int32 for availability tracking for atomic operations
-	writeBarrier int64
-	readBarrier  int64
+	buffer       []atomic.Pointer[messaging.Message]
+	writeCursor  int64                             // Next sequence to be claimed by a producer
+	cursors      atomic.Pointer[map[string]*int64] // Read cursor of each named consumer; replaced wholesale on register/unregister
+	cursorsMu    sync.Mutex                        // Serializes changes to cursors
+	available    []int64                           // Sequence last published into each slot, so readers never see a stale lap
+	readBarrier  int64                             // Cached minimum of the read cursors; producers may claim up to readBarrier + size
+	overflow     atomic.Pointer[overflowSettings]  // What Put does when the buffer is full; swapped by SetOverflowPolicy
+	waitStrategy WaitStrategy
 }
 
-// NewRingBuffer creates a new RingBuffer
-func NewRingBuffer(size int, numConsumers int) *RingBuffer {
+// overflowSettings are swapped as a pair so producers never see a policy with another's timeout
+type overflowSettings struct {
+	policy  OverflowPolicy
+	timeout time.Duration
+}
+
+// NewRingBuffer creates a new RingBuffer with a read cursor for each named consumer
+func NewRingBuffer(size int, consumers ...string) *RingBuffer {
 	rb := &RingBuffer{
-		size:        size,
-		buffer:      make([]interface{}, size),
-		ReadCursors: make([]int64, numConsumers),
-		available:   make([]int32, size), // Use int32 for availability tracking
+		size:         size,
+		buffer:       make([]atomic.Pointer[messaging.Message], size),
+		available:    make([]int64, size),
+		readBarrier:  -1,
+		waitStrategy: NewBlockingWaitStrategy(),
+	}
+	rb.overflow.Store(&overflowSettings{policy: OverflowBlock, timeout: DefaultOverflowTimeout})
+	cursors := make(map[string]*int64, len(consumers))
+	for _, name := range consumers {
+		cursor := int64(-1)
+		cursors[name] = &cursor
 	}
-	for i := range rb.ReadCursors {
-		rb.ReadCursors[i] = -1
+	rb.cursors.Store(&cursors)
+	for i := range rb.available {
+		rb.available[i] = -1
 	}
 	return rb
 }
 
-// Get reads data from the ring buffer in a round-robin manner
-func (rb *RingBuffer) Get(batchSize int, consumerGroup int) []*messaging.Message {
-	results := make([]*messaging.Message, 0, batchSize)
+// AddConsumer registers a read cursor so the consumer's next read starts after seq. A consumer
+// cannot start behind messages the buffer no longer guarantees to hold: seq is raised to the
+// slowest existing consumer (or, with none, to the last written message). Returns false if the
+// consumer already exists.
+func (rb *RingBuffer) AddConsumer(name string, seq int64) bool {
+	rb.cursorsMu.Lock()
+	defer rb.cursorsMu.Unlock()
+
+	current := *rb.cursors.Load()
+	if _, exists := current[name]; exists {
+		return false
+	}
+	if floor := rb.minReadCursor(); seq < floor {
+		seq = floor
+	}
+	if last := atomic.LoadInt64(&rb.writeCursor) - 1; seq > last {
+		seq = last
+	}
+
+	next := make(map[string]*int64, len(current)+1)
+	for k, v := range current {
+		next[k] = v
+	}
+	next[name] = &seq
+	rb.cursors.Store(&next)
+	return true
+}
+
+// RemoveConsumer drops a consumer's read cursor so it no longer holds back producers
+func (rb *RingBuffer) RemoveConsumer(name string) {
+	rb.cursorsMu.Lock()
+	defer rb.cursorsMu.Unlock()
+
+	current := *rb.cursors.Load()
+	if _, exists := current[name]; !exists {
+		return
+	}
+	next := make(map[string]*int64, len(current))
+	for k, v := range current {
+		if k != name {
+			next[k] = v
+		}
+	}
+	rb.cursors.Store(&next)
+}
+
+// Consumers returns the names of the registered consumers, sorted
+func (rb *RingBuffer) Consumers() []string {
+	current := *rb.cursors.Load()
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cursor returns the read cursor of a consumer, or nil if it is not registered
+func (rb *RingBuffer) cursor(name string) *int64 {
+	return (*rb.cursors.Load())[name]
+}
+
+// SetOverflowPolicy changes how Put behaves when consumers lag a full buffer behind. It is safe
+// to call while producers are running; a Put already waiting keeps the settings it started with.
+// A zero timeout makes OverflowBlock wait indefinitely.
+func (rb *RingBuffer) SetOverflowPolicy(policy OverflowPolicy, timeout time.Duration) {
+	rb.overflow.Store(&overflowSettings{policy: policy, timeout: timeout})
+}
+
+// OverflowPolicy returns the policy applied when the buffer is full
+func (rb *RingBuffer) OverflowPolicy() OverflowPolicy {
+	return rb.overflow.Load().policy
+}
+
+// SetWaitStrategy changes how GetWait waits for new messages. Ring buffers sharing one strategy
+// can be waited on together.
+func (rb *RingBuffer) SetWaitStrategy(ws WaitStrategy) {
+	rb.waitStrategy = ws
+}
+
+// WaitStrategy returns the strategy consumers use to wait for new messages
+func (rb *RingBuffer) WaitStrategy() WaitStrategy {
+	return rb.waitStrategy
+}
+
+// Available reports whether the next message for a consumer has been published
+func (rb *RingBuffer) Available(consumer string) bool {
+	readCursor := rb.cursor(consumer)
+	if readCursor == nil {
+		return false
+	}
+	next := atomic.LoadInt64(readCursor) + 1
+	return atomic.LoadInt64(&rb.available[next%int64(rb.size)]) == next
+}
 
+// GetWait reads like Get, but when nothing is available waits (per the wait strategy) until a
+// message is published or ctx is done
+func (rb *RingBuffer) GetWait(ctx context.Context, batchSize int, consumer string) []*messaging.Message {
+	if results := rb.Get(batchSize, consumer); len(results) > 0 {
+		return results
+	}
+	if !rb.waitStrategy.WaitFor(ctx, func() bool { return rb.Available(consumer) }) {
+		return nil
+	}
+	return rb.Get(batchSize, consumer)
+}
+
+// Get reads up to batchSize messages after the consumer's read cursor. Reading never frees a slot
+// for other consumers; producers reuse it only once every consumer has moved past it.
+func (rb *RingBuffer) Get(batchSize int, consumer string) []*messaging.Message {
+	results, _ := rb.Read(batchSize, consumer)
+	return results
+}
+
+// Read is Get that also returns the sequence of the first message read. The batch is always
+// contiguous, so message i has sequence first + i.
+func (rb *RingBuffer) Read(batchSize int, consumer string) ([]*messaging.Message, int64) {
 	// Get the consumer's read cursor
-	readCursor := &rb.ReadCursors[consumerGroup] // Track per-group cursor
-	nextRead := atomic.LoadInt64(readCursor) + 1
+	readCursor := rb.cursor(consumer)
+	if readCursor == nil {
+		return nil, -1
+	}
+	results := make([]*messaging.Message, 0, batchSize)
+	current := atomic.LoadInt64(readCursor)
+	nextRead := current + 1
+	first := nextRead
 
 	for i := 0; i < batchSize; {
 		slot := nextRead % int64(rb.size) // Compute the ring buffer index
 
-		if atomic.LoadInt32(&rb.available[slot]) == 1 {
-			if msg, ok := rb.buffer[slot].(*messaging.Message); ok {
-				results = append(results, msg)
+		if atomic.LoadInt64(&rb.available[slot]) == nextRead {
+			msg := rb.buffer[slot].Load()
 
-				// Mark slot as consumed
-				atomic.StoreInt32(&rb.available[slot], 0)
-				atomic.StoreInt64(readCursor, nextRead) // Update cursor per group
-				nextRead++
-				i++ // Move to next batch item
+			// Claim the slot; if the cursor moved underneath us (drop-oldest reclaimed it) start over from
+			// there, unless that would leave a gap in the batch
+			if !atomic.CompareAndSwapInt64(readCursor, current, nextRead) {
+				if len(results) > 0 {
+					break
+				}
+				current = atomic.LoadInt64(readCursor)
+				nextRead = current + 1
+				first = nextRead
+				continue
 			}
+			results = append(results, msg)
+			current = nextRead
+			nextRead++
+			i++ // Move to next batch item
 		} else {
 			// If no available messages, break early to avoid unnecessary looping
 			break
 		}
 	}
 
-	return results
+	return results, first
 }
 
-// // Get reads data from the ring buffer in batches (lock-free)
-// func (rb *RingBuffer) Get(batchSize int, consumerId int) []*messaging.Message {
-// 	readCursor := (*int64)(unsafe.Pointer(&rb.ReadCursors[consumerId])) // Unsafe cast for direct memory access
-// 	nextRead := atomic.LoadInt64(readCursor) + 1
-// 	results := make([]*messaging.Message, batchSize)
-
-// 	index := 0 // Index for results slice
-// 	for i := 0; i < batchSize; {
-// 		if nextRead <= atomic.LoadInt64(&rb.writeCursor) {
-// 			// Log the availability before consuming the message
-// 			slot := nextRead % int64(rb.size)
-// 			log.Printf("Consumer %d attempting to consume from Slot %d, Available: %d", consumerId, slot, atomic.LoadInt32(&rb.available[slot]))
-
-// 			if atomic.LoadInt32(&rb.available[slot]) == 1 {
-// 				if msg, ok := rb.buffer[slot].(*messaging.Message); ok {
-// 					results[index] = msg
-// 					log.Printf("Consumer %d consumed message ID: %s from Slot %d", consumerId, msg.GetId(), slot)
-
-// 					// Mark the slot as unavailable after consumption
-// 					atomic.StoreInt32(&rb.available[slot], 0)
-// 					atomic.StoreInt64(readCursor, nextRead) // Update read cursor immediately after consumption
-// 					nextRead++
-// 					i++
-// 					index++
-// 				}
-// 			} else {
-// 				log.Printf("Consumer %d found Slot %d unavailable, retrying...", consumerId, slot)
-// 				// Delay if the slot is unavailable, adjust as needed
-// 				time.Sleep(1 * time.Microsecond)
-// 			}
-
-// 		} else {
-// 			log.Printf("Consumer %d: No more messages to consume", consumerId)
-// 			break
-// 		}
-// 	}
-
-// 	// Log the final state of the read cursor
-// 	log.Printf("Consumer %d finished consuming, readCursor: %d", consumerId, atomic.LoadInt64(readCursor))
-
-// 	// Update the read barrier
-// 	minReadCursor := atomic.LoadInt64(readCursor)
-// 	for _, cursor := range rb.ReadCursors {
-// 		if cursor < minReadCursor {
-// 			minReadCursor = cursor
-// 		}
-// 	}
-// 	atomic.StoreInt64(&rb.readBarrier, minReadCursor)
-
-// 	return results[:index]
-// }
-
-// Put writes data to the ring buffer in batches
-func (rb *RingBuffer) Put(data ...*messaging.Message) {
-	nextWrite := atomic.AddInt64(&rb.writeCursor, int64(len(data))) - int64(len(data))
+// Put writes data to the ring buffer in batches and returns the sequence of the first message written.
+// Producers never overwrite a slot the slowest consumer group has not read; when the batch does not
+// fit, the overflow policy decides whether to wait, drop or fail.
+func (rb *RingBuffer) Put(data ...*messaging.Message) (int64, error) {
+	n := int64(len(data))
+	if n > int64(rb.size) {
+		return -1, ErrFull
+	}
+
+	var deadline time.Time
+	var nextWrite int64
+	for {
+		current := atomic.LoadInt64(&rb.writeCursor)
+		if err := rb.gate(current+n, &deadline); err != nil {
+			return -1, err
+		}
+		if atomic.CompareAndSwapInt64(&rb.writeCursor, current, current+n) {
+			nextWrite = current
+			break
+		}
+	}
+
+	first := nextWrite
 	for _, d := range data {
 		slot := nextWrite % int64(rb.size)
-		rb.buffer[slot] = d
-		atomic.StoreInt32(&rb.available[slot], 1) // Mark as available
-		log.Printf("Put Message ID: %s at Slot: %d, WriteCursor: %d, Available: %d", d.GetId(), slot, nextWrite, atomic.LoadInt32(&rb.available[slot]))
+		rb.buffer[slot].Store(d)
+		atomic.StoreInt64(&rb.available[slot], nextWrite) // Publish the slot for this sequence
 		nextWrite++
 	}
+	rb.waitStrategy.Signal()
+	return first, nil
+}
+
+// AwaitCapacity applies the overflow policy until n more messages fit without claiming them.
+// Callers that serialize their own producers use it to check for room before committing
+// messages elsewhere (e.g. a durable log), so the following Put cannot fail.
+func (rb *RingBuffer) AwaitCapacity(n int) error {
+	if n > rb.size {
+		return ErrFull
+	}
+	var deadline time.Time
+	return rb.gate(atomic.LoadInt64(&rb.writeCursor)+int64(n), &deadline)
+}
+
+// gate returns once sequences below next can be written without lapping a consumer
+func (rb *RingBuffer) gate(next int64, deadline *time.Time) error {
+	wrapPoint := next - 1 - int64(rb.size) // Highest sequence every group must have read
+	backoff := time.Microsecond
+	overflow := rb.overflow.Load()
+
+	for wrapPoint > atomic.LoadInt64(&rb.readBarrier) {
+		gating := rb.minReadCursor()
+		atomic.StoreInt64(&rb.readBarrier, gating)
+		if wrapPoint <= gating {
+			return nil
+		}
+
+		switch overflow.policy {
+		case OverflowFail:
+			return ErrFull
+		case OverflowDropNewest:
+			return ErrDropped
+		case OverflowDropOldest:
+			rb.advanceLagging(wrapPoint)
+		default:
+			if deadline.IsZero() {
+				*deadline = time.Now().Add(overflow.timeout)
+			}
+			if overflow.timeout > 0 && time.Now().After(*deadline) {
+				return ErrTimeout
+			}
+			time.Sleep(backoff)
+			if backoff < time.Millisecond {
+				backoff *= 2
+			}
+		}
+	}
+	return nil
+}
+
+// advanceLagging moves every consumer still at or below seq - 1 to seq, giving up the oldest
+// unread messages so their slots can be reused
+func (rb *RingBuffer) advanceLagging(seq int64) {
+	for _, cursor := range *rb.cursors.Load() {
+		advance(cursor, seq)
+	}
+}
+
+// advance moves a cursor forward to seq unless it is already there or beyond
+func advance(cursor *int64, seq int64) {
+	for {
+		current := atomic.LoadInt64(cursor)
+		if current >= seq || atomic.CompareAndSwapInt64(cursor, current, seq) {
+			return
+		}
+	}
+}
+
+// minReadCursor returns the sequence of the slowest consumer
+func (rb *RingBuffer) minReadCursor() int64 {
+	minRead := atomic.LoadInt64(&rb.writeCursor) - 1
+	for _, cursor := range *rb.cursors.Load() {
+		if seq := atomic.LoadInt64(cursor); seq < minRead {
+			minRead = seq
+		}
+	}
+	return minRead
+}
+
+// ReadCursor returns the last sequence read by a consumer, or -1 if it is not registered
+func (rb *RingBuffer) ReadCursor(consumer string) int64 {
+	readCursor := rb.cursor(consumer)
+	if readCursor == nil {
+		return -1
+	}
+	return atomic.LoadInt64(readCursor)
+}
 
-	// Update the write cursor once all data is written
-	atomic.StoreInt64(&rb.writeCursor, nextWrite)
-	log.Printf("WriteCursor advanced to: %d", nextWrite)
+// ReadCursors returns the last sequence read by every consumer
+func (rb *RingBuffer) ReadCursors() map[string]int64 {
+	current := *rb.cursors.Load()
+	cursors := make(map[string]int64, len(current))
+	for name, cursor := range current {
+		cursors[name] = atomic.LoadInt64(cursor)
+	}
+	return cursors
+}
+
+// AdvanceReadCursor moves a consumer's cursor forward to seq; it never moves a cursor back
+func (rb *RingBuffer) AdvanceReadCursor(consumer string, seq int64) {
+	if readCursor := rb.cursor(consumer); readCursor != nil {
+		advance(readCursor, seq)
+	}
+}
+
+// WriteCursor returns the sequence the next message written will receive
+func (rb *RingBuffer) WriteCursor() int64 {
+	return atomic.LoadInt64(&rb.writeCursor)
+}
+
+// Depth returns the number of messages written but not yet read by the slowest consumer
+func (rb *RingBuffer) Depth() int64 {
+	return atomic.LoadInt64(&rb.writeCursor) - 1 - rb.minReadCursor()
+}
+
+// Size returns the number of slots in the ring buffer
+func (rb *RingBuffer) Size() int {
+	return rb.size
+}
+
+// Restore positions an empty ring buffer so the next message written receives sequence next,
+// as when rebuilding the hot tail of a durable log
+func (rb *RingBuffer) Restore(next int64) {
+	atomic.StoreInt64(&rb.writeCursor, next)
+	atomic.StoreInt64(&rb.readBarrier, next-1)
+	for _, cursor := range *rb.cursors.Load() {
+		atomic.StoreInt64(cursor, next-1)
+	}
+}
+
+// Seek moves a consumer's cursor, forward or back, so its next read starts after seq. A seek past
+// the last written message stops there. Seeking back fails, leaving the cursor alone, when the
+// buffer no longer holds (or never held) the message after seq. Producers must be held off while
+// seeking back, or one that already passed the gate may overwrite the slot being rewound to.
+func (rb *RingBuffer) Seek(consumer string, seq int64) bool {
+	readCursor := rb.cursor(consumer)
+	if readCursor == nil {
+		return false
+	}
+	if last := atomic.LoadInt64(&rb.writeCursor) - 1; seq >= last {
+		seq = last
+	} else if !rb.holds(seq + 1) {
+		return false
+	}
+	atomic.StoreInt64(readCursor, seq)
+	atomic.StoreInt64(&rb.readBarrier, rb.minReadCursor())
+	return true
+}
+
+// At returns the message with sequence seq, if the buffer still holds it. Like Seek, it needs
+// producers held off to be sure the slot is not reused while it is read.
+func (rb *RingBuffer) At(seq int64) (*messaging.Message, bool) {
+	if !rb.holds(seq) {
+		return nil, false
+	}
+	return rb.buffer[seq%int64(rb.size)].Load(), true
+}
+
+// Expire drops the messages with sequences below seq that the buffer still holds, so they can be
+// garbage collected, and returns how many it dropped. Reads stop short of an expired message and
+// seeks to it fail; callers move affected cursors first. Like Seek, it needs producers held off.
+func (rb *RingBuffer) Expire(seq int64) int {
+	expired := 0
+	for s := max(seq-int64(rb.size), 0); s < seq; s++ {
+		slot := s % int64(rb.size)
+		if atomic.CompareAndSwapInt64(&rb.available[slot], s, -1) {
+			rb.buffer[slot].Store(nil)
+			expired++
+		}
+	}
+	return expired
+}
+
+// holds reports whether the message with sequence seq is still in the buffer
+func (rb *RingBuffer) holds(seq int64) bool {
+	return seq >= 0 && atomic.LoadInt64(&rb.available[seq%int64(rb.size)]) == seq
+}
+
+// SetReadCursor moves a consumer's cursor so its next read starts after seq
+func (rb *RingBuffer) SetReadCursor(consumer string, seq int64) {
+	if readCursor := rb.cursor(consumer); readCursor != nil {
+		atomic.StoreInt64(readCursor, seq)
+		atomic.StoreInt64(&rb.readBarrier, rb.minReadCursor())
+	}
 }
@@ -1,9 +1,13 @@
 package draupnir_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/a1mart/kafkaesque/internal/draupnir"
 	"github.com/a1mart/kafkaesque/internal/generated/messaging"
@@ -12,27 +16,27 @@ import (
 // TestNewRingBuffer ensures the buffer initializes correctly.
 func TestNewRingBuffer(t *testing.T) {
 	size := 5
-	numConsumers := 2
-	rb := draupnir.NewRingBuffer(size, numConsumers)
+	consumers := []string{"billing", "audit"}
+	rb := draupnir.NewRingBuffer(size, consumers...)
 
 	if rb == nil {
 		t.Fatal("RingBuffer should not be nil")
 	}
-	if len(rb.ReadCursors) != numConsumers {
-		t.Errorf("Expected %d consumers, got %d", numConsumers, len(rb.ReadCursors))
+	if len(rb.Consumers()) != len(consumers) {
+		t.Errorf("Expected %d consumers, got %d", len(consumers), len(rb.Consumers()))
 	}
 }
 
 // TestPutAndGet ensures messages are written and read correctly.
 func TestPutAndGet(t *testing.T) {
-	rb := draupnir.NewRingBuffer(3, 1)
+	rb := draupnir.NewRingBuffer(3, "a")
 
 	msg1 := &messaging.Message{Id: "1"}
 	msg2 := &messaging.Message{Id: "2"}
 
 	rb.Put(msg1, msg2)
 
-	msgs := rb.Get(2, 0)
+	msgs := rb.Get(2, "a")
 
 	if len(msgs) != 2 {
 		t.Errorf("Expected 2 messages, got %d", len(msgs))
@@ -44,7 +48,7 @@ func TestPutAndGet(t *testing.T) {
 
 // TestRingBufferWrapAround ensures data wraps correctly when buffer is full.
 func TestRingBufferWrapAround(t *testing.T) {
-	rb := draupnir.NewRingBuffer(3, 1)
+	rb := draupnir.NewRingBuffer(3, "a")
 
 	msg1 := &messaging.Message{Id: "1"}
 	msg2 := &messaging.Message{Id: "2"}
@@ -52,10 +56,10 @@ func TestRingBufferWrapAround(t *testing.T) {
 	msg4 := &messaging.Message{Id: "4"}
 
 	rb.Put(msg1, msg2, msg3)
-	rb.Get(2, 0)
+	rb.Get(2, "a")
 	rb.Put(msg4)
 
-	msgs := rb.Get(2, 0)
+	msgs := rb.Get(2, "a")
 	if len(msgs) != 2 || msgs[0].Id != "3" || msgs[1].Id != "4" {
 		t.Error("Ring buffer wrap-around failed")
 	}
@@ -63,7 +67,7 @@ func TestRingBufferWrapAround(t *testing.T) {
 
 // TestConcurrentAccess ensures thread safety of Put and Get.
 func TestConcurrentAccess(t *testing.T) {
-	rb := draupnir.NewRingBuffer(10, 2)
+	rb := draupnir.NewRingBuffer(10, "a", "b")
 	var wg sync.WaitGroup
 
 	producer := func(id string) {
@@ -74,7 +78,7 @@ func TestConcurrentAccess(t *testing.T) {
 		}
 	}
 
-	consumer := func(group int) {
+	consumer := func(group string) {
 		defer wg.Done()
 		for i := 0; i < 5; i++ {
 			rb.Get(1, group)
@@ -84,14 +88,14 @@ func TestConcurrentAccess(t *testing.T) {
 	wg.Add(4)
 	go producer("A")
 	go producer("B")
-	go consumer(0)
-	go consumer(1)
+	go consumer("a")
+	go consumer("b")
 	wg.Wait()
 }
 
 // TestAtomicCounters ensures atomic operations work as expected.
 func TestAtomicCounters(t *testing.T) {
-	_ = draupnir.NewRingBuffer(5, 1)
+	_ = draupnir.NewRingBuffer(5, "a")
 	var writeCursor int64
 	atomic.StoreInt64(&writeCursor, 2)
 	atomic.AddInt64(&writeCursor, 1)
@@ -99,3 +103,304 @@ func TestAtomicCounters(t *testing.T) {
 		t.Error("Atomic counter did not increment correctly")
 	}
 }
+
+// putConcurrently runs producers goroutines that each Put perProducer messages and returns the errors they saw.
+func putConcurrently(rb *draupnir.RingBuffer, producers, perProducer int) []error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				if _, err := rb.Put(&messaging.Message{Id: fmt.Sprintf("%d-%d", p, i)}); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+	return errs
+}
+
+// TestOverflowBlock ensures blocked producers resume as the consumer frees slots and nothing is lost.
+func TestOverflowBlock(t *testing.T) {
+	rb := draupnir.NewRingBuffer(4, "a")
+	rb.SetOverflowPolicy(draupnir.OverflowBlock, 5*time.Second)
+
+	const producers, perProducer = 4, 25
+	seen := make(map[string]bool)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for len(seen) < producers*perProducer {
+			for _, msg := range rb.Get(2, "a") {
+				if seen[msg.Id] {
+					t.Errorf("Message %s delivered twice", msg.Id)
+				}
+				seen[msg.Id] = true
+			}
+		}
+	}()
+
+	if errs := putConcurrently(rb, producers, perProducer); len(errs) != 0 {
+		t.Fatalf("Expected no errors while blocking, got %v", errs)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Consumer only received %d of %d messages", len(seen), producers*perProducer)
+	}
+}
+
+// TestOverflowBlockTimeout ensures a blocked producer gives up once the timeout expires.
+func TestOverflowBlockTimeout(t *testing.T) {
+	rb := draupnir.NewRingBuffer(4, "a")
+	rb.SetOverflowPolicy(draupnir.OverflowBlock, 20*time.Millisecond)
+
+	errs := putConcurrently(rb, 3, 2)
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 timed out producers, got %v", errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, draupnir.ErrTimeout) {
+			t.Errorf("Expected ErrTimeout, got %v", err)
+		}
+	}
+}
+
+// TestOverflowDropNewest ensures messages beyond capacity are rejected and the unread ones kept.
+func TestOverflowDropNewest(t *testing.T) {
+	rb := draupnir.NewRingBuffer(8, "a", "b")
+	rb.SetOverflowPolicy(draupnir.OverflowDropNewest, 0)
+
+	errs := putConcurrently(rb, 4, 5)
+	if len(errs) != 12 {
+		t.Fatalf("Expected 12 dropped messages, got %d", len(errs))
+	}
+	for _, err := range errs {
+		if !errors.Is(err, draupnir.ErrDropped) {
+			t.Errorf("Expected ErrDropped, got %v", err)
+		}
+	}
+	if msgs := rb.Get(20, "b"); len(msgs) != 8 {
+		t.Errorf("Expected the first 8 messages to survive, got %d", len(msgs))
+	}
+}
+
+// TestOverflowDropOldest ensures producers never block and lagging consumers skip to the newest messages.
+func TestOverflowDropOldest(t *testing.T) {
+	rb := draupnir.NewRingBuffer(8, "a")
+	rb.SetOverflowPolicy(draupnir.OverflowDropOldest, 0)
+
+	if errs := putConcurrently(rb, 4, 10); len(errs) != 0 {
+		t.Fatalf("Expected no errors when dropping oldest, got %v", errs)
+	}
+	msgs := rb.Get(20, "a")
+	if len(msgs) != 8 {
+		t.Fatalf("Expected the newest 8 messages, got %d", len(msgs))
+	}
+	if cursor := rb.ReadCursor("a"); cursor != 39 {
+		t.Errorf("Expected consumer to finish at sequence 39, got %d", cursor)
+	}
+}
+
+// TestOverflowFail ensures Put fails fast with ErrFull once the slowest consumer is a full buffer behind.
+func TestOverflowFail(t *testing.T) {
+	rb := draupnir.NewRingBuffer(8, "a", "b")
+	rb.SetOverflowPolicy(draupnir.OverflowFail, 0)
+
+	errs := putConcurrently(rb, 4, 4)
+	if len(errs) != 8 {
+		t.Fatalf("Expected 8 failed puts, got %d", len(errs))
+	}
+	for _, err := range errs {
+		if !errors.Is(err, draupnir.ErrFull) {
+			t.Errorf("Expected ErrFull, got %v", err)
+		}
+	}
+
+	// Draining one group is not enough while the other still lags a full buffer behind
+	rb.Get(8, "a")
+	if _, err := rb.Put(&messaging.Message{Id: "blocked"}); !errors.Is(err, draupnir.ErrFull) {
+		t.Errorf("Expected ErrFull while group b lags, got %v", err)
+	}
+
+	rb.Get(4, "b")
+	if _, err := rb.Put(&messaging.Message{Id: "after"}); err != nil {
+		t.Errorf("Expected room after the slow group caught up, got %v", err)
+	}
+}
+
+// TestWaitStrategies ensures every wait strategy wakes a waiting consumer on publish and gives up at the deadline.
+func TestWaitStrategies(t *testing.T) {
+	for _, name := range []string{"blocking", "busy_spin", "yielding", "sleeping", "timeout"} {
+		t.Run(name, func(t *testing.T) {
+			ws, err := draupnir.ParseWaitStrategy(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rb := draupnir.NewRingBuffer(8, "a")
+			rb.SetWaitStrategy(ws)
+
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				rb.Put(&messaging.Message{Id: "late"})
+			}()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if results := rb.GetWait(ctx, 1, "a"); len(results) != 1 || results[0].Id != "late" {
+				t.Fatalf("Expected the late message, got %v", results)
+			}
+
+			ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			if results := rb.GetWait(ctx, 1, "a"); len(results) != 0 {
+				t.Errorf("Expected no messages, got %v", results)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Expected GetWait to give up at the deadline, waited %v", elapsed)
+			}
+		})
+	}
+}
+
+// TestConsumerGroupsSeeEveryMessage ensures reading in one group neither steals messages from nor frees slots for another.
+func TestConsumerGroupsSeeEveryMessage(t *testing.T) {
+	rb := draupnir.NewRingBuffer(4, "a", "b")
+	rb.SetOverflowPolicy(draupnir.OverflowFail, 0)
+
+	rb.Put(&messaging.Message{Id: "1"}, &messaging.Message{Id: "2"}, &messaging.Message{Id: "3"}, &messaging.Message{Id: "4"})
+	if msgs := rb.Get(4, "a"); len(msgs) != 4 {
+		t.Fatalf("Expected group a to read 4 messages, got %d", len(msgs))
+	}
+	if _, err := rb.Put(&messaging.Message{Id: "5"}); !errors.Is(err, draupnir.ErrFull) {
+		t.Errorf("Expected slots to stay reserved for group b, got %v", err)
+	}
+
+	msgs := rb.Get(4, "b")
+	if len(msgs) != 4 || msgs[0].Id != "1" || msgs[3].Id != "4" {
+		t.Fatalf("Expected group b to read every message, got %v", msgs)
+	}
+	if _, err := rb.Put(&messaging.Message{Id: "5"}); err != nil {
+		t.Errorf("Expected room once both groups passed the slot, got %v", err)
+	}
+}
+
+// TestAddRemoveConsumer ensures consumers can join and leave at runtime without losing retained messages or gating producers.
+func TestAddRemoveConsumer(t *testing.T) {
+	rb := draupnir.NewRingBuffer(4, "a")
+	rb.SetOverflowPolicy(draupnir.OverflowFail, 0)
+	rb.Put(&messaging.Message{Id: "1"}, &messaging.Message{Id: "2"})
+	rb.Get(1, "a")
+
+	// A late consumer cannot start behind the slowest one, since those slots may already be reused
+	if !rb.AddConsumer("late", -1) {
+		t.Fatal("Expected late consumer to be added")
+	}
+	if rb.AddConsumer("late", -1) {
+		t.Error("Expected duplicate consumer to be rejected")
+	}
+	if msgs := rb.Get(4, "late"); len(msgs) != 1 || msgs[0].Id != "2" {
+		t.Errorf("Expected late consumer to start at message 2, got %v", msgs)
+	}
+
+	rb.Put(&messaging.Message{Id: "3"}, &messaging.Message{Id: "4"}, &messaging.Message{Id: "5"})
+	if _, err := rb.Put(&messaging.Message{Id: "6"}); !errors.Is(err, draupnir.ErrFull) {
+		t.Fatalf("Expected group a to gate producers, got %v", err)
+	}
+	rb.RemoveConsumer("a")
+	if _, err := rb.Put(&messaging.Message{Id: "6"}); err != nil {
+		t.Errorf("Expected removed consumer to stop gating producers, got %v", err)
+	}
+	if got := rb.Consumers(); len(got) != 1 || got[0] != "late" {
+		t.Errorf("Expected only the late consumer, got %v", got)
+	}
+	if msgs := rb.Get(1, "a"); msgs != nil {
+		t.Errorf("Expected no messages for a removed consumer, got %v", msgs)
+	}
+}
+
+// TestReadReturnsFirstSequence ensures Read reports the sequence of the first message in its batch.
+func TestReadReturnsFirstSequence(t *testing.T) {
+	rb := draupnir.NewRingBuffer(8, "a")
+	rb.Put(&messaging.Message{Id: "1"}, &messaging.Message{Id: "2"}, &messaging.Message{Id: "3"})
+	rb.Get(1, "a")
+
+	msgs, first := rb.Read(8, "a")
+	if len(msgs) != 2 || first != 1 || msgs[0].Id != "2" {
+		t.Errorf("Expected 2 messages from sequence 1, got %d from %d", len(msgs), first)
+	}
+	if msgs, first := rb.Read(8, "missing"); msgs != nil || first != -1 {
+		t.Errorf("Expected nothing for an unknown consumer, got %v from %d", msgs, first)
+	}
+}
+
+// TestSeek ensures cursors move back only to held messages and At returns what is held.
+func TestSeek(t *testing.T) {
+	rb := draupnir.NewRingBuffer(4, "a")
+	for i := 0; i < 6; i++ {
+		rb.Put(&messaging.Message{Id: fmt.Sprint(i)})
+		rb.Get(1, "a")
+	}
+
+	// Sequences 2..5 are held; 0 and 1 have been overwritten
+	if !rb.Seek("a", 2) {
+		t.Fatal("Expected to seek back to a held message")
+	}
+	if msgs := rb.Get(4, "a"); len(msgs) != 3 || msgs[0].Id != "3" {
+		t.Errorf("Expected 3 messages from ID 3 after seeking, got %v", msgs)
+	}
+	if rb.Seek("a", 0) {
+		t.Error("Expected seeking to an overwritten message to fail")
+	}
+	if rb.ReadCursor("a") != 5 {
+		t.Errorf("Expected a failed seek to leave the cursor at 5, got %d", rb.ReadCursor("a"))
+	}
+	if !rb.Seek("a", 100) || rb.ReadCursor("a") != 5 {
+		t.Errorf("Expected a seek past the end to stop at 5, got %d", rb.ReadCursor("a"))
+	}
+
+	if msg, ok := rb.At(4); !ok || msg.Id != "4" {
+		t.Errorf("Expected message 4 at sequence 4, got %v", msg)
+	}
+	if _, ok := rb.At(1); ok {
+		t.Error("Expected no message at an overwritten sequence")
+	}
+}
+
+// TestExpire ensures expired messages are gone from reads and seeks while later ones are not.
+func TestExpire(t *testing.T) {
+	rb := draupnir.NewRingBuffer(4, "a", "b")
+	for i := 0; i < 6; i++ {
+		rb.Put(&messaging.Message{Id: fmt.Sprint(i)})
+		rb.Get(1, "a")
+		rb.Get(1, "b")
+	}
+	rb.Seek("b", 1)
+
+	// Sequences 2..5 are held; expiring below 4 drops 2 and 3 only
+	if n := rb.Expire(4); n != 2 {
+		t.Errorf("Expected 2 messages expired, got %d", n)
+	}
+	if _, ok := rb.At(3); ok {
+		t.Error("Expected no message at an expired sequence")
+	}
+	if msg, ok := rb.At(4); !ok || msg.Id != "4" {
+		t.Errorf("Expected message 4 to survive, got %v", msg)
+	}
+	if rb.Seek("a", 2) {
+		t.Error("Expected seeking to an expired message to fail")
+	}
+	if msgs := rb.Get(4, "b"); len(msgs) != 0 {
+		t.Errorf("Expected reads to stop at an expired message, got %v", msgs)
+	}
+	if n := rb.Expire(4); n != 0 {
+		t.Errorf("Expected nothing left to expire, got %d", n)
+	}
+}
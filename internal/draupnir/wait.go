@@ -0,0 +1,162 @@
+package draupnir
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	spinTries  = 100 // Checks before a yielding or sleeping strategy backs off
+	yieldTries = 100 // Yields before a sleeping strategy starts sleeping
+)
+
+// WaitStrategy decides how a consumer waits for producers to publish the next sequence
+type WaitStrategy interface {
+	// WaitFor returns true as soon as ready reports true, or false once ctx is done
+	WaitFor(ctx context.Context, ready func() bool) bool
+	// Signal wakes consumers after a producer publishes
+	Signal()
+}
+
+// ParseWaitStrategy resolves "blocking", "busy_spin", "yielding", "sleeping" or "timeout"
+func ParseWaitStrategy(name string) (WaitStrategy, error) {
+	switch name {
+	case "blocking", "":
+		return NewBlockingWaitStrategy(), nil
+	case "busy_spin":
+		return BusySpinWaitStrategy{}, nil
+	case "yielding":
+		return YieldingWaitStrategy{}, nil
+	case "sleeping":
+		return SleepingWaitStrategy{Sleep: 100 * time.Microsecond}, nil
+	case "timeout":
+		return NewTimeoutWaitStrategy(time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown wait strategy %q", name)
+	}
+}
+
+// BusySpinWaitStrategy re-checks in a tight loop: lowest latency, burns a core while waiting
+type BusySpinWaitStrategy struct{}
+
+func (BusySpinWaitStrategy) WaitFor(ctx context.Context, ready func() bool) bool {
+	for !ready() {
+		if ctx.Err() != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (BusySpinWaitStrategy) Signal() {}
+
+// YieldingWaitStrategy spins briefly, then yields the processor between checks
+type YieldingWaitStrategy struct{}
+
+func (YieldingWaitStrategy) WaitFor(ctx context.Context, ready func() bool) bool {
+	for tries := 0; !ready(); tries++ {
+		if ctx.Err() != nil {
+			return false
+		}
+		if tries >= spinTries {
+			runtime.Gosched()
+		}
+	}
+	return true
+}
+
+func (YieldingWaitStrategy) Signal() {}
+
+// SleepingWaitStrategy spins, then yields, then sleeps between checks: low CPU use at the cost of latency
+type SleepingWaitStrategy struct {
+	Sleep time.Duration
+}
+
+func (s SleepingWaitStrategy) WaitFor(ctx context.Context, ready func() bool) bool {
+	for tries := 0; !ready(); tries++ {
+		if ctx.Err() != nil {
+			return false
+		}
+		switch {
+		case tries < spinTries:
+		case tries < spinTries+yieldTries:
+			runtime.Gosched()
+		default:
+			time.Sleep(s.Sleep)
+		}
+	}
+	return true
+}
+
+func (SleepingWaitStrategy) Signal() {}
+
+// BlockingWaitStrategy parks consumers on a condition variable until a producer signals.
+// Producers only take the lock when someone is waiting.
+type BlockingWaitStrategy struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	waiters int32
+}
+
+func NewBlockingWaitStrategy() *BlockingWaitStrategy {
+	ws := &BlockingWaitStrategy{}
+	ws.cond = sync.NewCond(&ws.mu)
+	return ws
+}
+
+func (ws *BlockingWaitStrategy) WaitFor(ctx context.Context, ready func() bool) bool {
+	if ready() {
+		return true
+	}
+
+	// Wake the condition variable when ctx ends so Wait cannot outlive the caller's deadline
+	stop := context.AfterFunc(ctx, func() {
+		ws.mu.Lock()
+		ws.cond.Broadcast()
+		ws.mu.Unlock()
+	})
+	defer stop()
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	atomic.AddInt32(&ws.waiters, 1)
+	defer atomic.AddInt32(&ws.waiters, -1)
+
+	for !ready() {
+		if ctx.Err() != nil {
+			return false
+		}
+		ws.cond.Wait()
+	}
+	return true
+}
+
+func (ws *BlockingWaitStrategy) Signal() {
+	if atomic.LoadInt32(&ws.waiters) == 0 {
+		return
+	}
+	ws.mu.Lock()
+	ws.cond.Broadcast()
+	ws.mu.Unlock()
+}
+
+// TimeoutWaitStrategy blocks like BlockingWaitStrategy but never waits longer than Timeout,
+// whatever deadline the caller asked for
+type TimeoutWaitStrategy struct {
+	*BlockingWaitStrategy
+	Timeout time.Duration
+}
+
+func NewTimeoutWaitStrategy(timeout time.Duration) *TimeoutWaitStrategy {
+	return &TimeoutWaitStrategy{BlockingWaitStrategy: NewBlockingWaitStrategy(), Timeout: timeout}
+}
+
+func (ws *TimeoutWaitStrategy) WaitFor(ctx context.Context, ready func() bool) bool {
+	ctx, cancel := context.WithTimeout(ctx, ws.Timeout)
+	defer cancel()
+	return ws.BlockingWaitStrategy.WaitFor(ctx, ready)
+}
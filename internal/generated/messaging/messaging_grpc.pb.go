@@ -19,11 +19,25 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	MessagingService_Publish_FullMethodName               = "/messaging.MessagingService/Publish"
-	MessagingService_Consume_FullMethodName               = "/messaging.MessagingService/Consume"
-	MessagingService_Acknowledge_FullMethodName           = "/messaging.MessagingService/Acknowledge"
-	MessagingService_GetDeadLetters_FullMethodName        = "/messaging.MessagingService/GetDeadLetters"
-	MessagingService_RegisterConsumerGroup_FullMethodName = "/messaging.MessagingService/RegisterConsumerGroup"
+	MessagingService_Publish_FullMethodName                 = "/messaging.MessagingService/Publish"
+	MessagingService_PublishBatch_FullMethodName            = "/messaging.MessagingService/PublishBatch"
+	MessagingService_CancelScheduled_FullMethodName         = "/messaging.MessagingService/CancelScheduled"
+	MessagingService_PublishStream_FullMethodName           = "/messaging.MessagingService/PublishStream"
+	MessagingService_Consume_FullMethodName                 = "/messaging.MessagingService/Consume"
+	MessagingService_Seek_FullMethodName                    = "/messaging.MessagingService/Seek"
+	MessagingService_Stream_FullMethodName                  = "/messaging.MessagingService/Stream"
+	MessagingService_Acknowledge_FullMethodName             = "/messaging.MessagingService/Acknowledge"
+	MessagingService_Nack_FullMethodName                    = "/messaging.MessagingService/Nack"
+	MessagingService_BeginTransaction_FullMethodName        = "/messaging.MessagingService/BeginTransaction"
+	MessagingService_CommitTransaction_FullMethodName       = "/messaging.MessagingService/CommitTransaction"
+	MessagingService_AbortTransaction_FullMethodName        = "/messaging.MessagingService/AbortTransaction"
+	MessagingService_GetDeadLetters_FullMethodName          = "/messaging.MessagingService/GetDeadLetters"
+	MessagingService_ReprocessDeadLetter_FullMethodName     = "/messaging.MessagingService/ReprocessDeadLetter"
+	MessagingService_PurgeDeadLetter_FullMethodName         = "/messaging.MessagingService/PurgeDeadLetter"
+	MessagingService_RegisterConsumerGroup_FullMethodName   = "/messaging.MessagingService/RegisterConsumerGroup"
+	MessagingService_UnregisterConsumerGroup_FullMethodName = "/messaging.MessagingService/UnregisterConsumerGroup"
+	MessagingService_PauseConsumer_FullMethodName           = "/messaging.MessagingService/PauseConsumer"
+	MessagingService_ResumeConsumer_FullMethodName          = "/messaging.MessagingService/ResumeConsumer"
 )
 
 // MessagingServiceClient is the client API for MessagingService service.
@@ -31,10 +45,31 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type MessagingServiceClient interface {
 	Publish(ctx context.Context, in *PublishRequest, opts ...grpc.CallOption) (*PublishResponse, error)
+	PublishBatch(ctx context.Context, in *PublishBatchRequest, opts ...grpc.CallOption) (*PublishBatchResponse, error)
+	CancelScheduled(ctx context.Context, in *CancelScheduledRequest, opts ...grpc.CallOption) (*CancelScheduledResponse, error)
+	// Client-streaming publish, gRPC only: the broker writes the streamed messages in batches and
+	// reports every result once the client closes the stream
+	PublishStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PublishRequest, PublishBatchResponse], error)
 	Consume(ctx context.Context, in *ConsumeRequest, opts ...grpc.CallOption) (*ConsumeResponse, error)
+	// Unacknowledged deliveries of the group on the sought partitions are dropped
+	Seek(ctx context.Context, in *SeekRequest, opts ...grpc.CallOption) (*SeekResponse, error)
+	// Streamed over HTTP as newline-delimited JSON, one {"result": StreamResponse} object per line
+	Stream(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamResponse], error)
 	Acknowledge(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckResponse, error)
+	Nack(ctx context.Context, in *NackRequest, opts ...grpc.CallOption) (*NackResponse, error)
+	BeginTransaction(ctx context.Context, in *BeginTransactionRequest, opts ...grpc.CallOption) (*BeginTransactionResponse, error)
+	CommitTransaction(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*CommitTransactionResponse, error)
+	AbortTransaction(ctx context.Context, in *AbortTransactionRequest, opts ...grpc.CallOption) (*AbortTransactionResponse, error)
 	GetDeadLetters(ctx context.Context, in *DeadLetterRequest, opts ...grpc.CallOption) (*DeadLetterResponse, error)
+	ReprocessDeadLetter(ctx context.Context, in *ReprocessDeadLetterRequest, opts ...grpc.CallOption) (*ReprocessDeadLetterResponse, error)
+	PurgeDeadLetter(ctx context.Context, in *PurgeDeadLetterRequest, opts ...grpc.CallOption) (*PurgeDeadLetterResponse, error)
 	RegisterConsumerGroup(ctx context.Context, in *RegisterConsumerRequest, opts ...grpc.CallOption) (*RegisterConsumerResponse, error)
+	// Releases the group's cursors so the ring buffer slots they held can be reused
+	UnregisterConsumerGroup(ctx context.Context, in *UnregisterConsumerRequest, opts ...grpc.CallOption) (*UnregisterConsumerResponse, error)
+	// A paused group holds producers back only until it lags the broker's paused lag bound; with
+	// persistence it then catches up from the log once resumed, in memory it loses the oldest messages
+	PauseConsumer(ctx context.Context, in *PauseConsumerRequest, opts ...grpc.CallOption) (*PauseConsumerResponse, error)
+	ResumeConsumer(ctx context.Context, in *ResumeConsumerRequest, opts ...grpc.CallOption) (*ResumeConsumerResponse, error)
 }
 
 type messagingServiceClient struct {
@@ -55,6 +90,39 @@ func (c *messagingServiceClient) Publish(ctx context.Context, in *PublishRequest
 	return out, nil
 }
 
+func (c *messagingServiceClient) PublishBatch(ctx context.Context, in *PublishBatchRequest, opts ...grpc.CallOption) (*PublishBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublishBatchResponse)
+	err := c.cc.Invoke(ctx, MessagingService_PublishBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messagingServiceClient) CancelScheduled(ctx context.Context, in *CancelScheduledRequest, opts ...grpc.CallOption) (*CancelScheduledResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelScheduledResponse)
+	err := c.cc.Invoke(ctx, MessagingService_CancelScheduled_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messagingServiceClient) PublishStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PublishRequest, PublishBatchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MessagingService_ServiceDesc.Streams[0], MessagingService_PublishStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PublishRequest, PublishBatchResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessagingService_PublishStreamClient = grpc.ClientStreamingClient[PublishRequest, PublishBatchResponse]
+
 func (c *messagingServiceClient) Consume(ctx context.Context, in *ConsumeRequest, opts ...grpc.CallOption) (*ConsumeResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ConsumeResponse)
@@ -65,6 +133,35 @@ func (c *messagingServiceClient) Consume(ctx context.Context, in *ConsumeRequest
 	return out, nil
 }
 
+func (c *messagingServiceClient) Seek(ctx context.Context, in *SeekRequest, opts ...grpc.CallOption) (*SeekResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SeekResponse)
+	err := c.cc.Invoke(ctx, MessagingService_Seek_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messagingServiceClient) Stream(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MessagingService_ServiceDesc.Streams[1], MessagingService_Stream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamRequest, StreamResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessagingService_StreamClient = grpc.ServerStreamingClient[StreamResponse]
+
 func (c *messagingServiceClient) Acknowledge(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(AckResponse)
@@ -75,6 +172,46 @@ func (c *messagingServiceClient) Acknowledge(ctx context.Context, in *AckRequest
 	return out, nil
 }
 
+func (c *messagingServiceClient) Nack(ctx context.Context, in *NackRequest, opts ...grpc.CallOption) (*NackResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NackResponse)
+	err := c.cc.Invoke(ctx, MessagingService_Nack_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messagingServiceClient) BeginTransaction(ctx context.Context, in *BeginTransactionRequest, opts ...grpc.CallOption) (*BeginTransactionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BeginTransactionResponse)
+	err := c.cc.Invoke(ctx, MessagingService_BeginTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messagingServiceClient) CommitTransaction(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*CommitTransactionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommitTransactionResponse)
+	err := c.cc.Invoke(ctx, MessagingService_CommitTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messagingServiceClient) AbortTransaction(ctx context.Context, in *AbortTransactionRequest, opts ...grpc.CallOption) (*AbortTransactionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AbortTransactionResponse)
+	err := c.cc.Invoke(ctx, MessagingService_AbortTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *messagingServiceClient) GetDeadLetters(ctx context.Context, in *DeadLetterRequest, opts ...grpc.CallOption) (*DeadLetterResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(DeadLetterResponse)
@@ -85,6 +222,26 @@ func (c *messagingServiceClient) GetDeadLetters(ctx context.Context, in *DeadLet
 	return out, nil
 }
 
+func (c *messagingServiceClient) ReprocessDeadLetter(ctx context.Context, in *ReprocessDeadLetterRequest, opts ...grpc.CallOption) (*ReprocessDeadLetterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReprocessDeadLetterResponse)
+	err := c.cc.Invoke(ctx, MessagingService_ReprocessDeadLetter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messagingServiceClient) PurgeDeadLetter(ctx context.Context, in *PurgeDeadLetterRequest, opts ...grpc.CallOption) (*PurgeDeadLetterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PurgeDeadLetterResponse)
+	err := c.cc.Invoke(ctx, MessagingService_PurgeDeadLetter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *messagingServiceClient) RegisterConsumerGroup(ctx context.Context, in *RegisterConsumerRequest, opts ...grpc.CallOption) (*RegisterConsumerResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(RegisterConsumerResponse)
@@ -95,15 +252,66 @@ func (c *messagingServiceClient) RegisterConsumerGroup(ctx context.Context, in *
 	return out, nil
 }
 
+func (c *messagingServiceClient) UnregisterConsumerGroup(ctx context.Context, in *UnregisterConsumerRequest, opts ...grpc.CallOption) (*UnregisterConsumerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnregisterConsumerResponse)
+	err := c.cc.Invoke(ctx, MessagingService_UnregisterConsumerGroup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messagingServiceClient) PauseConsumer(ctx context.Context, in *PauseConsumerRequest, opts ...grpc.CallOption) (*PauseConsumerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PauseConsumerResponse)
+	err := c.cc.Invoke(ctx, MessagingService_PauseConsumer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messagingServiceClient) ResumeConsumer(ctx context.Context, in *ResumeConsumerRequest, opts ...grpc.CallOption) (*ResumeConsumerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResumeConsumerResponse)
+	err := c.cc.Invoke(ctx, MessagingService_ResumeConsumer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MessagingServiceServer is the server API for MessagingService service.
 // All implementations must embed UnimplementedMessagingServiceServer
 // for forward compatibility.
 type MessagingServiceServer interface {
 	Publish(context.Context, *PublishRequest) (*PublishResponse, error)
+	PublishBatch(context.Context, *PublishBatchRequest) (*PublishBatchResponse, error)
+	CancelScheduled(context.Context, *CancelScheduledRequest) (*CancelScheduledResponse, error)
+	// Client-streaming publish, gRPC only: the broker writes the streamed messages in batches and
+	// reports every result once the client closes the stream
+	PublishStream(grpc.ClientStreamingServer[PublishRequest, PublishBatchResponse]) error
 	Consume(context.Context, *ConsumeRequest) (*ConsumeResponse, error)
+	// Unacknowledged deliveries of the group on the sought partitions are dropped
+	Seek(context.Context, *SeekRequest) (*SeekResponse, error)
+	// Streamed over HTTP as newline-delimited JSON, one {"result": StreamResponse} object per line
+	Stream(*StreamRequest, grpc.ServerStreamingServer[StreamResponse]) error
 	Acknowledge(context.Context, *AckRequest) (*AckResponse, error)
+	Nack(context.Context, *NackRequest) (*NackResponse, error)
+	BeginTransaction(context.Context, *BeginTransactionRequest) (*BeginTransactionResponse, error)
+	CommitTransaction(context.Context, *CommitTransactionRequest) (*CommitTransactionResponse, error)
+	AbortTransaction(context.Context, *AbortTransactionRequest) (*AbortTransactionResponse, error)
 	GetDeadLetters(context.Context, *DeadLetterRequest) (*DeadLetterResponse, error)
+	ReprocessDeadLetter(context.Context, *ReprocessDeadLetterRequest) (*ReprocessDeadLetterResponse, error)
+	PurgeDeadLetter(context.Context, *PurgeDeadLetterRequest) (*PurgeDeadLetterResponse, error)
 	RegisterConsumerGroup(context.Context, *RegisterConsumerRequest) (*RegisterConsumerResponse, error)
+	// Releases the group's cursors so the ring buffer slots they held can be reused
+	UnregisterConsumerGroup(context.Context, *UnregisterConsumerRequest) (*UnregisterConsumerResponse, error)
+	// A paused group holds producers back only until it lags the broker's paused lag bound; with
+	// persistence it then catches up from the log once resumed, in memory it loses the oldest messages
+	PauseConsumer(context.Context, *PauseConsumerRequest) (*PauseConsumerResponse, error)
+	ResumeConsumer(context.Context, *ResumeConsumerRequest) (*ResumeConsumerResponse, error)
 	mustEmbedUnimplementedMessagingServiceServer()
 }
 
@@ -117,18 +325,60 @@ type UnimplementedMessagingServiceServer struct{}
 func (UnimplementedMessagingServiceServer) Publish(context.Context, *PublishRequest) (*PublishResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Publish not implemented")
 }
+func (UnimplementedMessagingServiceServer) PublishBatch(context.Context, *PublishBatchRequest) (*PublishBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishBatch not implemented")
+}
+func (UnimplementedMessagingServiceServer) CancelScheduled(context.Context, *CancelScheduledRequest) (*CancelScheduledResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelScheduled not implemented")
+}
+func (UnimplementedMessagingServiceServer) PublishStream(grpc.ClientStreamingServer[PublishRequest, PublishBatchResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method PublishStream not implemented")
+}
 func (UnimplementedMessagingServiceServer) Consume(context.Context, *ConsumeRequest) (*ConsumeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Consume not implemented")
 }
+func (UnimplementedMessagingServiceServer) Seek(context.Context, *SeekRequest) (*SeekResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Seek not implemented")
+}
+func (UnimplementedMessagingServiceServer) Stream(*StreamRequest, grpc.ServerStreamingServer[StreamResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
 func (UnimplementedMessagingServiceServer) Acknowledge(context.Context, *AckRequest) (*AckResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Acknowledge not implemented")
 }
+func (UnimplementedMessagingServiceServer) Nack(context.Context, *NackRequest) (*NackResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Nack not implemented")
+}
+func (UnimplementedMessagingServiceServer) BeginTransaction(context.Context, *BeginTransactionRequest) (*BeginTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BeginTransaction not implemented")
+}
+func (UnimplementedMessagingServiceServer) CommitTransaction(context.Context, *CommitTransactionRequest) (*CommitTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommitTransaction not implemented")
+}
+func (UnimplementedMessagingServiceServer) AbortTransaction(context.Context, *AbortTransactionRequest) (*AbortTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbortTransaction not implemented")
+}
 func (UnimplementedMessagingServiceServer) GetDeadLetters(context.Context, *DeadLetterRequest) (*DeadLetterResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetDeadLetters not implemented")
 }
+func (UnimplementedMessagingServiceServer) ReprocessDeadLetter(context.Context, *ReprocessDeadLetterRequest) (*ReprocessDeadLetterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReprocessDeadLetter not implemented")
+}
+func (UnimplementedMessagingServiceServer) PurgeDeadLetter(context.Context, *PurgeDeadLetterRequest) (*PurgeDeadLetterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeDeadLetter not implemented")
+}
 func (UnimplementedMessagingServiceServer) RegisterConsumerGroup(context.Context, *RegisterConsumerRequest) (*RegisterConsumerResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RegisterConsumerGroup not implemented")
 }
+func (UnimplementedMessagingServiceServer) UnregisterConsumerGroup(context.Context, *UnregisterConsumerRequest) (*UnregisterConsumerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnregisterConsumerGroup not implemented")
+}
+func (UnimplementedMessagingServiceServer) PauseConsumer(context.Context, *PauseConsumerRequest) (*PauseConsumerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseConsumer not implemented")
+}
+func (UnimplementedMessagingServiceServer) ResumeConsumer(context.Context, *ResumeConsumerRequest) (*ResumeConsumerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeConsumer not implemented")
+}
 func (UnimplementedMessagingServiceServer) mustEmbedUnimplementedMessagingServiceServer() {}
 func (UnimplementedMessagingServiceServer) testEmbeddedByValue()                          {}
 
@@ -168,6 +418,49 @@ func _MessagingService_Publish_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MessagingService_PublishBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublishBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServiceServer).PublishBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessagingService_PublishBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServiceServer).PublishBatch(ctx, req.(*PublishBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessagingService_CancelScheduled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelScheduledRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServiceServer).CancelScheduled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessagingService_CancelScheduled_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServiceServer).CancelScheduled(ctx, req.(*CancelScheduledRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessagingService_PublishStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MessagingServiceServer).PublishStream(&grpc.GenericServerStream[PublishRequest, PublishBatchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessagingService_PublishStreamServer = grpc.ClientStreamingServer[PublishRequest, PublishBatchResponse]
+
 func _MessagingService_Consume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ConsumeRequest)
 	if err := dec(in); err != nil {
@@ -186,6 +479,35 @@ func _MessagingService_Consume_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MessagingService_Seek_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SeekRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServiceServer).Seek(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessagingService_Seek_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServiceServer).Seek(ctx, req.(*SeekRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessagingService_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MessagingServiceServer).Stream(m, &grpc.GenericServerStream[StreamRequest, StreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessagingService_StreamServer = grpc.ServerStreamingServer[StreamResponse]
+
 func _MessagingService_Acknowledge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(AckRequest)
 	if err := dec(in); err != nil {
@@ -204,6 +526,78 @@ func _MessagingService_Acknowledge_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MessagingService_Nack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServiceServer).Nack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessagingService_Nack_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServiceServer).Nack(ctx, req.(*NackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessagingService_BeginTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BeginTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServiceServer).BeginTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessagingService_BeginTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServiceServer).BeginTransaction(ctx, req.(*BeginTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessagingService_CommitTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServiceServer).CommitTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessagingService_CommitTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServiceServer).CommitTransaction(ctx, req.(*CommitTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessagingService_AbortTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AbortTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServiceServer).AbortTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessagingService_AbortTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServiceServer).AbortTransaction(ctx, req.(*AbortTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _MessagingService_GetDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeadLetterRequest)
 	if err := dec(in); err != nil {
@@ -222,6 +616,42 @@ func _MessagingService_GetDeadLetters_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MessagingService_ReprocessDeadLetter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReprocessDeadLetterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServiceServer).ReprocessDeadLetter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessagingService_ReprocessDeadLetter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServiceServer).ReprocessDeadLetter(ctx, req.(*ReprocessDeadLetterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessagingService_PurgeDeadLetter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeDeadLetterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServiceServer).PurgeDeadLetter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessagingService_PurgeDeadLetter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServiceServer).PurgeDeadLetter(ctx, req.(*PurgeDeadLetterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _MessagingService_RegisterConsumerGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RegisterConsumerRequest)
 	if err := dec(in); err != nil {
@@ -240,42 +670,160 @@ func _MessagingService_RegisterConsumerGroup_Handler(srv interface{}, ctx contex
 	return interceptor(ctx, in, info, handler)
 }
 
-// MessagingService_ServiceDesc is the grpc.ServiceDesc for MessagingService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var MessagingService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "messaging.MessagingService",
-	HandlerType: (*MessagingServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Publish",
-			Handler:    _MessagingService_Publish_Handler,
-		},
-		{
-			MethodName: "Consume",
-			Handler:    _MessagingService_Consume_Handler,
-		},
-		{
+func _MessagingService_UnregisterConsumerGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnregisterConsumerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServiceServer).UnregisterConsumerGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessagingService_UnregisterConsumerGroup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServiceServer).UnregisterConsumerGroup(ctx, req.(*UnregisterConsumerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessagingService_PauseConsumer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseConsumerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServiceServer).PauseConsumer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessagingService_PauseConsumer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServiceServer).PauseConsumer(ctx, req.(*PauseConsumerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessagingService_ResumeConsumer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeConsumerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessagingServiceServer).ResumeConsumer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessagingService_ResumeConsumer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessagingServiceServer).ResumeConsumer(ctx, req.(*ResumeConsumerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MessagingService_ServiceDesc is the grpc.ServiceDesc for MessagingService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MessagingService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "messaging.MessagingService",
+	HandlerType: (*MessagingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Publish",
+			Handler:    _MessagingService_Publish_Handler,
+		},
+		{
+			MethodName: "PublishBatch",
+			Handler:    _MessagingService_PublishBatch_Handler,
+		},
+		{
+			MethodName: "CancelScheduled",
+			Handler:    _MessagingService_CancelScheduled_Handler,
+		},
+		{
+			MethodName: "Consume",
+			Handler:    _MessagingService_Consume_Handler,
+		},
+		{
+			MethodName: "Seek",
+			Handler:    _MessagingService_Seek_Handler,
+		},
+		{
 			MethodName: "Acknowledge",
 			Handler:    _MessagingService_Acknowledge_Handler,
 		},
+		{
+			MethodName: "Nack",
+			Handler:    _MessagingService_Nack_Handler,
+		},
+		{
+			MethodName: "BeginTransaction",
+			Handler:    _MessagingService_BeginTransaction_Handler,
+		},
+		{
+			MethodName: "CommitTransaction",
+			Handler:    _MessagingService_CommitTransaction_Handler,
+		},
+		{
+			MethodName: "AbortTransaction",
+			Handler:    _MessagingService_AbortTransaction_Handler,
+		},
 		{
 			MethodName: "GetDeadLetters",
 			Handler:    _MessagingService_GetDeadLetters_Handler,
 		},
+		{
+			MethodName: "ReprocessDeadLetter",
+			Handler:    _MessagingService_ReprocessDeadLetter_Handler,
+		},
+		{
+			MethodName: "PurgeDeadLetter",
+			Handler:    _MessagingService_PurgeDeadLetter_Handler,
+		},
 		{
 			MethodName: "RegisterConsumerGroup",
 			Handler:    _MessagingService_RegisterConsumerGroup_Handler,
 		},
+		{
+			MethodName: "UnregisterConsumerGroup",
+			Handler:    _MessagingService_UnregisterConsumerGroup_Handler,
+		},
+		{
+			MethodName: "PauseConsumer",
+			Handler:    _MessagingService_PauseConsumer_Handler,
+		},
+		{
+			MethodName: "ResumeConsumer",
+			Handler:    _MessagingService_ResumeConsumer_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "PublishStream",
+			Handler:       _MessagingService_PublishStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Stream",
+			Handler:       _MessagingService_Stream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/proto/messaging.proto",
 }
 
 const (
-	AdminService_CreateTopic_FullMethodName   = "/messaging.AdminService/CreateTopic"
-	AdminService_ListTopics_FullMethodName    = "/messaging.AdminService/ListTopics"
-	AdminService_ListConsumers_FullMethodName = "/messaging.AdminService/ListConsumers"
+	AdminService_CreateTopic_FullMethodName       = "/messaging.AdminService/CreateTopic"
+	AdminService_ListTopics_FullMethodName        = "/messaging.AdminService/ListTopics"
+	AdminService_ListConsumers_FullMethodName     = "/messaging.AdminService/ListConsumers"
+	AdminService_ListPartitions_FullMethodName    = "/messaging.AdminService/ListPartitions"
+	AdminService_DescribeTopic_FullMethodName     = "/messaging.AdminService/DescribeTopic"
+	AdminService_AlterTopicConfig_FullMethodName  = "/messaging.AdminService/AlterTopicConfig"
+	AdminService_DeleteTopic_FullMethodName       = "/messaging.AdminService/DeleteTopic"
+	AdminService_SetConsumerWeight_FullMethodName = "/messaging.AdminService/SetConsumerWeight"
 )
 
 // AdminServiceClient is the client API for AdminService service.
@@ -285,6 +833,11 @@ type AdminServiceClient interface {
 	CreateTopic(ctx context.Context, in *CreateTopicRequest, opts ...grpc.CallOption) (*CreateTopicResponse, error)
 	ListTopics(ctx context.Context, in *ListTopicsRequest, opts ...grpc.CallOption) (*ListTopicsResponse, error)
 	ListConsumers(ctx context.Context, in *ListConsumersRequest, opts ...grpc.CallOption) (*ListConsumersResponse, error)
+	ListPartitions(ctx context.Context, in *ListPartitionsRequest, opts ...grpc.CallOption) (*ListPartitionsResponse, error)
+	DescribeTopic(ctx context.Context, in *DescribeTopicRequest, opts ...grpc.CallOption) (*DescribeTopicResponse, error)
+	AlterTopicConfig(ctx context.Context, in *AlterTopicConfigRequest, opts ...grpc.CallOption) (*AlterTopicConfigResponse, error)
+	DeleteTopic(ctx context.Context, in *DeleteTopicRequest, opts ...grpc.CallOption) (*DeleteTopicResponse, error)
+	SetConsumerWeight(ctx context.Context, in *SetConsumerWeightRequest, opts ...grpc.CallOption) (*SetConsumerWeightResponse, error)
 }
 
 type adminServiceClient struct {
@@ -325,6 +878,56 @@ func (c *adminServiceClient) ListConsumers(ctx context.Context, in *ListConsumer
 	return out, nil
 }
 
+func (c *adminServiceClient) ListPartitions(ctx context.Context, in *ListPartitionsRequest, opts ...grpc.CallOption) (*ListPartitionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPartitionsResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListPartitions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) DescribeTopic(ctx context.Context, in *DescribeTopicRequest, opts ...grpc.CallOption) (*DescribeTopicResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DescribeTopicResponse)
+	err := c.cc.Invoke(ctx, AdminService_DescribeTopic_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) AlterTopicConfig(ctx context.Context, in *AlterTopicConfigRequest, opts ...grpc.CallOption) (*AlterTopicConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AlterTopicConfigResponse)
+	err := c.cc.Invoke(ctx, AdminService_AlterTopicConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) DeleteTopic(ctx context.Context, in *DeleteTopicRequest, opts ...grpc.CallOption) (*DeleteTopicResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteTopicResponse)
+	err := c.cc.Invoke(ctx, AdminService_DeleteTopic_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) SetConsumerWeight(ctx context.Context, in *SetConsumerWeightRequest, opts ...grpc.CallOption) (*SetConsumerWeightResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetConsumerWeightResponse)
+	err := c.cc.Invoke(ctx, AdminService_SetConsumerWeight_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AdminServiceServer is the server API for AdminService service.
 // All implementations must embed UnimplementedAdminServiceServer
 // for forward compatibility.
@@ -332,6 +935,11 @@ type AdminServiceServer interface {
 	CreateTopic(context.Context, *CreateTopicRequest) (*CreateTopicResponse, error)
 	ListTopics(context.Context, *ListTopicsRequest) (*ListTopicsResponse, error)
 	ListConsumers(context.Context, *ListConsumersRequest) (*ListConsumersResponse, error)
+	ListPartitions(context.Context, *ListPartitionsRequest) (*ListPartitionsResponse, error)
+	DescribeTopic(context.Context, *DescribeTopicRequest) (*DescribeTopicResponse, error)
+	AlterTopicConfig(context.Context, *AlterTopicConfigRequest) (*AlterTopicConfigResponse, error)
+	DeleteTopic(context.Context, *DeleteTopicRequest) (*DeleteTopicResponse, error)
+	SetConsumerWeight(context.Context, *SetConsumerWeightRequest) (*SetConsumerWeightResponse, error)
 	mustEmbedUnimplementedAdminServiceServer()
 }
 
@@ -351,6 +959,21 @@ func (UnimplementedAdminServiceServer) ListTopics(context.Context, *ListTopicsRe
 func (UnimplementedAdminServiceServer) ListConsumers(context.Context, *ListConsumersRequest) (*ListConsumersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListConsumers not implemented")
 }
+func (UnimplementedAdminServiceServer) ListPartitions(context.Context, *ListPartitionsRequest) (*ListPartitionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPartitions not implemented")
+}
+func (UnimplementedAdminServiceServer) DescribeTopic(context.Context, *DescribeTopicRequest) (*DescribeTopicResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DescribeTopic not implemented")
+}
+func (UnimplementedAdminServiceServer) AlterTopicConfig(context.Context, *AlterTopicConfigRequest) (*AlterTopicConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AlterTopicConfig not implemented")
+}
+func (UnimplementedAdminServiceServer) DeleteTopic(context.Context, *DeleteTopicRequest) (*DeleteTopicResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTopic not implemented")
+}
+func (UnimplementedAdminServiceServer) SetConsumerWeight(context.Context, *SetConsumerWeightRequest) (*SetConsumerWeightResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetConsumerWeight not implemented")
+}
 func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
 func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
 
@@ -426,6 +1049,96 @@ func _AdminService_ListConsumers_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AdminService_ListPartitions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPartitionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListPartitions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListPartitions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListPartitions(ctx, req.(*ListPartitionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_DescribeTopic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeTopicRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).DescribeTopic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_DescribeTopic_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).DescribeTopic(ctx, req.(*DescribeTopicRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_AlterTopicConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AlterTopicConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).AlterTopicConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_AlterTopicConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).AlterTopicConfig(ctx, req.(*AlterTopicConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_DeleteTopic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTopicRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).DeleteTopic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_DeleteTopic_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).DeleteTopic(ctx, req.(*DeleteTopicRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SetConsumerWeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetConsumerWeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SetConsumerWeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SetConsumerWeight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SetConsumerWeight(ctx, req.(*SetConsumerWeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -445,6 +1158,880 @@ var AdminService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListConsumers",
 			Handler:    _AdminService_ListConsumers_Handler,
 		},
+		{
+			MethodName: "ListPartitions",
+			Handler:    _AdminService_ListPartitions_Handler,
+		},
+		{
+			MethodName: "DescribeTopic",
+			Handler:    _AdminService_DescribeTopic_Handler,
+		},
+		{
+			MethodName: "AlterTopicConfig",
+			Handler:    _AdminService_AlterTopicConfig_Handler,
+		},
+		{
+			MethodName: "DeleteTopic",
+			Handler:    _AdminService_DeleteTopic_Handler,
+		},
+		{
+			MethodName: "SetConsumerWeight",
+			Handler:    _AdminService_SetConsumerWeight_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/proto/messaging.proto",
+}
+
+const (
+	SchemaRegistryService_Register_FullMethodName           = "/messaging.SchemaRegistryService/Register"
+	SchemaRegistryService_GetSchema_FullMethodName          = "/messaging.SchemaRegistryService/GetSchema"
+	SchemaRegistryService_GetLatestSchema_FullMethodName    = "/messaging.SchemaRegistryService/GetLatestSchema"
+	SchemaRegistryService_ListSchemas_FullMethodName        = "/messaging.SchemaRegistryService/ListSchemas"
+	SchemaRegistryService_ListSchemaVersions_FullMethodName = "/messaging.SchemaRegistryService/ListSchemaVersions"
+	SchemaRegistryService_CheckCompatibility_FullMethodName = "/messaging.SchemaRegistryService/CheckCompatibility"
+	SchemaRegistryService_DeleteSchema_FullMethodName       = "/messaging.SchemaRegistryService/DeleteSchema"
+	SchemaRegistryService_ValidateMessage_FullMethodName    = "/messaging.SchemaRegistryService/ValidateMessage"
+)
+
+// SchemaRegistryServiceClient is the client API for SchemaRegistryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Schema Registry Service Definition
+type SchemaRegistryServiceClient interface {
+	// Register a new schema or a new version of an existing schema
+	Register(ctx context.Context, in *RegisterSchemaRequest, opts ...grpc.CallOption) (*RegisterSchemaResponse, error)
+	// Get schema details by ID
+	GetSchema(ctx context.Context, in *GetSchemaRequest, opts ...grpc.CallOption) (*GetSchemaResponse, error)
+	// Get the latest version of a schema by name
+	GetLatestSchema(ctx context.Context, in *GetLatestSchemaRequest, opts ...grpc.CallOption) (*GetLatestSchemaResponse, error)
+	// List all registered schemas
+	ListSchemas(ctx context.Context, in *ListSchemasRequest, opts ...grpc.CallOption) (*ListSchemasResponse, error)
+	// List all versions of a specific schema
+	ListSchemaVersions(ctx context.Context, in *ListSchemaVersionsRequest, opts ...grpc.CallOption) (*ListSchemaVersionsResponse, error)
+	// Check if a new schema version is compatible with the existing schema
+	CheckCompatibility(ctx context.Context, in *CheckCompatibilityRequest, opts ...grpc.CallOption) (*CheckCompatibilityResponse, error)
+	// Delete a schema (soft delete)
+	DeleteSchema(ctx context.Context, in *DeleteSchemaRequest, opts ...grpc.CallOption) (*DeleteSchemaResponse, error)
+	// Validate a message against a registered schema
+	ValidateMessage(ctx context.Context, in *ValidateMessageRequest, opts ...grpc.CallOption) (*ValidateMessageResponse, error)
+}
+
+type schemaRegistryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSchemaRegistryServiceClient(cc grpc.ClientConnInterface) SchemaRegistryServiceClient {
+	return &schemaRegistryServiceClient{cc}
+}
+
+func (c *schemaRegistryServiceClient) Register(ctx context.Context, in *RegisterSchemaRequest, opts ...grpc.CallOption) (*RegisterSchemaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterSchemaResponse)
+	err := c.cc.Invoke(ctx, SchemaRegistryService_Register_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schemaRegistryServiceClient) GetSchema(ctx context.Context, in *GetSchemaRequest, opts ...grpc.CallOption) (*GetSchemaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSchemaResponse)
+	err := c.cc.Invoke(ctx, SchemaRegistryService_GetSchema_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schemaRegistryServiceClient) GetLatestSchema(ctx context.Context, in *GetLatestSchemaRequest, opts ...grpc.CallOption) (*GetLatestSchemaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetLatestSchemaResponse)
+	err := c.cc.Invoke(ctx, SchemaRegistryService_GetLatestSchema_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schemaRegistryServiceClient) ListSchemas(ctx context.Context, in *ListSchemasRequest, opts ...grpc.CallOption) (*ListSchemasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSchemasResponse)
+	err := c.cc.Invoke(ctx, SchemaRegistryService_ListSchemas_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schemaRegistryServiceClient) ListSchemaVersions(ctx context.Context, in *ListSchemaVersionsRequest, opts ...grpc.CallOption) (*ListSchemaVersionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSchemaVersionsResponse)
+	err := c.cc.Invoke(ctx, SchemaRegistryService_ListSchemaVersions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schemaRegistryServiceClient) CheckCompatibility(ctx context.Context, in *CheckCompatibilityRequest, opts ...grpc.CallOption) (*CheckCompatibilityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckCompatibilityResponse)
+	err := c.cc.Invoke(ctx, SchemaRegistryService_CheckCompatibility_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schemaRegistryServiceClient) DeleteSchema(ctx context.Context, in *DeleteSchemaRequest, opts ...grpc.CallOption) (*DeleteSchemaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteSchemaResponse)
+	err := c.cc.Invoke(ctx, SchemaRegistryService_DeleteSchema_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schemaRegistryServiceClient) ValidateMessage(ctx context.Context, in *ValidateMessageRequest, opts ...grpc.CallOption) (*ValidateMessageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateMessageResponse)
+	err := c.cc.Invoke(ctx, SchemaRegistryService_ValidateMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SchemaRegistryServiceServer is the server API for SchemaRegistryService service.
+// All implementations must embed UnimplementedSchemaRegistryServiceServer
+// for forward compatibility.
+//
+// Schema Registry Service Definition
+type SchemaRegistryServiceServer interface {
+	// Register a new schema or a new version of an existing schema
+	Register(context.Context, *RegisterSchemaRequest) (*RegisterSchemaResponse, error)
+	// Get schema details by ID
+	GetSchema(context.Context, *GetSchemaRequest) (*GetSchemaResponse, error)
+	// Get the latest version of a schema by name
+	GetLatestSchema(context.Context, *GetLatestSchemaRequest) (*GetLatestSchemaResponse, error)
+	// List all registered schemas
+	ListSchemas(context.Context, *ListSchemasRequest) (*ListSchemasResponse, error)
+	// List all versions of a specific schema
+	ListSchemaVersions(context.Context, *ListSchemaVersionsRequest) (*ListSchemaVersionsResponse, error)
+	// Check if a new schema version is compatible with the existing schema
+	CheckCompatibility(context.Context, *CheckCompatibilityRequest) (*CheckCompatibilityResponse, error)
+	// Delete a schema (soft delete)
+	DeleteSchema(context.Context, *DeleteSchemaRequest) (*DeleteSchemaResponse, error)
+	// Validate a message against a registered schema
+	ValidateMessage(context.Context, *ValidateMessageRequest) (*ValidateMessageResponse, error)
+	mustEmbedUnimplementedSchemaRegistryServiceServer()
+}
+
+// UnimplementedSchemaRegistryServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSchemaRegistryServiceServer struct{}
+
+func (UnimplementedSchemaRegistryServiceServer) Register(context.Context, *RegisterSchemaRequest) (*RegisterSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedSchemaRegistryServiceServer) GetSchema(context.Context, *GetSchemaRequest) (*GetSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSchema not implemented")
+}
+func (UnimplementedSchemaRegistryServiceServer) GetLatestSchema(context.Context, *GetLatestSchemaRequest) (*GetLatestSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLatestSchema not implemented")
+}
+func (UnimplementedSchemaRegistryServiceServer) ListSchemas(context.Context, *ListSchemasRequest) (*ListSchemasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSchemas not implemented")
+}
+func (UnimplementedSchemaRegistryServiceServer) ListSchemaVersions(context.Context, *ListSchemaVersionsRequest) (*ListSchemaVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSchemaVersions not implemented")
+}
+func (UnimplementedSchemaRegistryServiceServer) CheckCompatibility(context.Context, *CheckCompatibilityRequest) (*CheckCompatibilityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckCompatibility not implemented")
+}
+func (UnimplementedSchemaRegistryServiceServer) DeleteSchema(context.Context, *DeleteSchemaRequest) (*DeleteSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSchema not implemented")
+}
+func (UnimplementedSchemaRegistryServiceServer) ValidateMessage(context.Context, *ValidateMessageRequest) (*ValidateMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateMessage not implemented")
+}
+func (UnimplementedSchemaRegistryServiceServer) mustEmbedUnimplementedSchemaRegistryServiceServer() {}
+func (UnimplementedSchemaRegistryServiceServer) testEmbeddedByValue()                               {}
+
+// UnsafeSchemaRegistryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SchemaRegistryServiceServer will
+// result in compilation errors.
+type UnsafeSchemaRegistryServiceServer interface {
+	mustEmbedUnimplementedSchemaRegistryServiceServer()
+}
+
+func RegisterSchemaRegistryServiceServer(s grpc.ServiceRegistrar, srv SchemaRegistryServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSchemaRegistryServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SchemaRegistryService_ServiceDesc, srv)
+}
+
+func _SchemaRegistryService_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchemaRegistryServiceServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SchemaRegistryService_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchemaRegistryServiceServer).Register(ctx, req.(*RegisterSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SchemaRegistryService_GetSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchemaRegistryServiceServer).GetSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SchemaRegistryService_GetSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchemaRegistryServiceServer).GetSchema(ctx, req.(*GetSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SchemaRegistryService_GetLatestSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLatestSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchemaRegistryServiceServer).GetLatestSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SchemaRegistryService_GetLatestSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchemaRegistryServiceServer).GetLatestSchema(ctx, req.(*GetLatestSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SchemaRegistryService_ListSchemas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSchemasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchemaRegistryServiceServer).ListSchemas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SchemaRegistryService_ListSchemas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchemaRegistryServiceServer).ListSchemas(ctx, req.(*ListSchemasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SchemaRegistryService_ListSchemaVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSchemaVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchemaRegistryServiceServer).ListSchemaVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SchemaRegistryService_ListSchemaVersions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchemaRegistryServiceServer).ListSchemaVersions(ctx, req.(*ListSchemaVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SchemaRegistryService_CheckCompatibility_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckCompatibilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchemaRegistryServiceServer).CheckCompatibility(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SchemaRegistryService_CheckCompatibility_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchemaRegistryServiceServer).CheckCompatibility(ctx, req.(*CheckCompatibilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SchemaRegistryService_DeleteSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchemaRegistryServiceServer).DeleteSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SchemaRegistryService_DeleteSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchemaRegistryServiceServer).DeleteSchema(ctx, req.(*DeleteSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SchemaRegistryService_ValidateMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchemaRegistryServiceServer).ValidateMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SchemaRegistryService_ValidateMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchemaRegistryServiceServer).ValidateMessage(ctx, req.(*ValidateMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SchemaRegistryService_ServiceDesc is the grpc.ServiceDesc for SchemaRegistryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SchemaRegistryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "messaging.SchemaRegistryService",
+	HandlerType: (*SchemaRegistryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _SchemaRegistryService_Register_Handler,
+		},
+		{
+			MethodName: "GetSchema",
+			Handler:    _SchemaRegistryService_GetSchema_Handler,
+		},
+		{
+			MethodName: "GetLatestSchema",
+			Handler:    _SchemaRegistryService_GetLatestSchema_Handler,
+		},
+		{
+			MethodName: "ListSchemas",
+			Handler:    _SchemaRegistryService_ListSchemas_Handler,
+		},
+		{
+			MethodName: "ListSchemaVersions",
+			Handler:    _SchemaRegistryService_ListSchemaVersions_Handler,
+		},
+		{
+			MethodName: "CheckCompatibility",
+			Handler:    _SchemaRegistryService_CheckCompatibility_Handler,
+		},
+		{
+			MethodName: "DeleteSchema",
+			Handler:    _SchemaRegistryService_DeleteSchema_Handler,
+		},
+		{
+			MethodName: "ValidateMessage",
+			Handler:    _SchemaRegistryService_ValidateMessage_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/proto/messaging.proto",
+}
+
+const (
+	ConnectorService_Register_FullMethodName         = "/messaging.ConnectorService/Register"
+	ConnectorService_Start_FullMethodName            = "/messaging.ConnectorService/Start"
+	ConnectorService_Stop_FullMethodName             = "/messaging.ConnectorService/Stop"
+	ConnectorService_List_FullMethodName             = "/messaging.ConnectorService/List"
+	ConnectorService_Get_FullMethodName              = "/messaging.ConnectorService/Get"
+	ConnectorService_Update_FullMethodName           = "/messaging.ConnectorService/Update"
+	ConnectorService_Delete_FullMethodName           = "/messaging.ConnectorService/Delete"
+	ConnectorService_ResetOffsets_FullMethodName     = "/messaging.ConnectorService/ResetOffsets"
+	ConnectorService_HealthCheck_FullMethodName      = "/messaging.ConnectorService/HealthCheck"
+	ConnectorService_GetConnectorLogs_FullMethodName = "/messaging.ConnectorService/GetConnectorLogs"
+)
+
+// ConnectorServiceClient is the client API for ConnectorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ======================== Connector Service ========================
+type ConnectorServiceClient interface {
+	// Register a new connector
+	Register(ctx context.Context, in *RegisterConnectorRequest, opts ...grpc.CallOption) (*RegisterConnectorResponse, error)
+	// Start a connector
+	Start(ctx context.Context, in *ConnectorControlRequest, opts ...grpc.CallOption) (*ConnectorControlResponse, error)
+	// Stop a connector
+	Stop(ctx context.Context, in *ConnectorControlRequest, opts ...grpc.CallOption) (*ConnectorControlResponse, error)
+	// List all connectors
+	List(ctx context.Context, in *ListConnectorsRequest, opts ...grpc.CallOption) (*ListConnectorsResponse, error)
+	// Retrieve details of a specific connector
+	Get(ctx context.Context, in *GetConnectorRequest, opts ...grpc.CallOption) (*GetConnectorResponse, error)
+	// Update an existing connector
+	Update(ctx context.Context, in *UpdateConnectorRequest, opts ...grpc.CallOption) (*UpdateConnectorResponse, error)
+	// Delete a connector
+	Delete(ctx context.Context, in *DeleteConnectorRequest, opts ...grpc.CallOption) (*DeleteConnectorResponse, error)
+	// Reset message offsets for a connector
+	ResetOffsets(ctx context.Context, in *ResetOffsetsRequest, opts ...grpc.CallOption) (*ResetOffsetsResponse, error)
+	// Check health of a connector
+	HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error)
+	GetConnectorLogs(ctx context.Context, in *ConnectorLogsRequest, opts ...grpc.CallOption) (*ConnectorLogsResponse, error)
+}
+
+type connectorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConnectorServiceClient(cc grpc.ClientConnInterface) ConnectorServiceClient {
+	return &connectorServiceClient{cc}
+}
+
+func (c *connectorServiceClient) Register(ctx context.Context, in *RegisterConnectorRequest, opts ...grpc.CallOption) (*RegisterConnectorResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterConnectorResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_Register_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) Start(ctx context.Context, in *ConnectorControlRequest, opts ...grpc.CallOption) (*ConnectorControlResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConnectorControlResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_Start_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) Stop(ctx context.Context, in *ConnectorControlRequest, opts ...grpc.CallOption) (*ConnectorControlResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConnectorControlResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_Stop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) List(ctx context.Context, in *ListConnectorsRequest, opts ...grpc.CallOption) (*ListConnectorsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListConnectorsResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) Get(ctx context.Context, in *GetConnectorRequest, opts ...grpc.CallOption) (*GetConnectorResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetConnectorResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) Update(ctx context.Context, in *UpdateConnectorRequest, opts ...grpc.CallOption) (*UpdateConnectorResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateConnectorResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_Update_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) Delete(ctx context.Context, in *DeleteConnectorRequest, opts ...grpc.CallOption) (*DeleteConnectorResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteConnectorResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) ResetOffsets(ctx context.Context, in *ResetOffsetsRequest, opts ...grpc.CallOption) (*ResetOffsetsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetOffsetsResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_ResetOffsets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HealthCheckResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_HealthCheck_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorServiceClient) GetConnectorLogs(ctx context.Context, in *ConnectorLogsRequest, opts ...grpc.CallOption) (*ConnectorLogsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConnectorLogsResponse)
+	err := c.cc.Invoke(ctx, ConnectorService_GetConnectorLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ConnectorServiceServer is the server API for ConnectorService service.
+// All implementations must embed UnimplementedConnectorServiceServer
+// for forward compatibility.
+//
+// ======================== Connector Service ========================
+type ConnectorServiceServer interface {
+	// Register a new connector
+	Register(context.Context, *RegisterConnectorRequest) (*RegisterConnectorResponse, error)
+	// Start a connector
+	Start(context.Context, *ConnectorControlRequest) (*ConnectorControlResponse, error)
+	// Stop a connector
+	Stop(context.Context, *ConnectorControlRequest) (*ConnectorControlResponse, error)
+	// List all connectors
+	List(context.Context, *ListConnectorsRequest) (*ListConnectorsResponse, error)
+	// Retrieve details of a specific connector
+	Get(context.Context, *GetConnectorRequest) (*GetConnectorResponse, error)
+	// Update an existing connector
+	Update(context.Context, *UpdateConnectorRequest) (*UpdateConnectorResponse, error)
+	// Delete a connector
+	Delete(context.Context, *DeleteConnectorRequest) (*DeleteConnectorResponse, error)
+	// Reset message offsets for a connector
+	ResetOffsets(context.Context, *ResetOffsetsRequest) (*ResetOffsetsResponse, error)
+	// Check health of a connector
+	HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error)
+	GetConnectorLogs(context.Context, *ConnectorLogsRequest) (*ConnectorLogsResponse, error)
+	mustEmbedUnimplementedConnectorServiceServer()
+}
+
+// UnimplementedConnectorServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConnectorServiceServer struct{}
+
+func (UnimplementedConnectorServiceServer) Register(context.Context, *RegisterConnectorRequest) (*RegisterConnectorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedConnectorServiceServer) Start(context.Context, *ConnectorControlRequest) (*ConnectorControlResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Start not implemented")
+}
+func (UnimplementedConnectorServiceServer) Stop(context.Context, *ConnectorControlRequest) (*ConnectorControlResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
+}
+func (UnimplementedConnectorServiceServer) List(context.Context, *ListConnectorsRequest) (*ListConnectorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedConnectorServiceServer) Get(context.Context, *GetConnectorRequest) (*GetConnectorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedConnectorServiceServer) Update(context.Context, *UpdateConnectorRequest) (*UpdateConnectorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedConnectorServiceServer) Delete(context.Context, *DeleteConnectorRequest) (*DeleteConnectorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedConnectorServiceServer) ResetOffsets(context.Context, *ResetOffsetsRequest) (*ResetOffsetsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetOffsets not implemented")
+}
+func (UnimplementedConnectorServiceServer) HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HealthCheck not implemented")
+}
+func (UnimplementedConnectorServiceServer) GetConnectorLogs(context.Context, *ConnectorLogsRequest) (*ConnectorLogsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConnectorLogs not implemented")
+}
+func (UnimplementedConnectorServiceServer) mustEmbedUnimplementedConnectorServiceServer() {}
+func (UnimplementedConnectorServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeConnectorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConnectorServiceServer will
+// result in compilation errors.
+type UnsafeConnectorServiceServer interface {
+	mustEmbedUnimplementedConnectorServiceServer()
+}
+
+func RegisterConnectorServiceServer(s grpc.ServiceRegistrar, srv ConnectorServiceServer) {
+	// If the following call pancis, it indicates UnimplementedConnectorServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ConnectorService_ServiceDesc, srv)
+}
+
+func _ConnectorService_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterConnectorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).Register(ctx, req.(*RegisterConnectorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_Start_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectorControlRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).Start(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_Start_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).Start(ctx, req.(*ConnectorControlRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectorControlRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).Stop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_Stop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).Stop(ctx, req.(*ConnectorControlRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListConnectorsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).List(ctx, req.(*ListConnectorsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConnectorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).Get(ctx, req.(*GetConnectorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateConnectorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_Update_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).Update(ctx, req.(*UpdateConnectorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteConnectorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).Delete(ctx, req.(*DeleteConnectorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_ResetOffsets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetOffsetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).ResetOffsets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_ResetOffsets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).ResetOffsets(ctx, req.(*ResetOffsetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_HealthCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).HealthCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_HealthCheck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).HealthCheck(ctx, req.(*HealthCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConnectorService_GetConnectorLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectorLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServiceServer).GetConnectorLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectorService_GetConnectorLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServiceServer).GetConnectorLogs(ctx, req.(*ConnectorLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ConnectorService_ServiceDesc is the grpc.ServiceDesc for ConnectorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConnectorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "messaging.ConnectorService",
+	HandlerType: (*ConnectorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _ConnectorService_Register_Handler,
+		},
+		{
+			MethodName: "Start",
+			Handler:    _ConnectorService_Start_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _ConnectorService_Stop_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _ConnectorService_List_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _ConnectorService_Get_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _ConnectorService_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _ConnectorService_Delete_Handler,
+		},
+		{
+			MethodName: "ResetOffsets",
+			Handler:    _ConnectorService_ResetOffsets_Handler,
+		},
+		{
+			MethodName: "HealthCheck",
+			Handler:    _ConnectorService_HealthCheck_Handler,
+		},
+		{
+			MethodName: "GetConnectorLogs",
+			Handler:    _ConnectorService_GetConnectorLogs_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/proto/messaging.proto",
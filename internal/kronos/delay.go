@@ -0,0 +1,132 @@
+package kronos
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DelayQueue holds values until they fall due, earliest first. Every value has a key, so it can be
+// cancelled before it falls due. It is safe for concurrent use.
+type DelayQueue[T any] struct {
+	mu    sync.Mutex
+	items delayHeap[T]
+	byKey map[string]*delayed[T]
+}
+
+// delayed is a value waiting in a DelayQueue
+type delayed[T any] struct {
+	key   string
+	due   time.Time
+	value T
+	index int // Position in the heap
+}
+
+// NewDelayQueue returns an empty queue
+func NewDelayQueue[T any]() *DelayQueue[T] {
+	return &DelayQueue[T]{byKey: make(map[string]*delayed[T])}
+}
+
+// Add queues a value to fall due at due, returning false if the key is already queued
+func (q *DelayQueue[T]) Add(key string, due time.Time, value T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.byKey[key]; ok {
+		return false
+	}
+	d := &delayed[T]{key: key, due: due, value: value}
+	heap.Push(&q.items, d)
+	q.byKey[key] = d
+	return true
+}
+
+// Cancel removes the value queued under key, returning it and whether it was queued
+func (q *DelayQueue[T]) Cancel(key string) (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	d, ok := q.byKey[key]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	heap.Remove(&q.items, d.index)
+	delete(q.byKey, key)
+	return d.value, true
+}
+
+// Next returns when the earliest value falls due, or false if the queue is empty
+func (q *DelayQueue[T]) Next() (time.Time, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return time.Time{}, false
+	}
+	return q.items[0].due, true
+}
+
+// PopDue removes and returns up to limit values due at or before now, earliest first. A limit of 0
+// returns them all.
+func (q *DelayQueue[T]) PopDue(now time.Time, limit int) []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var due []T
+	for len(q.items) > 0 && !q.items[0].due.After(now) && (limit == 0 || len(due) < limit) {
+		d := heap.Pop(&q.items).(*delayed[T])
+		delete(q.byKey, d.key)
+		due = append(due, d.value)
+	}
+	return due
+}
+
+// Values returns every value queued, earliest first, leaving them queued
+func (q *DelayQueue[T]) Values() []T {
+	q.mu.Lock()
+	sorted := append(delayHeap[T](nil), q.items...)
+	q.mu.Unlock()
+	sort.Slice(sorted, func(i, j int) bool { return sorted.Less(i, j) })
+	values := make([]T, len(sorted))
+	for i, d := range sorted {
+		values[i] = d.value
+	}
+	return values
+}
+
+// Len returns the number of values queued
+func (q *DelayQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// delayHeap orders values by due time, then by key so values due together pop in a stable order
+type delayHeap[T any] []*delayed[T]
+
+func (h delayHeap[T]) Len() int { return len(h) }
+
+func (h delayHeap[T]) Less(i, j int) bool {
+	if !h[i].due.Equal(h[j].due) {
+		return h[i].due.Before(h[j].due)
+	}
+	return h[i].key < h[j].key
+}
+
+func (h delayHeap[T]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *delayHeap[T]) Push(x any) {
+	d := x.(*delayed[T])
+	d.index = len(*h)
+	*h = append(*h, d)
+}
+
+func (h *delayHeap[T]) Pop() any {
+	old := *h
+	d := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return d
+}
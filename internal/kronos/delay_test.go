@@ -0,0 +1,63 @@
+package kronos_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/kronos"
+)
+
+func TestDelayQueue(t *testing.T) {
+	base := time.Unix(1000, 0)
+	q := kronos.NewDelayQueue[string]()
+	for i, offset := range []int{30, 10, 20, 10, 50} {
+		key := fmt.Sprintf("k%d", i)
+		if !q.Add(key, base.Add(time.Duration(offset)*time.Second), key) {
+			t.Fatalf("Add(%s) refused a new key", key)
+		}
+	}
+	if q.Add("k0", base, "again") {
+		t.Fatal("Add accepted a key already queued")
+	}
+
+	if next, ok := q.Next(); !ok || !next.Equal(base.Add(10*time.Second)) {
+		t.Fatalf("Next = %v, %v; want base+10s", next, ok)
+	}
+	if got := q.PopDue(base.Add(5*time.Second), 0); len(got) != 0 {
+		t.Fatalf("PopDue before anything is due = %v", got)
+	}
+
+	if v, ok := q.Cancel("k2"); !ok || v != "k2" {
+		t.Fatalf("Cancel(k2) = %q, %v", v, ok)
+	}
+	if _, ok := q.Cancel("k2"); ok {
+		t.Fatal("Cancel succeeded twice")
+	}
+
+	if got := fmt.Sprint(q.Values()); got != "[k1 k3 k0 k4]" {
+		t.Fatalf("Values = %s", got)
+	}
+
+	// Values due together pop by key
+	if got := fmt.Sprint(q.PopDue(base.Add(30*time.Second), 1)); got != "[k1]" {
+		t.Fatalf("PopDue with limit 1 = %s", got)
+	}
+	if got := fmt.Sprint(q.PopDue(base.Add(30*time.Second), 0)); got != "[k3 k0]" {
+		t.Fatalf("PopDue = %s", got)
+	}
+	if q.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", q.Len())
+	}
+
+	// A popped key can be queued again
+	if !q.Add("k1", base, "k1") {
+		t.Fatal("Add refused a key that had been popped")
+	}
+	if got := fmt.Sprint(q.PopDue(base.Add(time.Hour), 0)); got != "[k1 k4]" {
+		t.Fatalf("PopDue = %s", got)
+	}
+	if _, ok := q.Next(); ok {
+		t.Fatal("Next reported a value in an empty queue")
+	}
+}
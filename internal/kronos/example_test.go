@@ -0,0 +1,40 @@
+package kronos_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/kronos"
+)
+
+// Processes take turns on a single worker, each running for at most the 3ms quantum at a time
+func ExamplePool() {
+	clock := kronos.NewFakeClock(time.Unix(0, 0))
+	pool := kronos.NewPool(kronos.NewRoundRobin(3*time.Millisecond), 1, clock)
+	defer pool.Close()
+
+	process := func(id string, burst time.Duration) *kronos.Task {
+		remaining := burst
+		return &kronos.Task{ID: id, Work: func(ctx context.Context, slice time.Duration) bool {
+			run := min(remaining, slice)
+			clock.Advance(run) // Stands in for real work
+			remaining -= run
+			fmt.Printf("process %s ran for %v, %v left\n", id, run, remaining)
+			return remaining == 0
+		}}
+	}
+	pool.Submit(process("1", 5*time.Millisecond))
+	pool.Submit(process("2", 7*time.Millisecond))
+	pool.Submit(process("3", 2*time.Millisecond))
+	pool.Start(context.Background())
+	pool.Wait()
+
+	// Output:
+	// process 1 ran for 3ms, 2ms left
+	// process 2 ran for 3ms, 4ms left
+	// process 3 ran for 2ms, 0s left
+	// process 1 ran for 2ms, 0s left
+	// process 2 ran for 3ms, 1ms left
+	// process 2 ran for 1ms, 0s left
+}
@@ -0,0 +1,220 @@
+package kronos
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FairShare shares a budget of bytes per second between flows by deficit round-robin. Callers
+// acquire a turn for their flow before doing some work, and release it with the bytes the work
+// actually took. Flows waiting for a turn are visited in rotation, each visit crediting a flow
+// quantum bytes times its weight; a flow is granted turns while its credit lasts, and turns that
+// took more than their share leave it in debt until later visits pay it off. Over time each busy
+// flow gets bytes in proportion to its weight. Without a rate limit every turn is granted at
+// once. It is safe for concurrent use.
+type FairShare struct {
+	clock   Clock
+	quantum int64
+
+	mu      sync.Mutex
+	rate    int64   // Bytes per second; 0 means unlimited
+	tokens  float64 // Bytes the rate allows now; granting a turn reserves a quantum of them
+	updated time.Time
+	flows   map[string]*fairFlow
+	weights map[string]int
+	active  []*fairFlow // Flows with waiters, in visiting order
+	next    int         // Index in active of the flow whose turn it is
+	waiting int
+}
+
+// fairFlow is the state of one flow
+type fairFlow struct {
+	name    string
+	deficit int64 // Bytes the flow may still take this round; negative when in debt
+	visited bool  // Whether it has been credited for the current visit
+	queued  bool  // Whether it is in active
+	waiters []chan struct{}
+}
+
+// NewFairShare returns a FairShare limiting flows to rate bytes per second altogether (0 for
+// unlimited), crediting quantum bytes per visit to a flow of weight 1. clock times the rate, and
+// is SystemClock when nil.
+func NewFairShare(rate, quantum int64, clock Clock) *FairShare {
+	if clock == nil {
+		clock = SystemClock
+	}
+	f := &FairShare{
+		clock:   clock,
+		quantum: max(quantum, 1),
+		rate:    max(rate, 0),
+		updated: clock.Now(),
+		flows:   make(map[string]*fairFlow),
+		weights: make(map[string]int),
+	}
+	f.tokens = float64(f.burst())
+	return f
+}
+
+// burst is how many bytes may go at once after the flows were idle: one second's worth
+func (f *FairShare) burst() int64 {
+	return max(f.rate, f.quantum)
+}
+
+// SetWeight sets a flow's share relative to the others; weights below 1 restore the default of 1
+func (f *FairShare) SetWeight(flow string, weight int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if weight <= 1 {
+		delete(f.weights, flow)
+		return
+	}
+	f.weights[flow] = weight
+}
+
+// Weight returns a flow's share relative to the others
+func (f *FairShare) Weight(flow string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.weight(flow)
+}
+
+func (f *FairShare) weight(flow string) int {
+	if w, ok := f.weights[flow]; ok {
+		return w
+	}
+	return 1
+}
+
+// Forget drops a flow's weight, and its debt unless it has turns pending
+func (f *FairShare) Forget(flow string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.weights, flow)
+	if fl, ok := f.flows[flow]; ok && !fl.queued {
+		delete(f.flows, flow)
+	}
+}
+
+// Waiting returns how many Acquire calls are waiting for a turn
+func (f *FairShare) Waiting() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.waiting
+}
+
+// Acquire waits for a turn of the flow and returns the function to call with the bytes the turn
+// took once it is done. It fails only if ctx is done first.
+func (f *FairShare) Acquire(ctx context.Context, flow string) (release func(n int64), err error) {
+	f.mu.Lock()
+	fl := f.flows[flow]
+	if fl == nil {
+		fl = &fairFlow{name: flow}
+		f.flows[flow] = fl
+	}
+	granted := make(chan struct{})
+	fl.waiters = append(fl.waiters, granted)
+	f.waiting++
+	if !fl.queued {
+		fl.queued = true
+		f.active = append(f.active, fl)
+	}
+	refill := f.dispatch()
+	f.mu.Unlock()
+
+	release = func(n int64) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		// The turn reserved a quantum; settle the difference with what it took
+		extra := n - f.quantum
+		fl.deficit -= extra
+		if f.rate > 0 {
+			f.tokens -= float64(extra)
+		}
+		f.dispatch()
+	}
+	for {
+		select {
+		case <-granted:
+			return release, nil
+		case <-ctx.Done():
+			f.mu.Lock()
+			select {
+			case <-granted:
+				// Granted in the meantime: hand back the reservation unused
+				f.mu.Unlock()
+				release(0)
+				return nil, ctx.Err()
+			default:
+			}
+			for i, w := range fl.waiters {
+				if w == granted {
+					fl.waiters = append(fl.waiters[:i], fl.waiters[i+1:]...)
+					f.waiting--
+					break
+				}
+			}
+			f.dispatch()
+			f.mu.Unlock()
+			return nil, ctx.Err()
+		case <-refill:
+			f.mu.Lock()
+			refill = f.dispatch()
+			f.mu.Unlock()
+		}
+	}
+}
+
+// dispatch grants turns while the rate allows, returning a channel that fires once it allows
+// more if flows are left waiting; the caller holds f.mu
+func (f *FairShare) dispatch() <-chan time.Time {
+	if f.rate > 0 {
+		now := f.clock.Now()
+		f.tokens = min(f.tokens+now.Sub(f.updated).Seconds()*float64(f.rate), float64(f.burst()))
+		f.updated = now
+	}
+	for len(f.active) > 0 {
+		if f.rate > 0 && f.tokens <= 0 {
+			wait := time.Duration((1 - f.tokens) / float64(f.rate) * float64(time.Second))
+			return f.clock.After(max(wait, time.Millisecond))
+		}
+		f.next %= len(f.active)
+		fl := f.active[f.next]
+		if !fl.visited {
+			fl.visited = true
+			fl.deficit += f.quantum * int64(f.weight(fl.name))
+		}
+		if fl.deficit > 0 && len(fl.waiters) > 0 {
+			close(fl.waiters[0])
+			fl.waiters = fl.waiters[1:]
+			f.waiting--
+			fl.deficit -= f.quantum
+			if f.rate > 0 {
+				f.tokens -= float64(f.quantum)
+			}
+			continue
+		}
+
+		// The flow's turn is over; unused credit does not carry over to its next visit
+		fl.visited = false
+		fl.deficit = min(fl.deficit, 0)
+		if len(fl.waiters) == 0 {
+			fl.queued = false
+			f.active = append(f.active[:f.next], f.active[f.next+1:]...)
+			continue
+		}
+		f.next++
+	}
+	return nil
+}
+
+// SetRate changes the byte rate shared between the flows, 0 lifting the limit
+func (f *FairShare) SetRate(rate int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dispatch()
+	f.rate = max(rate, 0)
+	f.tokens = min(f.tokens, float64(f.burst()))
+	f.updated = f.clock.Now()
+	f.dispatch()
+}
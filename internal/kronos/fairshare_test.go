@@ -0,0 +1,95 @@
+package kronos_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/kronos"
+)
+
+func TestFairShareUnlimited(t *testing.T) {
+	f := kronos.NewFairShare(0, 100, nil)
+	for i := 0; i < 10; i++ {
+		release, err := f.Acquire(context.Background(), "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		release(1000)
+	}
+}
+
+func TestFairShareWeights(t *testing.T) {
+	clock := kronos.NewFakeClock(time.Unix(0, 0))
+	f := kronos.NewFairShare(1000, 100, clock)
+	f.SetWeight("light", 1)
+	f.SetWeight("heavy", 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Every turn takes 200 bytes, twice the quantum, so flows also carry debt between turns
+	var mu sync.Mutex
+	served := map[string]int64{}
+	var wg sync.WaitGroup
+	for _, flow := range []string{"light", "heavy"} {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(flow string) {
+				defer wg.Done()
+				for {
+					release, err := f.Acquire(ctx, flow)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					served[flow] += 200
+					mu.Unlock()
+					release(200)
+				}
+			}(flow)
+		}
+	}
+
+	total := func() int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return served["light"] + served["heavy"]
+	}
+	// Each step adds 100 bytes to the budget; wait for the flows to use it before the next one, so
+	// the budget never fills up to its burst and goes to waste
+	await := func(want int64) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for total() < want || f.Waiting() != 8 {
+			if time.Now().After(deadline) {
+				t.Fatalf("served %d bytes with %d turns waiting, want %d with 8", total(), f.Waiting(), want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	await(0)
+	mu.Lock()
+	served = map[string]int64{} // Forget the initial burst
+	mu.Unlock()
+	for i := int64(1); i <= 200; i++ {
+		clock.Advance(100 * time.Millisecond)
+		await(100*i - 400)
+	}
+
+	mu.Lock()
+	light, heavy := served["light"], served["heavy"]
+	mu.Unlock()
+	if total := light + heavy; total < 19000 || total > 21000 {
+		t.Errorf("served %d bytes in 20s at 1000 bytes/s", total)
+	}
+	if ratio := float64(heavy) / float64(light); ratio < 2.5 || ratio > 3.5 {
+		t.Errorf("heavy/light = %d/%d = %.2f, want about 3", heavy, light, ratio)
+	}
+
+	cancel()
+	wg.Wait()
+	if f.Waiting() != 0 {
+		t.Fatalf("%d turns waiting after cancelling", f.Waiting())
+	}
+}
@@ -0,0 +1,181 @@
+package kronos
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrPoolClosed is returned when submitting a task to a closed Pool
+	ErrPoolClosed = errors.New("kronos: pool is closed")
+	// ErrDuplicateTask is returned when submitting a periodic task under the ID of one already
+	// submitted to the Pool
+	ErrDuplicateTask = errors.New("kronos: a periodic task with this ID is already in the pool")
+)
+
+// Pool runs tasks on a bounded number of workers, in the order its Scheduler picks. A task that
+// does not finish within its slice goes back to the scheduler; a periodic task that finishes is
+// released again one Period after its previous release. It is safe for concurrent use.
+type Pool struct {
+	scheduler Scheduler
+	clock     Clock
+	workers   int
+
+	mu       sync.Mutex
+	changed  *sync.Cond // Broadcast when tasks are queued or finish, or the pool closes
+	running  int
+	closed   bool
+	periodic *DelayQueue[*Task] // Periodic tasks waiting for their next release, by ID
+	periods  map[string]bool    // IDs of the periodic tasks submitted
+	released chan struct{}      // Wakes the releaser when a periodic task is queued
+	done     chan struct{}      // Closed with the pool
+	wg       sync.WaitGroup
+}
+
+// NewPool returns a pool of workers running the tasks of scheduler, timed by clock (SystemClock
+// when nil). No task runs before Start.
+func NewPool(scheduler Scheduler, workers int, clock Clock) *Pool {
+	if clock == nil {
+		clock = SystemClock
+	}
+	p := &Pool{
+		scheduler: scheduler,
+		clock:     clock,
+		workers:   max(workers, 1),
+		periodic:  NewDelayQueue[*Task](),
+		periods:   make(map[string]bool),
+		released:  make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	p.changed = sync.NewCond(&p.mu)
+	return p
+}
+
+// Submit queues a task to run. A periodic task counts its first release from now, and its ID must
+// be unique among the periodic tasks of the pool.
+func (p *Pool) Submit(t *Task) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	if t.Period > 0 {
+		if p.periods[t.ID] {
+			return ErrDuplicateTask
+		}
+		p.periods[t.ID] = true
+	}
+	t.release = p.clock.Now()
+	p.scheduler.Push(t)
+	p.changed.Broadcast()
+	return nil
+}
+
+// Start runs the workers, passing ctx to every task; cancelling it closes the pool
+func (p *Pool) Start(ctx context.Context) {
+	p.wg.Add(p.workers + 1)
+	for i := 0; i < p.workers; i++ {
+		go p.work(ctx)
+	}
+	go p.release()
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.Close()
+		case <-p.done:
+		}
+	}()
+}
+
+// Wait blocks until no task is queued or running, not counting periodic tasks waiting for their
+// next release
+func (p *Pool) Wait() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for !p.closed && (p.scheduler.Len() > 0 || p.running > 0) {
+		p.changed.Wait()
+	}
+}
+
+// Close stops the pool once the running tasks return, dropping those still queued
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.done)
+		p.changed.Broadcast()
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// work runs tasks until the pool closes
+func (p *Pool) work(ctx context.Context) {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		for !p.closed && p.scheduler.Len() == 0 {
+			p.changed.Wait()
+		}
+		if p.closed {
+			p.mu.Unlock()
+			return
+		}
+		t, slice, _ := p.scheduler.Pop()
+		p.running++
+		p.mu.Unlock()
+
+		start := p.clock.Now()
+		done := t.Work(ctx, slice)
+		ran := p.clock.Now().Sub(start)
+
+		p.mu.Lock()
+		p.running--
+		switch {
+		case p.closed:
+		case !done:
+			p.scheduler.Yield(t, ran)
+		case t.Period > 0:
+			// Of the releases missed while the task ran late, only the latest is kept
+			next := t.release.Add(t.Period)
+			if late := p.clock.Now().Sub(next); late > 0 {
+				next = next.Add(late.Truncate(t.Period))
+			}
+			t.release = next
+			p.periodic.Add(t.ID, next, t) // Submit keeps IDs unique, so this always queues it
+			select {
+			case p.released <- struct{}{}:
+			default:
+			}
+		}
+		p.changed.Broadcast()
+		p.mu.Unlock()
+	}
+}
+
+// release queues periodic tasks as their release times come
+func (p *Pool) release() {
+	defer p.wg.Done()
+	for {
+		var due <-chan time.Time
+		if next, ok := p.periodic.Next(); ok {
+			due = p.clock.After(next.Sub(p.clock.Now()))
+		}
+		select {
+		case <-p.done:
+			return
+		case <-p.released:
+			continue
+		case <-due:
+		}
+
+		p.mu.Lock()
+		for _, t := range p.periodic.PopDue(p.clock.Now(), 0) {
+			p.scheduler.Push(t)
+		}
+		p.changed.Broadcast()
+		p.mu.Unlock()
+	}
+}
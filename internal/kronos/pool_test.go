@@ -0,0 +1,144 @@
+package kronos_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/kronos"
+)
+
+func TestPoolBoundsWorkers(t *testing.T) {
+	pool := kronos.NewPool(kronos.NewFCFS(), 2, kronos.NewFakeClock(time.Unix(0, 0)))
+	defer pool.Close()
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	started := make(chan struct{}, 5)
+	finish := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		pool.Submit(&kronos.Task{Work: func(ctx context.Context, slice time.Duration) bool {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+			started <- struct{}{}
+			<-finish
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return true
+		}})
+	}
+	pool.Start(context.Background())
+	<-started
+	<-started
+	select {
+	case <-started:
+		t.Fatal("a third task started on a pool of two workers")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(finish)
+	pool.Wait()
+	if peak != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", peak)
+	}
+	pool.Close()
+	if err := pool.Submit(&kronos.Task{}); err != kronos.ErrPoolClosed {
+		t.Fatalf("Submit after Close = %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestPoolSlicesAndPeriods(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := kronos.NewFakeClock(start)
+	pool := kronos.NewPool(kronos.NewRateMonotonic(), 1, clock)
+	defer pool.Close()
+
+	runs := make(chan string, 16)
+	periodic := func(id string, period time.Duration) *kronos.Task {
+		return &kronos.Task{ID: id, Period: period, Work: func(ctx context.Context, slice time.Duration) bool {
+			runs <- id + "@" + clock.Now().Sub(start).String()
+			return true
+		}}
+	}
+	pool.Submit(periodic("slow", 25*time.Millisecond))
+	pool.Submit(periodic("fast", 10*time.Millisecond))
+	pool.Start(context.Background())
+
+	expect := func(want ...string) {
+		t.Helper()
+		for _, w := range want {
+			select {
+			case got := <-runs:
+				if got != w {
+					t.Fatalf("ran %s, want %s", got, w)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for %s", w)
+			}
+		}
+	}
+
+	// The shorter period goes first
+	expect("fast@0s", "slow@0s")
+	clock.Advance(10 * time.Millisecond)
+	expect("fast@10ms")
+	// Skipping ahead releases both; fast missed its 20ms release and runs once for it
+	clock.Advance(15 * time.Millisecond)
+	expect("fast@25ms", "slow@25ms")
+	clock.Advance(5 * time.Millisecond)
+	expect("fast@30ms")
+	select {
+	case got := <-runs:
+		t.Fatalf("unexpected run %s", got)
+	default:
+	}
+}
+
+func TestPoolRequeuesUnfinishedTasks(t *testing.T) {
+	clock := kronos.NewFakeClock(time.Unix(0, 0))
+	pool := kronos.NewPool(kronos.NewFeedbackQueue(2, time.Millisecond, 0), 1, clock)
+	defer pool.Close()
+
+	var turns []time.Duration
+	remaining := 5 * time.Millisecond
+	pool.Submit(&kronos.Task{ID: "job", Work: func(ctx context.Context, slice time.Duration) bool {
+		run := min(remaining, slice)
+		clock.Advance(run)
+		remaining -= run
+		turns = append(turns, slice)
+		return remaining == 0
+	}})
+	pool.Start(context.Background())
+	pool.Wait()
+
+	// The first slice is used up in full, so the job drops to the level with 2ms slices
+	if got, want := len(turns), 3; got != want {
+		t.Fatalf("turns = %v, want %d of them", turns, want)
+	}
+	if turns[0] != time.Millisecond || turns[1] != 2*time.Millisecond || turns[2] != 2*time.Millisecond {
+		t.Fatalf("slices = %v", turns)
+	}
+}
+
+func TestPoolRejectsDuplicatePeriodicTasks(t *testing.T) {
+	pool := kronos.NewPool(kronos.NewFCFS(), 1, kronos.NewFakeClock(time.Unix(0, 0)))
+	defer pool.Close()
+
+	work := func(ctx context.Context, slice time.Duration) bool { return true }
+	if err := pool.Submit(&kronos.Task{ID: "tick", Period: time.Second, Work: work}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Submit(&kronos.Task{ID: "tick", Period: time.Second, Work: work}); !errors.Is(err, kronos.ErrDuplicateTask) {
+		t.Fatalf("second periodic task: got %v, want ErrDuplicateTask", err)
+	}
+	// One-off tasks may share IDs
+	for i := 0; i < 2; i++ {
+		if err := pool.Submit(&kronos.Task{ID: "tick", Work: work}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
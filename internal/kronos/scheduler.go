@@ -0,0 +1,226 @@
+package kronos
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+// Work is the body of a task. It runs for at most slice (without limit when zero) and reports
+// whether the task is finished; an unfinished task goes back to its scheduler for another turn.
+type Work func(ctx context.Context, slice time.Duration) (done bool)
+
+// Task is a unit of work for a Scheduler. Besides ID and Work, each field matters only to the
+// schedulers that use it.
+type Task struct {
+	ID       string
+	Work     Work
+	Estimate time.Duration // Expected run time, shortest first under SJF
+	Priority int           // Highest first under the priority scheduler
+	Deadline time.Time     // Earliest first under EDF; tasks without one go last
+	Period   time.Duration // Run again this long after each release; shortest first under rate-monotonic
+	Weight   int           // Quanta per turn under weighted round-robin; below 1 counts as 1
+
+	seq     uint64    // Order the task was queued in, breaking ties
+	release time.Time // When a periodic task was last released
+	level   int       // Feedback queue level
+}
+
+// Scheduler decides the order tasks run in, and for how long before they must yield. A Scheduler
+// is not safe for concurrent use; a Pool serializes access to its own.
+type Scheduler interface {
+	// Push queues a task that is ready to run
+	Push(t *Task)
+	// Pop takes the task to run next and the slice it may run for (0 for until done), or returns
+	// false if none is queued
+	Pop() (t *Task, slice time.Duration, ok bool)
+	// Yield queues again a task that ran for ran without finishing
+	Yield(t *Task, ran time.Duration)
+	// Len returns the number of tasks queued
+	Len() int
+}
+
+// heapScheduler runs tasks in the order of less, each until it finishes or its slice runs out
+type heapScheduler struct {
+	tasks    taskHeap
+	slice    time.Duration
+	weighted bool // Whether slice is multiplied by each task's Weight
+	seq      uint64
+}
+
+// NewFCFS returns a first-come, first-served scheduler: tasks run to completion in arrival order
+func NewFCFS() Scheduler {
+	return &heapScheduler{tasks: taskHeap{less: bySeq}}
+}
+
+// NewSJF returns a shortest-job-first scheduler: the task with the smallest Estimate runs next, to
+// completion
+func NewSJF() Scheduler {
+	return &heapScheduler{tasks: taskHeap{less: func(a, b *Task) bool {
+		if a.Estimate != b.Estimate {
+			return a.Estimate < b.Estimate
+		}
+		return bySeq(a, b)
+	}}}
+}
+
+// NewPriority returns a priority scheduler: the task with the highest Priority runs next, to
+// completion
+func NewPriority() Scheduler {
+	return &heapScheduler{tasks: taskHeap{less: func(a, b *Task) bool {
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return bySeq(a, b)
+	}}}
+}
+
+// NewRoundRobin returns a round-robin scheduler: tasks take turns in arrival order, running for at
+// most quantum before going to the back of the queue
+func NewRoundRobin(quantum time.Duration) Scheduler {
+	return &heapScheduler{tasks: taskHeap{less: bySeq}, slice: quantum}
+}
+
+// NewWeightedRoundRobin returns a weighted round-robin scheduler: tasks take turns in arrival
+// order, running for at most quantum times their Weight before going to the back of the queue
+func NewWeightedRoundRobin(quantum time.Duration) Scheduler {
+	return &heapScheduler{tasks: taskHeap{less: bySeq}, slice: quantum, weighted: true}
+}
+
+// NewEDF returns an earliest-deadline-first scheduler: the task due soonest runs next, to completion
+func NewEDF() Scheduler {
+	return &heapScheduler{tasks: taskHeap{less: func(a, b *Task) bool {
+		if !a.Deadline.Equal(b.Deadline) {
+			return !a.Deadline.IsZero() && (b.Deadline.IsZero() || a.Deadline.Before(b.Deadline))
+		}
+		return bySeq(a, b)
+	}}}
+}
+
+// NewRateMonotonic returns a rate-monotonic scheduler: the task with the shortest Period runs
+// next, to completion; tasks without a period go last
+func NewRateMonotonic() Scheduler {
+	return &heapScheduler{tasks: taskHeap{less: func(a, b *Task) bool {
+		if a.Period != b.Period {
+			return a.Period > 0 && (b.Period <= 0 || a.Period < b.Period)
+		}
+		return bySeq(a, b)
+	}}}
+}
+
+func (s *heapScheduler) Push(t *Task) {
+	s.seq++
+	t.seq = s.seq
+	heap.Push(&s.tasks, t)
+}
+
+func (s *heapScheduler) Pop() (*Task, time.Duration, bool) {
+	if s.tasks.Len() == 0 {
+		return nil, 0, false
+	}
+	t := heap.Pop(&s.tasks).(*Task)
+	if s.weighted {
+		return t, s.slice * time.Duration(max(t.Weight, 1)), true
+	}
+	return t, s.slice, true
+}
+
+func (s *heapScheduler) Yield(t *Task, ran time.Duration) {
+	s.Push(t)
+}
+
+func (s *heapScheduler) Len() int {
+	return s.tasks.Len()
+}
+
+// feedbackQueue is a multilevel feedback queue: new tasks enter the top level, and a task that
+// uses up its whole slice drops a level, where slices are twice as long. Levels are served best
+// first, each in arrival order. Every boostEvery turns all tasks return to the top level, so long
+// running tasks are not starved.
+type feedbackQueue struct {
+	levels     [][]*Task
+	quantum    time.Duration
+	boostEvery int
+	turns      int
+	queued     int
+}
+
+// NewFeedbackQueue returns a multilevel feedback queue scheduler with the given number of levels,
+// granting quantum on the top level. A boostEvery of 0 never moves tasks back up.
+func NewFeedbackQueue(levels int, quantum time.Duration, boostEvery int) Scheduler {
+	return &feedbackQueue{levels: make([][]*Task, max(levels, 1)), quantum: quantum, boostEvery: boostEvery}
+}
+
+func (q *feedbackQueue) Push(t *Task) {
+	t.level = 0
+	q.enqueue(t)
+}
+
+func (q *feedbackQueue) enqueue(t *Task) {
+	q.levels[t.level] = append(q.levels[t.level], t)
+	q.queued++
+}
+
+func (q *feedbackQueue) Pop() (*Task, time.Duration, bool) {
+	if q.boostEvery > 0 && q.turns > 0 && q.turns%q.boostEvery == 0 {
+		q.boost()
+	}
+	for level, tasks := range q.levels {
+		if len(tasks) == 0 {
+			continue
+		}
+		t := tasks[0]
+		tasks[0] = nil
+		q.levels[level] = tasks[1:]
+		q.queued--
+		q.turns++
+		return t, q.quantum << level, true
+	}
+	return nil, 0, false
+}
+
+// boost moves every task back to the top level, keeping their order
+func (q *feedbackQueue) boost() {
+	for level := 1; level < len(q.levels); level++ {
+		for _, t := range q.levels[level] {
+			t.level = 0
+			q.levels[0] = append(q.levels[0], t)
+		}
+		q.levels[level] = nil
+	}
+}
+
+func (q *feedbackQueue) Yield(t *Task, ran time.Duration) {
+	if ran >= q.quantum<<t.level {
+		t.level = min(t.level+1, len(q.levels)-1)
+	}
+	q.enqueue(t)
+}
+
+func (q *feedbackQueue) Len() int {
+	return q.queued
+}
+
+func bySeq(a, b *Task) bool { return a.seq < b.seq }
+
+// taskHeap orders tasks by less
+type taskHeap struct {
+	tasks []*Task
+	less  func(a, b *Task) bool
+}
+
+func (h taskHeap) Len() int { return len(h.tasks) }
+
+func (h taskHeap) Less(i, j int) bool { return h.less(h.tasks[i], h.tasks[j]) }
+
+func (h taskHeap) Swap(i, j int) { h.tasks[i], h.tasks[j] = h.tasks[j], h.tasks[i] }
+
+func (h *taskHeap) Push(x any) { h.tasks = append(h.tasks, x.(*Task)) }
+
+func (h *taskHeap) Pop() any {
+	old := h.tasks
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	h.tasks = old[:len(old)-1]
+	return t
+}
@@ -0,0 +1,90 @@
+package kronos_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/kronos"
+)
+
+// runAll drains a scheduler, each task running for what remains of its cost or its slice,
+// whichever is shorter, and returns the turns taken
+func runAll(s kronos.Scheduler, tasks []*kronos.Task, costs map[string]time.Duration) string {
+	for _, t := range tasks {
+		s.Push(t)
+	}
+	var turns []string
+	for {
+		t, slice, ok := s.Pop()
+		if !ok {
+			return strings.Join(turns, " ")
+		}
+		ran := costs[t.ID]
+		if slice > 0 {
+			ran = min(ran, slice)
+		}
+		costs[t.ID] -= ran
+		turns = append(turns, t.ID+":"+ran.String())
+		if costs[t.ID] > 0 {
+			s.Yield(t, ran)
+		}
+	}
+}
+
+func TestSchedulers(t *testing.T) {
+	base := time.Unix(1000, 0)
+	tasks := func() []*kronos.Task {
+		return []*kronos.Task{
+			{ID: "a", Estimate: 4 * time.Second, Priority: 3, Deadline: base.Add(2 * time.Second), Period: 20 * time.Millisecond},
+			{ID: "b", Estimate: 3 * time.Second, Priority: 2, Deadline: base.Add(time.Second), Period: 10 * time.Millisecond},
+			{ID: "c", Estimate: 5 * time.Second, Priority: 1},
+		}
+	}
+	costs := func() map[string]time.Duration {
+		return map[string]time.Duration{"a": 4 * time.Second, "b": 3 * time.Second, "c": 5 * time.Second}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		scheduler kronos.Scheduler
+		want      string
+	}{
+		{"fcfs", kronos.NewFCFS(), "a:4s b:3s c:5s"},
+		{"sjf", kronos.NewSJF(), "b:3s a:4s c:5s"},
+		{"priority", kronos.NewPriority(), "a:4s b:3s c:5s"},
+		{"round robin", kronos.NewRoundRobin(2 * time.Second), "a:2s b:2s c:2s a:2s b:1s c:2s c:1s"},
+		{"edf", kronos.NewEDF(), "b:3s a:4s c:5s"},
+		{"rate monotonic", kronos.NewRateMonotonic(), "b:3s a:4s c:5s"},
+		// c uses its 1s and then 2s slices in full and sinks to the bottom level
+		{"feedback queue", kronos.NewFeedbackQueue(3, time.Second, 0), "a:1s b:1s c:1s a:2s b:2s c:2s a:1s c:2s"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := runAll(tc.scheduler, tasks(), costs()); got != tc.want {
+				t.Fatalf("turns = %q, want %q", got, tc.want)
+			}
+			if tc.scheduler.Len() != 0 {
+				t.Fatalf("Len = %d after draining", tc.scheduler.Len())
+			}
+		})
+	}
+}
+
+func TestFeedbackQueueBoost(t *testing.T) {
+	s := kronos.NewFeedbackQueue(2, time.Second, 3)
+
+	// The task sinks to the bottom level and takes 2s turns, until the boost puts it back on top
+	got := runAll(s, []*kronos.Task{{ID: "long"}}, map[string]time.Duration{"long": 10 * time.Second})
+	if want := "long:1s long:2s long:2s long:1s long:2s long:2s"; got != want {
+		t.Fatalf("turns = %q, want %q", got, want)
+	}
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	s := kronos.NewWeightedRoundRobin(time.Second)
+	tasks := []*kronos.Task{{ID: "heavy", Weight: 3}, {ID: "light"}}
+	got := runAll(s, tasks, map[string]time.Duration{"heavy": 8 * time.Second, "light": 3 * time.Second})
+	if want := "heavy:3s light:1s heavy:3s light:1s heavy:2s light:1s"; got != want {
+		t.Fatalf("turns = %q, want %q", got, want)
+	}
+}
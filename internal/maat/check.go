@@ -0,0 +1,230 @@
+package maat
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// valueType is the static type of an expression
+type valueType int
+
+const (
+	typeDynamic valueType = iota // A payload value, only known at run time
+	typeBool
+	typeNumber
+	typeString
+	typeList
+	typeNull
+)
+
+func (t valueType) String() string {
+	switch t {
+	case typeBool:
+		return "boolean"
+	case typeNumber:
+		return "number"
+	case typeString:
+		return "string"
+	case typeList:
+		return "list"
+	case typeNull:
+		return "null"
+	default:
+		return "payload value"
+	}
+}
+
+// accepts reports whether a value of type t can be used where want is expected
+func (t valueType) accepts(want valueType) bool {
+	return t == want || t == typeDynamic
+}
+
+// comparable reports whether == and != between the types can ever be true
+func comparable(a, b valueType) bool {
+	return a == b || a == typeDynamic || b == typeDynamic || a == typeNull || b == typeNull
+}
+
+// signature describes a built-in function
+type signature struct {
+	params []valueType // typeDynamic accepts anything
+	result valueType
+}
+
+var functions = map[string]signature{
+	"contains":   {[]valueType{typeString, typeString}, typeBool},
+	"startsWith": {[]valueType{typeString, typeString}, typeBool},
+	"endsWith":   {[]valueType{typeString, typeString}, typeBool},
+	"lower":      {[]valueType{typeString}, typeString},
+	"upper":      {[]valueType{typeString}, typeString},
+	"len":        {[]valueType{typeDynamic}, typeNumber},          // Of a string or list
+	"matches":    {[]valueType{typeString, typeString}, typeBool}, // Regular expression, must be a literal
+	"exists":     {[]valueType{typeDynamic}, typeBool},            // Whether a header or payload path is present
+}
+
+type checker struct {
+	src string
+}
+
+// check type checks a syntax tree, returning the type of its root
+func check(src string, root node) (valueType, error) {
+	c := &checker{src: src}
+	return c.check(root)
+}
+
+func (c *checker) check(n node) (valueType, error) {
+	switch n := n.(type) {
+	case *literal:
+		switch n.val.(type) {
+		case float64:
+			return typeNumber, nil
+		case string:
+			return typeString, nil
+		case bool:
+			return typeBool, nil
+		default:
+			return typeNull, nil
+		}
+
+	case *listExpr:
+		for _, elem := range n.elems {
+			if _, err := c.check(elem); err != nil {
+				return 0, err
+			}
+		}
+		return typeList, nil
+
+	case *path:
+		switch n.root {
+		case "payload":
+			return typeDynamic, nil
+		case "headers":
+			if len(n.steps) != 1 {
+				return 0, c.errorf(n, "headers must be followed by a single header name, e.g. headers.region or headers[\"x-region\"]")
+			}
+			if _, ok := n.steps[0].(string); !ok {
+				return 0, c.errorf(n, "header names must be strings")
+			}
+			return typeString, nil
+		default:
+			if len(n.steps) > 0 {
+				return 0, c.errorf(n, "message field %s is a string and has no fields (use payload.%s to read the payload)", n.root, n.root)
+			}
+			return typeString, nil
+		}
+
+	case *unary:
+		t, err := c.check(n.arg)
+		if err != nil {
+			return 0, err
+		}
+		if n.op == "-" {
+			if !t.accepts(typeNumber) {
+				return 0, c.errorf(n, "cannot negate a %s", t)
+			}
+			return typeNumber, nil
+		}
+		if !t.accepts(typeBool) {
+			return 0, c.errorf(n, "cannot apply ! to a %s", t)
+		}
+		return typeBool, nil
+
+	case *binary:
+		x, err := c.check(n.x)
+		if err != nil {
+			return 0, err
+		}
+		y, err := c.check(n.y)
+		if err != nil {
+			return 0, err
+		}
+		return c.checkBinary(n, x, y)
+
+	case *call:
+		return c.checkCall(n)
+	}
+	return 0, fmt.Errorf("maat: unexpected node %T", n)
+}
+
+func (c *checker) checkBinary(n *binary, x, y valueType) (valueType, error) {
+	switch n.op {
+	case "&&", "||":
+		if !x.accepts(typeBool) {
+			return 0, c.errorf(n.x, "operands of %s must be conditions, not a %s", n.op, x)
+		}
+		if !y.accepts(typeBool) {
+			return 0, c.errorf(n.y, "operands of %s must be conditions, not a %s", n.op, y)
+		}
+
+	case "==", "!=":
+		if !comparable(x, y) {
+			return 0, c.errorf(n, "cannot compare %s with %s", x, y)
+		}
+
+	case "<", "<=", ">", ">=":
+		for _, t := range []valueType{x, y} {
+			if t != typeNumber && t != typeString && t != typeDynamic {
+				return 0, c.errorf(n, "cannot order a %s; %s compares numbers or strings", t, n.op)
+			}
+		}
+		if x != typeDynamic && y != typeDynamic && x != y {
+			return 0, c.errorf(n, "cannot compare %s with %s", x, y)
+		}
+
+	case "in", "not in":
+		if !y.accepts(typeList) {
+			return 0, c.errorf(n.y, "%s needs a list on its right, not a %s", n.op, y)
+		}
+		if list, ok := n.y.(*listExpr); ok {
+			for _, elem := range list.elems {
+				t, _ := c.check(elem)
+				if !comparable(x, t) {
+					return 0, c.errorf(elem, "list element is a %s but the value looked up is a %s", t, x)
+				}
+			}
+		}
+	}
+	return typeBool, nil
+}
+
+func (c *checker) checkCall(n *call) (valueType, error) {
+	sig, ok := functions[n.name]
+	if !ok {
+		return 0, c.errorf(n, "unknown function %s", n.name)
+	}
+	if len(n.args) != len(sig.params) {
+		return 0, c.errorf(n, "%s takes %d argument(s), got %d", n.name, len(sig.params), len(n.args))
+	}
+	for i, arg := range n.args {
+		t, err := c.check(arg)
+		if err != nil {
+			return 0, err
+		}
+		if sig.params[i] != typeDynamic && !t.accepts(sig.params[i]) {
+			return 0, c.errorf(arg, "argument %d of %s must be a %s, not a %s", i+1, n.name, sig.params[i], t)
+		}
+	}
+
+	switch n.name {
+	case "len":
+		if t, _ := c.check(n.args[0]); t != typeString && t != typeList && t != typeDynamic {
+			return 0, c.errorf(n.args[0], "len needs a string or list, not a %s", t)
+		}
+	case "matches":
+		lit, ok := n.args[1].(*literal)
+		if !ok {
+			return 0, c.errorf(n.args[1], "the pattern given to matches must be a string literal")
+		}
+		if _, err := regexp.Compile(lit.val.(string)); err != nil {
+			return 0, c.errorf(n.args[1], "invalid pattern: %v", err)
+		}
+	case "exists":
+		if p, ok := n.args[0].(*path); !ok || p.root != "payload" && p.root != "headers" {
+			return 0, c.errorf(n.args[0], "exists needs a header or payload path")
+		}
+	}
+	return sig.result, nil
+}
+
+func (c *checker) errorf(n node, format string, args ...interface{}) error {
+	return errorAt(c.src, n.pos(), format, args...)
+}
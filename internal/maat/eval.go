@@ -0,0 +1,258 @@
+package maat
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// evalFunc computes the value of a compiled expression: float64, string, bool, nil,
+// []interface{} or map[string]interface{}
+type evalFunc func(*env) interface{}
+
+// env is the message an expression is evaluated against; the payload is decoded at most once,
+// and only if the expression reads it
+type env struct {
+	in      Input
+	payload interface{}
+	decoded bool
+}
+
+func (e *env) root(name string) interface{} {
+	switch name {
+	case "id":
+		return e.in.ID
+	case "type":
+		return e.in.Type
+	case "key":
+		return e.in.Key
+	case "content_type":
+		return e.in.ContentType
+	case "correlation_id":
+		return e.in.CorrelationID
+	case "causation_id":
+		return e.in.CausationID
+	case "producer_id":
+		return e.in.ProducerID
+	}
+	if !e.decoded {
+		e.decoded = true
+		if json.Unmarshal(e.in.Payload, &e.payload) != nil {
+			e.payload = nil
+		}
+	}
+	return e.payload
+}
+
+// compile turns a type checked syntax tree into a closure
+func compile(n node) evalFunc {
+	switch n := n.(type) {
+	case *literal:
+		val := n.val
+		return func(*env) interface{} { return val }
+
+	case *listExpr:
+		elems := compileAll(n.elems)
+		return func(e *env) interface{} {
+			list := make([]interface{}, len(elems))
+			for i, elem := range elems {
+				list[i] = elem(e)
+			}
+			return list
+		}
+
+	case *path:
+		return compilePath(n)
+
+	case *unary:
+		arg := compile(n.arg)
+		if n.op == "-" {
+			return func(e *env) interface{} {
+				if f, ok := arg(e).(float64); ok {
+					return -f
+				}
+				return nil
+			}
+		}
+		return func(e *env) interface{} { return arg(e) != true }
+
+	case *binary:
+		return compileBinary(n)
+
+	case *call:
+		return compileCall(n)
+	}
+	panic("maat: unexpected node")
+}
+
+func compileAll(nodes []node) []evalFunc {
+	fns := make([]evalFunc, len(nodes))
+	for i, n := range nodes {
+		fns[i] = compile(n)
+	}
+	return fns
+}
+
+func compilePath(n *path) evalFunc {
+	root, steps := n.root, n.steps
+	if root == "headers" {
+		name := steps[0].(string)
+		return func(e *env) interface{} {
+			if v, ok := e.in.Headers[name]; ok {
+				return v
+			}
+			return nil
+		}
+	}
+	return func(e *env) interface{} {
+		v := e.root(root)
+		for _, step := range steps {
+			switch step := step.(type) {
+			case string:
+				m, ok := v.(map[string]interface{})
+				if !ok {
+					return nil
+				}
+				v = m[step]
+			case int:
+				list, ok := v.([]interface{})
+				if !ok || step >= len(list) {
+					return nil
+				}
+				v = list[step]
+			}
+		}
+		return v
+	}
+}
+
+func compileBinary(n *binary) evalFunc {
+	x, y := compile(n.x), compile(n.y)
+	switch n.op {
+	case "&&":
+		return func(e *env) interface{} { return x(e) == true && y(e) == true }
+	case "||":
+		return func(e *env) interface{} { return x(e) == true || y(e) == true }
+	case "==":
+		return func(e *env) interface{} { return equal(x(e), y(e)) }
+	case "!=":
+		return func(e *env) interface{} { return !equal(x(e), y(e)) }
+	case "in":
+		return func(e *env) interface{} { return member(x(e), y(e)) }
+	case "not in":
+		return func(e *env) interface{} { return !member(x(e), y(e)) }
+	}
+
+	op := n.op
+	return func(e *env) interface{} {
+		a, b := x(e), y(e)
+		var cmp int
+		switch a := a.(type) {
+		case float64:
+			b, ok := b.(float64)
+			if !ok {
+				return false
+			}
+			switch {
+			case a < b:
+				cmp = -1
+			case a > b:
+				cmp = 1
+			}
+		case string:
+			b, ok := b.(string)
+			if !ok {
+				return false
+			}
+			cmp = strings.Compare(a, b)
+		default:
+			return false
+		}
+		switch op {
+		case "<":
+			return cmp < 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		default:
+			return cmp >= 0
+		}
+	}
+}
+
+func compileCall(n *call) evalFunc {
+	args := compileAll(n.args)
+	strs := func(e *env) (string, string, bool) {
+		a, ok1 := args[0](e).(string)
+		b, ok2 := args[1](e).(string)
+		return a, b, ok1 && ok2
+	}
+
+	switch n.name {
+	case "contains":
+		return func(e *env) interface{} { a, b, ok := strs(e); return ok && strings.Contains(a, b) }
+	case "startsWith":
+		return func(e *env) interface{} { a, b, ok := strs(e); return ok && strings.HasPrefix(a, b) }
+	case "endsWith":
+		return func(e *env) interface{} { a, b, ok := strs(e); return ok && strings.HasSuffix(a, b) }
+	case "lower", "upper":
+		convert := strings.ToLower
+		if n.name == "upper" {
+			convert = strings.ToUpper
+		}
+		return func(e *env) interface{} {
+			if s, ok := args[0](e).(string); ok {
+				return convert(s)
+			}
+			return nil
+		}
+	case "len":
+		return func(e *env) interface{} {
+			switch v := args[0](e).(type) {
+			case string:
+				return float64(len([]rune(v)))
+			case []interface{}:
+				return float64(len(v))
+			}
+			return nil
+		}
+	case "matches":
+		re := regexp.MustCompile(n.args[1].(*literal).val.(string)) // Validated by the checker
+		return func(e *env) interface{} {
+			s, ok := args[0](e).(string)
+			return ok && re.MatchString(s)
+		}
+	case "exists":
+		p := n.args[0].(*path)
+		if p.root == "headers" {
+			name := p.steps[0].(string)
+			return func(e *env) interface{} { _, ok := e.in.Headers[name]; return ok }
+		}
+		return func(e *env) interface{} { return args[0](e) != nil }
+	}
+	panic("maat: unknown function " + n.name)
+}
+
+// equal compares two values; values of different types are never equal
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case float64, string, bool, nil:
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func member(v, list interface{}) bool {
+	elems, ok := list.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, elem := range elems {
+		if equal(v, elem) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+// Package maat compiles filter expressions that decide which messages a consumer receives, e.g.
+//
+//	temperature > 30 && headers.region in ["eu", "us"]
+//	startsWith(type, "order.") && !exists(payload.cancelled_at)
+//
+// An expression sees the message's id, type, key, content_type, correlation_id, causation_id and
+// producer_id, its headers (headers.name or headers["x-name"]) and its JSON payload. Payload fields are reached through payload.a.b[0],
+// $.a.b[0] or, when the name is not one of the message fields above, simply a.b[0]. Payload
+// values that are missing or of an unexpected type never match a comparison except !=.
+//
+// Supported: == != < <= > >= on numbers and strings, && || ! (or and, or, not), in and not in
+// against a list, and the functions contains, startsWith, endsWith, lower, upper, len, matches
+// and exists.
+package maat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Input is the part of a message a filter can see
+type Input struct {
+	ID            string
+	Type          string
+	Key           string
+	ContentType   string
+	CorrelationID string
+	CausationID   string
+	ProducerID    string
+	Headers       map[string]string
+	Payload       []byte // JSON; anything else reads as an empty payload
+}
+
+// Filter is a compiled filter expression, safe for concurrent use
+type Filter struct {
+	src  string
+	eval evalFunc
+}
+
+// Compile parses, type checks and compiles a filter expression. Errors are *Error values
+// pointing at the offending part of the expression.
+func Compile(expr string) (*Filter, error) {
+	root, err := parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := check(expr, root)
+	if err != nil {
+		return nil, err
+	}
+	if typ != typeBool && typ != typeDynamic {
+		return nil, errorAt(expr, root.pos(), "filter must be a condition, not a %s", typ)
+	}
+	return &Filter{src: expr, eval: compile(root)}, nil
+}
+
+// Match reports whether the message satisfies the filter. A nil filter matches everything.
+func (f *Filter) Match(in Input) bool {
+	if f == nil {
+		return true
+	}
+	return f.eval(&env{in: in}) == true
+}
+
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.src
+}
+
+// Error is a syntax or type error at a position in a filter expression
+type Error struct {
+	Offset int // Byte offset in the expression
+	Line   int // 1-based
+	Column int // 1-based, in characters
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+func errorAt(src string, offset int, format string, args ...interface{}) *Error {
+	before := src[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := len([]rune(before[strings.LastIndex(before, "\n")+1:])) + 1
+	return &Error{Offset: offset, Line: line, Column: column, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,120 @@
+package maat_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/a1mart/kafkaesque/internal/maat"
+)
+
+func TestMatch(t *testing.T) {
+	in := maat.Input{
+		ID:            "m-1",
+		Type:          "order.created",
+		Key:           "customer-7",
+		ContentType:   "application/json",
+		CorrelationID: "checkout-42",
+		ProducerID:    "orders-api",
+		Headers:       map[string]string{"region": "eu", "x-trace-id": "abc"},
+		Payload:       []byte(`{"temperature": 31.5, "sensor": {"name": "Boiler"}, "tags": ["hot", "indoor"], "cancelled_at": null}`),
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"temperature > 30", true},
+		{"temperature > 30 && temperature <= 31.5", true},
+		{"payload.temperature < -5", false},
+		{"$.sensor.name == 'Boiler'", true},
+		{"sensor.name != \"Boiler\"", false},
+		{"tags[0] == 'hot'", true},
+		{"tags[5] == 'hot'", false},
+		{"'indoor' in tags", true},
+		{"headers.region in ['eu', 'us']", true},
+		{"headers.region not in ['eu', 'us']", false},
+		{"headers[\"x-trace-id\"] == 'abc'", true},
+		{"headers.missing == null", true},
+		{"type == 'order.created' and key == 'customer-7' and id == 'm-1'", true},
+		{"startsWith(type, 'order.') && endsWith(key, '-7')", true},
+		{"content_type == 'application/json' && correlation_id == 'checkout-42'", true},
+		{"producer_id in ['orders-api'] && causation_id == ''", true},
+		{"contains(lower(sensor.name), 'boil')", true},
+		{"upper(headers.region) == 'EU'", true},
+		{"len(tags) == 2 && len(sensor.name) == 6", true},
+		{"matches(id, '^m-[0-9]+$')", true},
+		{"exists(payload.sensor) && !exists(headers.tenant)", true},
+		{"exists(cancelled_at)", false},
+		{"not (temperature > 30) or humidity > 10", false},
+		{"humidity > 10", false},    // Missing values never order
+		{"humidity != 10", true},    // ...but are unequal to everything
+		{"sensor.name > 30", false}, // Mismatched types at run time do not match
+		{"temperature == '31.5'", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := maat.Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if got := f.Match(in); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchNonJSONPayload(t *testing.T) {
+	f, err := maat.Compile("temperature > 30 || type == 'raw'")
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if !f.Match(maat.Input{Type: "raw", Payload: []byte("not json")}) {
+		t.Error("expected message fields to match when the payload is not JSON")
+	}
+
+	var none *maat.Filter
+	if !none.Match(maat.Input{}) {
+		t.Error("expected a nil filter to match everything")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		expr   string
+		line   int
+		column int
+	}{
+		{"", 1, 1},
+		{"temperature >", 1, 14},
+		{"temperature = 30", 1, 13},
+		{"(temperature > 30", 1, 18},
+		{"name == 'unterminated", 1, 9},
+		{"temperature > 30 &&\n  id > 5", 2, 6},
+		{"id.length == 3", 1, 1},
+		{"headers == 'x'", 1, 1},
+		{"temperature in 'hot'", 1, 16},
+		{"id in [1, 2]", 1, 8},
+		{"shout(id)", 1, 1},
+		{"contains(id)", 1, 1},
+		{"matches(id, '[')", 1, 13},
+		{"matches(id, type)", 1, 13},
+		{"exists(id)", 1, 8},
+		{"len(5)", 1, 5},
+		{"true > false", 1, 6},
+		{"id == 'a' 'b'", 1, 11},
+		{"@", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := maat.Compile(tt.expr)
+			var perr *maat.Error
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected *maat.Error, got %v", err)
+			}
+			if perr.Line != tt.line || perr.Column != tt.column {
+				t.Errorf("error at %d:%d, want %d:%d (%v)", perr.Line, perr.Column, tt.line, tt.column, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package maat
+
+import "fmt"
+
+// node is an expression in the syntax tree
+type node interface {
+	pos() int
+}
+
+type (
+	literal struct {
+		at  int
+		val interface{} // float64, string, bool or nil
+	}
+	listExpr struct {
+		at    int
+		elems []node
+	}
+	// path reads a message field, a header or a value inside the payload
+	path struct {
+		at    int
+		root  string        // One of messageFields
+		steps []interface{} // Field names (string) and list indexes (int) below the root
+	}
+	unary struct {
+		at  int
+		op  string // "!" or "-"
+		arg node
+	}
+	binary struct {
+		at   int // Position of the operator
+		op   string
+		x, y node
+	}
+	call struct {
+		at   int
+		name string
+		args []node
+	}
+)
+
+func (n *literal) pos() int  { return n.at }
+func (n *listExpr) pos() int { return n.at }
+func (n *path) pos() int     { return n.at }
+func (n *unary) pos() int    { return n.at }
+func (n *binary) pos() int   { return n.at }
+func (n *call) pos() int     { return n.at }
+
+// Message fields addressable by name; any other leading identifier reads the payload
+var messageFields = map[string]bool{
+	"id": true, "type": true, "key": true, "content_type": true, "correlation_id": true, "causation_id": true,
+	"producer_id": true, "headers": true, "payload": true,
+}
+
+type parser struct {
+	src    string
+	tokens []token
+	i      int
+}
+
+// parse builds the syntax tree of an expression:
+//
+//	or      = and { ("||" | "or") and }
+//	and     = not { ("&&" | "and") not }
+//	not     = ("!" | "not") not | compare
+//	compare = operand [ ("==" | "!=" | "<" | "<=" | ">" | ">=") operand | ["not"] "in" operand ]
+//	operand = "-" operand | number | string | "true" | "false" | "null" | "(" or ")"
+//	        | "[" [ or { "," or } ] "]" | ident "(" [ or { "," or } ] ")" | path
+//	path    = ( ident | "$" ) { "." ident | "[" ( string | number ) "]" }
+func parse(src string) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, errorAt(src, 0, "empty filter expression")
+	}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.unexpected(tok, "end of expression")
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+// is reports whether the next token is the given operator or keyword
+func (p *parser) is(text string) bool {
+	tok := p.peek()
+	return (tok.kind == tokOp || tok.kind == tokIdent) && tok.text == text
+}
+
+func (p *parser) expect(op string) (token, error) {
+	tok := p.next()
+	if tok.kind != tokOp || tok.text != op {
+		return tok, p.unexpected(tok, fmt.Sprintf("'%s'", op))
+	}
+	return tok, nil
+}
+
+func (p *parser) unexpected(tok token, want string) error {
+	switch tok.kind {
+	case tokEOF:
+		return errorAt(p.src, tok.pos, "unexpected end of expression, expected %s", want)
+	case tokString:
+		return errorAt(p.src, tok.pos, "unexpected string %q, expected %s", tok.text, want)
+	default:
+		return errorAt(p.src, tok.pos, "unexpected '%s', expected %s", tok.text, want)
+	}
+}
+
+func (p *parser) or() (node, error) {
+	x, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("||") || p.is("or") {
+		op := p.next()
+		y, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{at: op.pos, op: "||", x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) and() (node, error) {
+	x, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("&&") || p.is("and") {
+		op := p.next()
+		y, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{at: op.pos, op: "&&", x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) not() (node, error) {
+	if p.is("!") || p.is("not") {
+		op := p.next()
+		arg, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{at: op.pos, op: "!", arg: arg}, nil
+	}
+	return p.compare()
+}
+
+func (p *parser) compare() (node, error) {
+	x, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := p.peek(); {
+	case tok.kind == tokOp && (tok.text == "==" || tok.text == "!=" || tok.text == "<" || tok.text == "<=" || tok.text == ">" || tok.text == ">="):
+		p.next()
+		y, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		return &binary{at: tok.pos, op: tok.text, x: x, y: y}, nil
+
+	case p.is("in"), p.is("not") && p.tokens[p.i+1].kind == tokIdent && p.tokens[p.i+1].text == "in":
+		op := "in"
+		if p.next().text == "not" {
+			p.next()
+			op = "not in"
+		}
+		y, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		return &binary{at: tok.pos, op: op, x: x, y: y}, nil
+	}
+	return x, nil
+}
+
+func (p *parser) operand() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return &literal{at: tok.pos, val: tok.num}, nil
+	case tokString:
+		return &literal{at: tok.pos, val: tok.text}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true", "false":
+			return &literal{at: tok.pos, val: tok.text == "true"}, nil
+		case "null":
+			return &literal{at: tok.pos, val: nil}, nil
+		case "and", "or", "not", "in":
+			return nil, p.unexpected(tok, "a value")
+		}
+		if p.is("(") {
+			p.next()
+			args, err := p.list(")")
+			if err != nil {
+				return nil, err
+			}
+			return &call{at: tok.pos, name: tok.text, args: args}, nil
+		}
+		return p.path(tok)
+	case tokOp:
+		switch tok.text {
+		case "-":
+			arg, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			if lit, ok := arg.(*literal); ok {
+				if n, ok := lit.val.(float64); ok {
+					return &literal{at: tok.pos, val: -n}, nil
+				}
+			}
+			return &unary{at: tok.pos, op: "-", arg: arg}, nil
+		case "(":
+			n, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case "[":
+			elems, err := p.list("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listExpr{at: tok.pos, elems: elems}, nil
+		case "$":
+			return p.path(tok)
+		}
+	}
+	return nil, p.unexpected(tok, "a value")
+}
+
+// list parses comma-separated expressions up to and including the closing token
+func (p *parser) list(closing string) ([]node, error) {
+	var elems []node
+	if p.is(closing) {
+		p.next()
+		return elems, nil
+	}
+	for {
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, n)
+		tok := p.next()
+		if tok.kind == tokOp && tok.text == closing {
+			return elems, nil
+		}
+		if tok.kind != tokOp || tok.text != "," {
+			return nil, p.unexpected(tok, fmt.Sprintf("',' or '%s'", closing))
+		}
+	}
+}
+
+// path parses the field accesses following the first identifier (or "$") of a path
+func (p *parser) path(first token) (node, error) {
+	n := &path{at: first.pos, root: "payload"}
+	switch {
+	case first.text == "$":
+	case messageFields[first.text]:
+		n.root = first.text
+	default:
+		n.steps = append(n.steps, first.text)
+	}
+
+	for {
+		switch {
+		case p.is("."):
+			p.next()
+			tok := p.next()
+			if tok.kind != tokIdent {
+				return nil, p.unexpected(tok, "a field name")
+			}
+			n.steps = append(n.steps, tok.text)
+		case p.is("["):
+			p.next()
+			tok := p.next()
+			switch {
+			case tok.kind == tokString:
+				n.steps = append(n.steps, tok.text)
+			case tok.kind == tokNumber && tok.num >= 0 && tok.num == float64(int(tok.num)):
+				n.steps = append(n.steps, int(tok.num))
+			default:
+				return nil, p.unexpected(tok, "a quoted field name or list index")
+			}
+			if _, err := p.expect("]"); err != nil {
+				return nil, err
+			}
+		default:
+			return n, nil
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"regexp"
 )
@@ -163,8 +164,13 @@ func checkJsonPrimitiveType(expected string, value interface{}) bool {
 		_, ok := value.(bool)
 		return ok
 	case "integer":
-		_, ok := value.(int)
-		return ok
+		switch v := value.(type) {
+		case int:
+			return true
+		case float64: // JSON unmarshals numbers as float64
+			return v == math.Trunc(v)
+		}
+		return false
 	case "number":
 		_, ok := value.(float64)
 		return ok
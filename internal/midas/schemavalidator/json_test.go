@@ -12,19 +12,30 @@ func TestValidateJSON(t *testing.T) {
 		data      string
 		expectErr bool
 	}{
-		// {
-		// 	name: "Valid Object - Required Fields Present",
-		// 	schema: `{
-		// 		"type": "object",
-		// 		"properties": {
-		// 			"name": {"type": "string"},
-		// 			"age": {"type": "integer"}
-		// 		},
-		// 		"required": ["name", "age"]
-		// 	}`,
-		// 	data:      `{"name": "Alice", "age": 30}`,
-		// 	expectErr: false,
-		// },
+		{
+			name: "Valid Object - Required Fields Present",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"age": {"type": "integer"}
+				},
+				"required": ["name", "age"]
+			}`,
+			data:      `{"name": "Alice", "age": 30}`,
+			expectErr: false,
+		},
+		{
+			name: "Fractional Integer",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"age": {"type": "integer"}
+				}
+			}`,
+			data:      `{"age": 30.5}`,
+			expectErr: true,
+		},
 		{
 			name: "Missing Required Field",
 			schema: `{
@@ -1,59 +1,125 @@
 package mnemosyne
 
 import (
+	"container/list"
 	"sync"
 	"time"
 )
 
-// MemTable structure
+// MemTable is a key-value table whose entries expire ttl after they were last put. Since every
+// entry lives equally long, the entries kept in insertion order are also in expiry order, and the
+// cleanup routine only looks at the oldest ones.
 type MemTable struct {
-	data map[string]interface{}
-	mu   sync.RWMutex
-	ttl  time.Duration
+	data   map[string]*list.Element
+	expiry *list.List // memEntry values, oldest first
+	mu     sync.RWMutex
+	ttl    time.Duration
+	done   chan struct{}
+	once   sync.Once
 }
 
-// NewMemTable initializes a MemTable with TTL
+// memEntry is a value held in a MemTable
+type memEntry struct {
+	key      string
+	value    interface{}
+	inserted time.Time
+}
+
+// NewMemTable initializes a MemTable with TTL. A TTL of zero or less keeps entries until deleted.
 func NewMemTable(ttl time.Duration) *MemTable {
 	memTable := &MemTable{
-		data: make(map[string]interface{}),
-		ttl:  ttl,
+		data:   make(map[string]*list.Element),
+		expiry: list.New(),
+		ttl:    ttl,
+		done:   make(chan struct{}),
 	}
 
 	// Background cleanup routine
-	go memTable.cleanupExpired()
+	if ttl > 0 {
+		go memTable.cleanupExpired()
+	}
 	return memTable
 }
 
-// Put stores a message in the MemTable
+// Put stores a message in the MemTable, restarting its TTL
 func (mt *MemTable) Put(key string, value interface{}) {
 	mt.mu.Lock()
-	mt.data[key] = value
-	mt.mu.Unlock()
+	defer mt.mu.Unlock()
+	if el, ok := mt.data[key]; ok {
+		entry := el.Value.(*memEntry)
+		entry.value = value
+		entry.inserted = time.Now()
+		mt.expiry.MoveToBack(el)
+		return
+	}
+	mt.data[key] = mt.expiry.PushBack(&memEntry{key: key, value: value, inserted: time.Now()})
 }
 
-// Get retrieves a message
+// Get retrieves a message, treating one past its TTL as gone
 func (mt *MemTable) Get(key string) (interface{}, bool) {
 	mt.mu.RLock()
 	defer mt.mu.RUnlock()
-	val, exists := mt.data[key]
-	return val, exists
+	el, ok := mt.data[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*memEntry)
+	if mt.expired(entry, time.Now()) {
+		return nil, false
+	}
+	return entry.value, true
 }
 
 // Delete removes a message
 func (mt *MemTable) Delete(key string) {
 	mt.mu.Lock()
-	delete(mt.data, key)
-	mt.mu.Unlock()
+	defer mt.mu.Unlock()
+	if el, ok := mt.data[key]; ok {
+		mt.expiry.Remove(el)
+		delete(mt.data, key)
+	}
 }
 
-// Cleanup expired messages
+// Len returns the number of entries held, including expired ones not cleaned up yet
+func (mt *MemTable) Len() int {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	return len(mt.data)
+}
+
+// Close stops the cleanup routine
+func (mt *MemTable) Close() {
+	mt.once.Do(func() { close(mt.done) })
+}
+
+func (mt *MemTable) expired(entry *memEntry, now time.Time) bool {
+	return mt.ttl > 0 && !now.Before(entry.inserted.Add(mt.ttl))
+}
+
+// Cleanup expired messages, waking when the oldest entry is due to expire
 func (mt *MemTable) cleanupExpired() {
+	timer := time.NewTimer(mt.ttl)
+	defer timer.Stop()
 	for {
-		time.Sleep(mt.ttl)
+		select {
+		case <-mt.done:
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		wait := mt.ttl
 		mt.mu.Lock()
-		for key := range mt.data {
-			delete(mt.data, key)
+		for el := mt.expiry.Front(); el != nil; el = mt.expiry.Front() {
+			entry := el.Value.(*memEntry)
+			if !mt.expired(entry, now) {
+				wait = entry.inserted.Add(mt.ttl).Sub(now)
+				break
+			}
+			mt.expiry.Remove(el)
+			delete(mt.data, entry.key)
 		}
 		mt.mu.Unlock()
+		timer.Reset(wait)
 	}
 }
@@ -0,0 +1,60 @@
+package mnemosyne_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/mnemosyne"
+)
+
+func TestMemTableExpiresEntriesIndividually(t *testing.T) {
+	const ttl = 200 * time.Millisecond
+	mt := mnemosyne.NewMemTable(ttl)
+	defer mt.Close()
+
+	mt.Put("old", 1)
+	time.Sleep(ttl / 2)
+	mt.Put("new", 2)
+	mt.Put("refreshed", 3)
+	time.Sleep(ttl/2 + ttl/4)
+
+	if _, ok := mt.Get("old"); ok {
+		t.Fatal("Get returned an entry past its TTL")
+	}
+	if v, ok := mt.Get("new"); !ok || v != 2 {
+		t.Fatalf("Get(new) = %v, %v; want 2, true", v, ok)
+	}
+
+	// Putting a key again restarts its TTL
+	mt.Put("refreshed", 4)
+	time.Sleep(ttl/2 + ttl/4)
+	if _, ok := mt.Get("new"); ok {
+		t.Fatal("Get returned an entry past its TTL")
+	}
+	if v, ok := mt.Get("refreshed"); !ok || v != 4 {
+		t.Fatalf("Get(refreshed) = %v, %v; want 4, true", v, ok)
+	}
+
+	// The cleanup routine removes expired entries rather than the whole table
+	deadline := time.Now().Add(time.Second)
+	for mt.Len() != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if mt.Len() != 1 {
+		t.Fatalf("Len = %d after cleanup, want 1", mt.Len())
+	}
+}
+
+func TestMemTableWithoutTTL(t *testing.T) {
+	mt := mnemosyne.NewMemTable(0)
+	defer mt.Close()
+
+	mt.Put("k", "v")
+	if v, ok := mt.Get("k"); !ok || v != "v" {
+		t.Fatalf("Get(k) = %v, %v", v, ok)
+	}
+	mt.Delete("k")
+	if _, ok := mt.Get("k"); ok || mt.Len() != 0 {
+		t.Fatal("Delete left the entry behind")
+	}
+}
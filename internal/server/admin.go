@@ -2,26 +2,60 @@ package server
 
 import (
 	"context"
+	"errors"
 	"log"
+	"os"
+	"path/filepath"
+	"sort"
 
 	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Admin Service: Create Topic
 func (s *Server) CreateTopic(ctx context.Context, req *messaging.CreateTopicRequest) (*messaging.CreateTopicResponse, error) {
-	topic := req.GetTopic()
+	name := req.GetTopic()
 	strategy := req.GetStrategy()
 
-	if topic == "" || strategy == "" {
+	if name == "" || strategy == "" {
 		return &messaging.CreateTopicResponse{Success: false, Error: "Invalid topic or strategy"}, nil
 	}
+	if !validTopicName.MatchString(name) {
+		return &messaging.CreateTopicResponse{Success: false, Error: "Topic names may only contain letters, digits, '.', '_' and '-'"}, nil
+	}
+	if strategy != strategyRoundRobin && strategy != strategyBroadcast {
+		return &messaging.CreateTopicResponse{Success: false, Error: "Strategy must be \"round_robin\" or \"broadcast\""}, nil
+	}
+
+	config, err := newTopicConfig(req.GetConfig(), s.cfg.Topics)
+	if err != nil {
+		return &messaging.CreateTopicResponse{Success: false, Error: err.Error()}, nil
+	}
+	if err := s.checkSchema(config); err != nil {
+		return &messaging.CreateTopicResponse{Success: false, Error: err.Error()}, nil
+	}
+	settings := topicSettings{topicConfig: config}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if _, exists := s.topics[topic]; exists {
+	if _, exists := s.topics[name]; exists {
 		return &messaging.CreateTopicResponse{Success: false, Error: "Topic already exists"}, nil
 	}
 
-	s.topics[topic] = strategy
-	log.Printf("Created topic: %s with strategy: %s", topic, strategy)
+	t, err := newTopic(name, strategy, settings, s.cfg)
+	if err != nil {
+		return &messaging.CreateTopicResponse{Success: false, Error: err.Error()}, nil
+	}
+	if err := s.saveTopicMeta(t); err != nil {
+		t.close()
+		return nil, status.Errorf(codes.Internal, "persisting topic %q: %v", name, err)
+	}
+
+	s.topics[name] = t
+	log.Printf("Created topic: %s with strategy: %s and %d partitions", name, strategy, config.partitions)
 	return &messaging.CreateTopicResponse{Success: true}, nil
 }
 
@@ -29,17 +63,139 @@ func (s *Server) CreateTopic(ctx context.Context, req *messaging.CreateTopicRequ
 func (s *Server) ListTopics(ctx context.Context, req *messaging.ListTopicsRequest) (*messaging.ListTopicsResponse, error) {
 	var topicList []*messaging.TopicInfo
 
-	for topic, strategy := range s.topics {
-		topicList = append(topicList, &messaging.TopicInfo{Topic: topic, Strategy: strategy})
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for name, t := range s.topics {
+		topicList = append(topicList, &messaging.TopicInfo{
+			Topic:       name,
+			Strategy:    t.strategy,
+			Depth:       t.depth(),
+			Partitions:  int32(t.partitionCount()),
+			Partitioner: t.config().partitioner,
+		})
 	}
 
 	return &messaging.ListTopicsResponse{Topics: topicList}, nil
 }
 
+// Admin Service: Describe a topic's effective configuration and consumer groups
+func (s *Server) DescribeTopic(ctx context.Context, req *messaging.DescribeTopicRequest) (*messaging.DescribeTopicResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	return &messaging.DescribeTopicResponse{
+		Topic:                t.name,
+		Strategy:             t.strategy,
+		Config:               t.config().proto(),
+		Depth:                t.depth(),
+		ConsumerGroups:       t.groupNames(),
+		PausedConsumerGroups: t.pausedGroups(),
+		ConsumerWeights:      consumerWeights(t.groupWeights()),
+	}, nil
+}
+
+// Admin Service: Alter Topic Config, changing only the fields listed in the request
+func (s *Server) AlterTopicConfig(ctx context.Context, req *messaging.AlterTopicConfigRequest) (*messaging.AlterTopicConfigResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	config, err := t.alterConfig(req.GetConfig(), req.GetFields(), s.cfg.Topics, s.checkSchema)
+	if err != nil {
+		return &messaging.AlterTopicConfigResponse{Success: false, Error: err.Error()}, nil
+	}
+	if err := s.saveTopicMeta(t); err != nil {
+		return nil, status.Errorf(codes.Internal, "persisting config of topic %q: %v", t.name, err)
+	}
+	log.Printf("Altered config of topic: %s (%v)", t.name, req.GetFields())
+	return &messaging.AlterTopicConfigResponse{Success: true, Config: config.proto()}, nil
+}
+
+// checkSchema verifies that the schema a topic validates against is registered
+func (s *Server) checkSchema(config topicConfig) error {
+	if config.schemaName == "" {
+		return nil
+	}
+	_, err := s.schemas.lookup(config.schemaName, config.schemaVersion)
+	return err
+}
+
+// Admin Service: List the consumer groups registered on any topic
 func (s *Server) ListConsumers(ctx context.Context, req *messaging.ListConsumersRequest) (*messaging.ListConsumersResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
 	var consumers []string
-	for c := range s.consumerMap {
-		consumers = append(consumers, c)
+	for _, t := range s.topics {
+		for _, group := range t.groupNames() {
+			if !seen[group] {
+				seen[group] = true
+				consumers = append(consumers, group)
+			}
+		}
 	}
+	sort.Strings(consumers)
 	return &messaging.ListConsumersResponse{ConsumerGroups: consumers}, nil
 }
+
+// Admin Service: List Partitions with their write and consumer group offsets
+func (s *Server) ListPartitions(ctx context.Context, req *messaging.ListPartitionsRequest) (*messaging.ListPartitionsResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make([]*messaging.PartitionInfo, 0, len(t.partitions))
+	for _, p := range t.partitions {
+		partitions = append(partitions, &messaging.PartitionInfo{
+			Partition:       int32(p.index),
+			NextOffset:      p.rb.WriteCursor(),
+			Depth:           p.rb.Depth(),
+			ConsumerOffsets: p.readPositions(),
+			Priority:        int32(p.lane),
+		})
+	}
+	return &messaging.ListPartitionsResponse{Partitions: partitions}, nil
+}
+
+// Admin Service: Delete a topic along with its messages, consumer groups, offsets and on-disk data.
+// Publishes already in flight finish first; later ones, and consumers still reading, get NotFound.
+func (s *Server) DeleteTopic(ctx context.Context, req *messaging.DeleteTopicRequest) (*messaging.DeleteTopicResponse, error) {
+	if !s.cfg.AllowDeleteTopics {
+		return nil, status.Error(codes.FailedPrecondition, "topic deletion is disabled; start the broker with -allow-delete-topics")
+	}
+	name := req.GetTopic()
+
+	// Held throughout so the name cannot be reused before the old data is gone
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, exists := s.topics[name]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "topic %q not found", name)
+	}
+	delete(s.topics, name)
+	t.drain()
+	for _, group := range t.groupNames() {
+		s.dispatch.Forget(dispatchFlow(name, group))
+	}
+	if err := t.close(); err != nil {
+		log.Printf("Failed to close deleted topic %s: %v", name, err)
+	}
+
+	if s.cfg.DataDir != "" {
+		// Remove the metadata first so a crash part way through does not bring back a partial topic
+		dir := filepath.Join(s.cfg.DataDir, name)
+		if err := os.Remove(filepath.Join(dir, topicMetaFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, status.Errorf(codes.Internal, "removing topic %q: %v", name, err)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, status.Errorf(codes.Internal, "removing data of topic %q: %v", name, err)
+		}
+	}
+	log.Printf("Deleted topic: %s", name)
+	return &messaging.DeleteTopicResponse{Success: true}, nil
+}
@@ -0,0 +1,175 @@
+package server_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestDeleteTopicDisabled ensures topics cannot be deleted unless the broker allows it
+func TestDeleteTopicDisabled(t *testing.T) {
+	b := start(t, server.DefaultConfig())
+	createTopic(t, b, "orders", nil)
+	_, err := b.DeleteTopic(context.Background(), &messaging.DeleteTopicRequest{Topic: "orders"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("deleting with deletion disabled returned %v, want FailedPrecondition", err)
+	}
+	publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: "order-1"}})
+}
+
+// TestDeleteTopic ensures deleting a topic ends its streams and rejects later publishes with
+// NotFound, removes its data, and leaves the name free for a new, empty topic
+func TestDeleteTopic(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		cfg.AllowDeleteTopics = true
+		b := start(t, cfg)
+		createTopic(t, b, "orders", nil)
+		register(t, b, "orders", "billing")
+		publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: "order-1"}})
+		stream, cancel := openStream(b, &messaging.StreamRequest{Topic: "orders", ConsumerGroup: "billing", ConsumerId: "reader"})
+		if got := stream.receive(time.Second); len(got) != 1 {
+			t.Fatalf("streamed %v, want [order-1]", ids(got))
+		}
+
+		if _, err := b.DeleteTopic(context.Background(), &messaging.DeleteTopicRequest{Topic: "orders"}); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case err := <-stream.done:
+			if status.Code(err) != codes.NotFound {
+				t.Fatalf("stream of a deleted topic returned %v, want NotFound", err)
+			}
+		case <-time.After(time.Second):
+			cancel()
+			t.Fatal("stream of a deleted topic still open")
+		}
+		_, err := b.Publish(context.Background(), &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: "order-2"}})
+		if status.Code(err) != codes.NotFound {
+			t.Fatalf("publishing to a deleted topic returned %v, want NotFound", err)
+		}
+		if cfg.DataDir != "" {
+			if _, err := os.Stat(filepath.Join(cfg.DataDir, "orders")); !os.IsNotExist(err) {
+				t.Fatalf("data of the deleted topic left behind: %v", err)
+			}
+			b = b.restart(t, cfg)
+			if _, err := b.DescribeTopic(context.Background(), &messaging.DescribeTopicRequest{Topic: "orders"}); status.Code(err) != codes.NotFound {
+				t.Fatalf("deleted topic came back after a restart: %v", err)
+			}
+		}
+
+		createTopic(t, b, "orders", nil)
+		register(t, b, "orders", "billing")
+		if got := consume(t, b, &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 10}); len(got) != 0 {
+			t.Fatalf("recreated topic holds %v", ids(got))
+		}
+	})
+}
+
+func describe(t *testing.T, b *broker, topic string) *messaging.TopicConfig {
+	t.Helper()
+	resp, err := b.DescribeTopic(context.Background(), &messaging.DescribeTopicRequest{Topic: topic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.GetConfig()
+}
+
+func alter(b *broker, topic string, config *messaging.TopicConfig, fields ...string) (*messaging.AlterTopicConfigResponse, error) {
+	return b.AlterTopicConfig(context.Background(), &messaging.AlterTopicConfigRequest{Topic: topic, Config: config, Fields: fields})
+}
+
+// TestDescribeTopicDefaults ensures DescribeTopic reports the settings a topic was created with
+// and the broker defaults for the rest
+func TestDescribeTopicDefaults(t *testing.T) {
+	b := start(t, server.DefaultConfig())
+	createTopic(t, b, "orders", &messaging.TopicConfig{Partitions: 3, RetentionMs: 60000})
+
+	config := describe(t, b, "orders")
+	if config.GetPartitions() != 3 || config.GetRetentionMs() != 60000 {
+		t.Fatalf("config = %v, want the settings it was created with", config)
+	}
+	if config.GetOverflowPolicy() != "block" || config.GetVisibilityTimeoutMs() != 30000 ||
+		config.GetMaxMessageBytes() != 1048576 || config.GetCleanupPolicy() != "delete" ||
+		config.GetMaxDeliveries() != -1 || config.GetRetentionBytes() != -1 {
+		t.Fatalf("config = %v, want broker defaults", config)
+	}
+}
+
+// TestAlterTopicConfig ensures altering a topic changes only the fields listed, takes effect at
+// once, survives a restart, and reverts a field listed but left unset to its default
+func TestAlterTopicConfig(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", &messaging.TopicConfig{RetentionMs: 60000})
+		large := &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: "large", Payload: make([]byte, 200)}}
+
+		resp, err := alter(b, "orders", &messaging.TopicConfig{MaxMessageBytes: 100, RetentionMs: 1000}, "max_message_bytes")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.GetSuccess() {
+			t.Fatalf("altering: %s", resp.GetError())
+		}
+		if _, err := b.Publish(context.Background(), large); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("publishing past the altered size limit returned %v, want InvalidArgument", err)
+		}
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+		if config := describe(t, b, "orders"); config.GetMaxMessageBytes() != 100 || config.GetRetentionMs() != 60000 {
+			t.Fatalf("config = %v, want max_message_bytes 100 and retention_ms unchanged", config)
+		}
+
+		if resp, err := alter(b, "orders", nil, "max_message_bytes"); err != nil || !resp.GetSuccess() {
+			t.Fatalf("reverting: %v %s", err, resp.GetError())
+		}
+		if config := describe(t, b, "orders"); config.GetMaxMessageBytes() != 1048576 {
+			t.Fatalf("max_message_bytes = %d after reverting, want the default", config.GetMaxMessageBytes())
+		}
+		publish(t, b, large)
+	})
+}
+
+// TestTopicConfigRejected ensures topics cannot be created or altered with invalid settings, nor
+// have their fixed settings altered
+func TestTopicConfigRejected(t *testing.T) {
+	b := start(t, server.DefaultConfig())
+	resp, err := b.CreateTopic(context.Background(), &messaging.CreateTopicRequest{Topic: "orders", Strategy: "round_robin", Config: &messaging.TopicConfig{OverflowPolicy: "sometimes"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetSuccess() {
+		t.Fatal("created a topic with an unknown overflow policy")
+	}
+
+	createTopic(t, b, "orders", nil)
+	for _, tc := range []struct {
+		name   string
+		config *messaging.TopicConfig
+		field  string
+	}{
+		{"negative limit", &messaging.TopicConfig{RetentionBytes: -2}, "retention_bytes"},
+		{"fixed at creation", &messaging.TopicConfig{Partitions: 4}, "partitions"},
+		{"unknown field", &messaging.TopicConfig{}, "colour"},
+		{"unregistered schema", &messaging.TopicConfig{SchemaName: "Missing"}, "schema_name"},
+	} {
+		resp, err := alter(b, "orders", tc.config, tc.field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetSuccess() {
+			t.Errorf("%s: altering %s succeeded", tc.name, tc.field)
+		}
+	}
+	if config := describe(t, b, "orders"); config.GetPartitions() != 1 || config.GetRetentionBytes() != -1 || config.GetSchemaName() != "" {
+		t.Fatalf("config = %v after rejected changes", config)
+	}
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/draupnir"
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxStreamBatch = 256 // Streamed messages PublishStream writes at once
+
+// PublishBatch publishes several messages in one call. Messages bound for the same partition are
+// written as one contiguous run of offsets. In atomic mode the batch must go to a single partition,
+// and a message that fails validation, or a lack of room for all of them, rejects the whole batch.
+func (s *Server) PublishBatch(ctx context.Context, req *messaging.PublishBatchRequest) (*messaging.PublishBatchResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	if err := t.beginPublish(); err != nil {
+		return nil, err
+	}
+	defer t.endPublish()
+	txn, err := s.joinTransaction(req.GetTransactionId())
+	if err != nil {
+		return nil, err
+	}
+	if txn != nil {
+		defer txn.mu.Unlock()
+	}
+
+	messages := req.GetMessages()
+	var pins []int32
+	if req.Partition != nil {
+		pins = make([]int32, len(messages))
+		for i := range pins {
+			pins[i] = req.GetPartition()
+		}
+	}
+	return s.publishBatch(t, messages, pins, req.GetAtomic(), txn), nil
+}
+
+// PublishStream publishes the messages a client streams, writing them in batches and scheduling
+// those with a delivery time, and reports the result of each once the client closes the stream
+func (s *Server) PublishStream(stream messaging.MessagingService_PublishStreamServer) error {
+	var results []*messaging.PublishResult
+	var topic, txnID string
+	var pending []*messaging.Message
+	var pins []int32 // Partition of each pending message, or -1 to let the partitioner pick
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		t, err := s.getTopic(topic)
+		if err == nil {
+			if err = t.beginPublish(); err == nil {
+				var txn *transaction
+				if txn, err = s.joinTransaction(txnID); err == nil {
+					results = append(results, s.publishBatch(t, pending, pins, false, txn).GetResults()...)
+					if txn != nil {
+						txn.mu.Unlock()
+					}
+				}
+				t.endPublish()
+			}
+		}
+		if err != nil {
+			for range pending {
+				results = append(results, &messaging.PublishResult{Error: status.Convert(err).Message()})
+			}
+		}
+		pending = pending[:0]
+		pins = pins[:0]
+	}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			flush()
+			return stream.SendAndClose(batchResponse(results, ""))
+		}
+		if err != nil {
+			return err
+		}
+		// Messages to deliver later are scheduled one by one as Publish does, after those before them
+		if at, err := deliverAt(req, time.Now()); err != nil || !at.IsZero() {
+			flush()
+			resp, err := s.Publish(stream.Context(), req)
+			if err != nil {
+				results = append(results, &messaging.PublishResult{Error: status.Convert(err).Message()})
+			} else {
+				results = append(results, &messaging.PublishResult{Success: resp.GetSuccess(), Error: resp.GetError(), Partition: resp.GetPartition(), Offset: resp.GetOffset(), Scheduled: resp.GetScheduled()})
+			}
+			continue
+		}
+		if req.GetTopic() != topic || req.GetTransactionId() != txnID || len(pending) == maxStreamBatch {
+			flush()
+		}
+		topic, txnID = req.GetTopic(), req.GetTransactionId()
+		pending = append(pending, req.GetMessage())
+		if req.Partition != nil {
+			pins = append(pins, req.GetPartition())
+		} else {
+			pins = append(pins, -1)
+		}
+	}
+}
+
+// publishBatch validates the messages and appends them to their partitions, one run per
+// partition, returning a result per message in order. Messages with a pin (not -1) go to that
+// partition; pins may be nil. Messages are published in txn unless it is nil; the caller holds
+// txn.mu.
+func (s *Server) publishBatch(t *topic, messages []*messaging.Message, pins []int32, atomic bool, txn *transaction) *messaging.PublishBatchResponse {
+	results := make([]*messaging.PublishResult, len(messages))
+	config := t.config()
+
+	// Validate everything and pick partitions before writing anything
+	byPartition := make(map[*partition][]int)
+	for i, msg := range messages {
+		var err error
+		if msg == nil {
+			err = errors.New("message is nil")
+		} else {
+			txn.claim(msg)
+			err = s.validate(t, config, msg)
+		}
+		if err == nil {
+			pin := -1
+			if pins != nil {
+				pin = int(pins[i])
+			}
+			var p *partition
+			if p, err = t.route(msg, pin); err == nil && atomic && len(byPartition) > 0 && byPartition[p] == nil {
+				err = status.Error(codes.InvalidArgument, "an atomic batch must go to a single partition and priority; pin the partition or give the messages one key")
+			}
+			if err == nil {
+				txn.enlist(t, p)
+				byPartition[p] = append(byPartition[p], i)
+				continue
+			}
+		}
+
+		var invalid *schemaError
+		if atomic {
+			if errors.As(err, &invalid) {
+				err = fmt.Errorf("schema validation failed: %w", invalid.err) // Nothing is dead-lettered
+			}
+			return rejectBatch(results, i, err)
+		}
+		if errors.As(err, &invalid) {
+			if dlqErr := s.deadLetterInvalid(t, msg, invalid); dlqErr != nil {
+				err = dlqErr
+			}
+		}
+		results[i] = &messaging.PublishResult{Error: status.Convert(err).Message()}
+	}
+
+	partitions := make([]*partition, 0, len(byPartition))
+	for p := range byPartition {
+		partitions = append(partitions, p)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].id < partitions[j].id })
+
+	if atomic {
+		if len(partitions) == 1 {
+			p := partitions[0]
+			if i, err := appendAtomic(t, p, byPartition[p], messages, results); err != nil {
+				return rejectBatch(results, i, err)
+			}
+		}
+	} else {
+		for _, p := range partitions {
+			indexes := byPartition[p]
+			for len(indexes) > 0 {
+				// A run can take at most the whole ring buffer
+				n := min(len(indexes), p.rb.Size())
+				outcomes, err := p.append(pick(messages, indexes[:n])...)
+				record(t, p, indexes[:n], outcomes, err, results)
+				indexes = indexes[n:]
+			}
+		}
+	}
+
+	published := 0
+	for _, r := range results {
+		if r.GetSuccess() {
+			published++
+		}
+	}
+	log.Printf("Published %d of %d messages in a batch to topic: %s", published, len(messages), t.name)
+	return batchResponse(results, "")
+}
+
+// appendAtomic appends the run of an atomic batch, which goes to a single partition, once all of
+// it has room and no message in it is rejected; otherwise it returns the index of a message that
+// failed. Runs on several partitions could not be undone once one of them was written, so atomic
+// batches are kept to one.
+func appendAtomic(t *topic, p *partition, indexes []int, messages []*messaging.Message, results []*messaging.PublishResult) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(indexes) > p.rb.Size() {
+		return indexes[0], appendError(t, p, fmt.Errorf("%w: %d messages for one partition exceed its ring buffer", draupnir.ErrFull, len(indexes)))
+	}
+	batched := make(map[string]int64)
+	seen := make(map[string]bool)
+	for _, i := range indexes {
+		e, ok := p.dedup.entry(messages[i])
+		if !ok || seen[e.key] {
+			continue
+		}
+		seen[e.key] = true
+		_, dup, err := p.dedup.lookup(e, batched)
+		if err != nil {
+			return i, err
+		}
+		if !dup && e.producer != "" {
+			batched[e.producer] = e.sequence
+		}
+	}
+	if err := p.reserve(len(indexes)); err != nil {
+		if errors.Is(err, draupnir.ErrDropped) {
+			return indexes[0], err
+		}
+		return indexes[0], appendError(t, p, err)
+	}
+	// Room is reserved, so only a failing log write can stop the run now
+	outcomes, err := p.appendLocked(pick(messages, indexes))
+	if err != nil {
+		return indexes[0], appendError(t, p, err)
+	}
+	record(t, p, indexes, outcomes, nil, results)
+	return 0, nil
+}
+
+// record fills in the results of a run of messages from their outcomes, or from err if the run
+// failed as a whole
+func record(t *topic, p *partition, indexes []int, outcomes []appended, err error, results []*messaging.PublishResult) {
+	var reason string
+	if errors.Is(err, draupnir.ErrDropped) {
+		reason = err.Error()
+	} else if err != nil {
+		reason = status.Convert(appendError(t, p, err)).Message()
+	}
+	for i, index := range indexes {
+		if err != nil {
+			results[index] = &messaging.PublishResult{Error: reason, Partition: int32(p.index), Priority: int32(p.lane)}
+			continue
+		}
+		if outcome := outcomes[i]; outcome.err != nil {
+			results[index] = &messaging.PublishResult{Error: status.Convert(outcome.err).Message(), Partition: int32(p.index), Priority: int32(p.lane)}
+		} else {
+			results[index] = &messaging.PublishResult{Success: true, Partition: int32(p.index), Offset: outcome.offset, Duplicate: outcome.duplicate, Priority: int32(p.lane)}
+		}
+	}
+}
+
+// rejectBatch fails every message of an atomic batch because of the one at index failed
+func rejectBatch(results []*messaging.PublishResult, failed int, err error) *messaging.PublishBatchResponse {
+	reason := status.Convert(err).Message()
+	for i := range results {
+		results[i] = &messaging.PublishResult{Error: fmt.Sprintf("batch rejected: message %d: %s", failed, reason)}
+	}
+	results[failed].Error = reason
+	return batchResponse(results, fmt.Sprintf("message %d: %s", failed, reason))
+}
+
+// batchResponse summarizes the results of a batch
+func batchResponse(results []*messaging.PublishResult, reason string) *messaging.PublishBatchResponse {
+	failed := 0
+	for _, r := range results {
+		if !r.GetSuccess() {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return &messaging.PublishBatchResponse{Success: true, Results: results}
+	}
+	if reason == "" {
+		reason = fmt.Sprintf("%d of %d messages were not published", failed, len(results))
+	}
+	return &messaging.PublishBatchResponse{Success: false, Error: reason, Results: results}
+}
+
+// pick returns the messages at the given indexes
+func pick(messages []*messaging.Message, indexes []int) []*messaging.Message {
+	picked := make([]*messaging.Message, len(indexes))
+	for i, index := range indexes {
+		picked[i] = messages[index]
+	}
+	return picked
+}
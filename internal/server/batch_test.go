@@ -0,0 +1,176 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+
+	"google.golang.org/grpc"
+)
+
+func publishBatch(t *testing.T, b *broker, req *messaging.PublishBatchRequest) *messaging.PublishBatchResponse {
+	t.Helper()
+	resp, err := b.PublishBatch(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.GetResults()) != len(req.GetMessages()) {
+		t.Fatalf("got %d results for %d messages", len(resp.GetResults()), len(req.GetMessages()))
+	}
+	return resp
+}
+
+// TestPublishBatch ensures a batch is written as a contiguous run of offsets with a result per
+// message, and a message that is rejected does not hold back the rest
+func TestPublishBatch(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", &messaging.TopicConfig{MaxMessageBytes: 100})
+		register(t, b, "orders", "billing")
+		publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: "first"}})
+
+		resp := publishBatch(t, b, &messaging.PublishBatchRequest{Topic: "orders", Messages: []*messaging.Message{
+			{Id: "a"},
+			{Id: "large", Payload: make([]byte, 200)},
+			{Id: "b"},
+		}})
+		if resp.GetSuccess() {
+			t.Fatal("batch with a rejected message reported success")
+		}
+		results := resp.GetResults()
+		if !results[0].GetSuccess() || results[0].GetOffset() != 1 || !results[2].GetSuccess() || results[2].GetOffset() != 2 {
+			t.Fatalf("results = %v, want a and b at offsets 1 and 2", results)
+		}
+		if results[1].GetSuccess() || results[1].GetError() == "" {
+			t.Fatalf("result of the oversized message = %v, want an error", results[1])
+		}
+
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+		if got := ids(consume(t, b, &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 10})); fmt.Sprint(got) != "[first a b]" {
+			t.Fatalf("got %v, want [first a b]", got)
+		}
+	})
+}
+
+// TestAtomicBatch ensures an atomic batch is published whole or not at all, and only to a single
+// partition
+func TestAtomicBatch(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", &messaging.TopicConfig{Partitions: 4, Partitioner: "hash", MaxMessageBytes: 100})
+		register(t, b, "orders", "billing")
+		consumeReq := &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 10}
+
+		rejected := []*messaging.PublishBatchRequest{
+			{Topic: "orders", Atomic: true, Messages: []*messaging.Message{
+				{Id: "a", Key: "customer-1"},
+				{Id: "large", Key: "customer-1", Payload: make([]byte, 200)},
+			}},
+			{Topic: "orders", Atomic: true, Messages: []*messaging.Message{
+				{Id: "a", Key: "customer-1"}, {Id: "b", Key: "customer-2"}, {Id: "c", Key: "customer-3"},
+				{Id: "d", Key: "customer-4"}, {Id: "e", Key: "customer-5"},
+			}},
+		}
+		for _, req := range rejected {
+			resp := publishBatch(t, b, req)
+			if resp.GetSuccess() {
+				t.Fatalf("atomic batch %v published", ids(req.GetMessages()))
+			}
+			for _, result := range resp.GetResults() {
+				if result.GetSuccess() {
+					t.Fatalf("results = %v, want every message rejected", resp.GetResults())
+				}
+			}
+		}
+		if got := consume(t, b, consumeReq); len(got) != 0 {
+			t.Fatalf("rejected atomic batches left %v", ids(got))
+		}
+
+		pinned := int32(2)
+		resp := publishBatch(t, b, &messaging.PublishBatchRequest{Topic: "orders", Atomic: true, Partition: &pinned, Messages: []*messaging.Message{
+			{Id: "a", Key: "customer-1"}, {Id: "b", Key: "customer-2"},
+		}})
+		if !resp.GetSuccess() {
+			t.Fatalf("pinned atomic batch: %s", resp.GetError())
+		}
+		for i, result := range resp.GetResults() {
+			if result.GetPartition() != pinned || result.GetOffset() != int64(i) {
+				t.Fatalf("results = %v, want offsets 0 and 1 of partition %d", resp.GetResults(), pinned)
+			}
+		}
+		if got := ids(consume(t, b, consumeReq)); fmt.Sprint(got) != "[a b]" {
+			t.Fatalf("got %v, want [a b]", got)
+		}
+	})
+}
+
+// publishStream feeds requests to PublishStream as a client would, then keeps its response; the
+// embedded grpc.ServerStream is nil, as the broker only calls Context, Recv and SendAndClose
+type publishStream struct {
+	grpc.ServerStream
+	requests []*messaging.PublishRequest
+	response *messaging.PublishBatchResponse
+}
+
+func (s *publishStream) Context() context.Context { return context.Background() }
+
+func (s *publishStream) Recv() (*messaging.PublishRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *publishStream) SendAndClose(resp *messaging.PublishBatchResponse) error {
+	s.response = resp
+	return nil
+}
+
+// TestPublishStreamSchedules ensures streamed messages with a delay are scheduled rather than
+// written at once, and those without are written in order around them
+func TestPublishStreamSchedules(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "reminders", nil)
+		register(t, b, "reminders", "mailer")
+
+		stream := &publishStream{requests: []*messaging.PublishRequest{
+			{Topic: "reminders", Message: &messaging.Message{Id: "now-1"}},
+			{Topic: "reminders", Message: &messaging.Message{Id: "later"}, DelayMs: 100},
+			{Topic: "reminders", Message: &messaging.Message{Id: "now-2"}},
+			{Topic: "reminders", Message: &messaging.Message{}, DelayMs: 100},
+		}}
+		if err := b.PublishStream(stream); err != nil {
+			t.Fatal(err)
+		}
+		results := stream.response.GetResults()
+		if len(results) != 4 {
+			t.Fatalf("got %d results for 4 messages", len(results))
+		}
+		if !results[0].GetSuccess() || results[0].GetOffset() != 0 || !results[2].GetSuccess() || results[2].GetOffset() != 1 {
+			t.Fatalf("results = %v, want now-1 and now-2 at offsets 0 and 1", results)
+		}
+		if !results[1].GetSuccess() || !results[1].GetScheduled() || results[1].GetOffset() != -1 {
+			t.Fatalf("result of the delayed message = %v, want it scheduled", results[1])
+		}
+		if results[3].GetSuccess() || results[3].GetError() == "" {
+			t.Fatalf("result of a delayed message without an ID = %v, want an error", results[3])
+		}
+
+		if got := await(t, b, "reminders", "mailer", 0); fmt.Sprint(got) != "[now-1 now-2]" {
+			t.Fatalf("got %v before the delivery time, want [now-1 now-2]", got)
+		}
+		if got := await(t, b, "reminders", "mailer", 2*time.Second); fmt.Sprint(got) != "[later]" {
+			t.Fatalf("got %v, want [later]", got)
+		}
+	})
+}
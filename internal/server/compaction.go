@@ -0,0 +1,145 @@
+package server
+
+import (
+	"expvar"
+	"log"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/mnemosyne"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Compaction metrics, published by expvar under /debug/vars and keyed by topic
+var (
+	compactionBytesReclaimed = expvar.NewMap("compaction_bytes_reclaimed")
+	compactionRecordsRemoved = expvar.NewMap("compaction_records_removed")
+)
+
+// compactionLoop periodically compacts the logs of topics with the compact cleanup policy
+func (s *Server) compactionLoop() {
+	defer s.wg.Done()
+	interval := s.cfg.CompactionInterval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closed:
+			return
+		case now := <-ticker.C:
+			// Holding the read lock keeps DeleteTopic from closing a topic being compacted
+			s.mu.RLock()
+			for _, t := range s.topics {
+				t.compact(now)
+			}
+			s.mu.RUnlock()
+		}
+	}
+}
+
+// compact keeps only the newest message per key in the sealed segments of every partition log,
+// removing keys whose newest message is a tombstone older than the topic's delete retention
+func (t *topic) compact(now time.Time) {
+	config := t.config()
+	if config.cleanupPolicy != cleanupCompact {
+		return
+	}
+	for _, p := range t.partitions {
+		removed, reclaimed, err := p.compact(now.Add(-config.deleteRetention).UnixMilli())
+		if err != nil {
+			log.Printf("Compaction of topic %s partition %d failed: %v", t.name, p.id, err)
+		}
+		if removed > 0 {
+			compactionRecordsRemoved.Add(t.name, removed)
+			compactionBytesReclaimed.Add(t.name, reclaimed)
+			log.Printf("Compacted %d messages (%d bytes) from topic %s partition %d", removed, reclaimed, t.name, p.id)
+		}
+	}
+}
+
+// compact rewrites the partition log without superseded messages and without tombstones
+// published before tombstoneCutoff (milliseconds since the epoch). Aborted transactions go too,
+// marker and all, while messages from the oldest open transaction on are left alone. Partitions
+// kept in memory are left alone: their ring buffer already bounds what they hold.
+func (p *partition) compact(tombstoneCutoff int64) (int64, int64, error) {
+	if p.log == nil {
+		return 0, 0, nil
+	}
+	stable := p.txns.lastStable()
+	aborted, err := p.abortMarkers(p.log.StartOffset(), p.log.NextOffset())
+	if err != nil {
+		return 0, 0, err
+	}
+	latest, err := p.latestOffsets(stable, aborted)
+	if err != nil {
+		return 0, 0, err
+	}
+	var dropped []string // Aborted transactions whose marker was removed
+	removed, reclaimed, err := p.log.Compact(func(offset int64, record []byte) bool {
+		if offset >= stable {
+			return true
+		}
+		msg := &messaging.Message{}
+		if err := proto.Unmarshal(record, msg); err != nil {
+			return true
+		}
+		id := msg.GetTransactionId()
+		if _, ok := aborted[id]; ok {
+			// The marker follows every message of its transaction, so they all go in the same pass
+			if msg.GetTransactionMarker() == markerAbort {
+				dropped = append(dropped, id)
+			}
+			return false
+		}
+		if msg.GetKey() == "" {
+			return true // Only keyed messages can be superseded
+		}
+		if newest, ok := latest[msg.GetKey()]; ok && newest != offset {
+			return false
+		}
+		return !isTombstone(msg) || msg.GetTimestamp() >= tombstoneCutoff
+	})
+	if err == nil {
+		p.txns.dropAborted(dropped)
+	}
+	return removed, reclaimed, err
+}
+
+// latestOffsets maps the keys that occur more than once in the log before end to the offset of
+// their newest message, leaving out aborted transactions. Keys seen once are only remembered by a
+// cuckoo filter, so the map stays small when most keys are unique. A false positive from the
+// filter only adds a key to the map; it never gets a message dropped.
+func (p *partition) latestOffsets(end int64, aborted map[string]int64) (map[string]int64, error) {
+	seen := mnemosyne.NewCuckooFilter()
+	latest := make(map[string]int64)
+	end = min(end, p.log.NextOffset())
+	err := p.log.ReadFrom(p.log.StartOffset(), func(offset int64, record []byte) bool {
+		if offset >= end {
+			return false
+		}
+		msg := &messaging.Message{}
+		if err := proto.Unmarshal(record, msg); err != nil || msg.GetKey() == "" {
+			return true
+		}
+		if _, ok := aborted[msg.GetTransactionId()]; ok {
+			return true
+		}
+		key := msg.GetKey()
+		// Once in the map a key stays there, so the map always holds its newest offset
+		if _, dup := latest[key]; dup || seen.Lookup([]byte(key)) || !seen.Insert([]byte(key)) {
+			latest[key] = offset
+		}
+		return true
+	})
+	return latest, err
+}
+
+// isTombstone reports whether a message marks its key deleted on a compacted topic
+func isTombstone(msg *messaging.Message) bool {
+	return len(msg.GetPayload()) == 0
+}
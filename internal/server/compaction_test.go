@@ -0,0 +1,90 @@
+package server_test
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+)
+
+// recordsRemoved reads the compaction_records_removed counter of a topic
+func recordsRemoved(topic string) int64 {
+	removed, _ := expvar.Get("compaction_records_removed").(*expvar.Map).Get(topic).(*expvar.Int)
+	if removed == nil {
+		return 0
+	}
+	return removed.Value()
+}
+
+// TestCompaction ensures compacting a topic keeps the newest message of each key at its offset,
+// drops keys whose newest message is a tombstone, and consumers and seeks see the same offsets
+func TestCompaction(t *testing.T) {
+	cfg := server.DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	cfg.CompactionInterval = 20 * time.Millisecond
+	cfg.Log.SegmentBytes = 256
+	b := start(t, cfg)
+	createTopic(t, b, "accounts", &messaging.TopicConfig{CleanupPolicy: "compact", DeleteRetentionMs: 1})
+	register(t, b, "accounts", "snapshot")
+
+	removed := recordsRemoved("accounts")
+	for _, msg := range []*messaging.Message{
+		{Id: "alice-1", Key: "alice", Payload: []byte("10")},
+		{Id: "bob-1", Key: "bob", Payload: []byte("20")},
+		{Id: "alice-2", Key: "alice", Payload: []byte("15")},
+		{Id: "carol-1", Key: "carol", Payload: []byte("30")},
+		{Id: "bob-closed", Key: "bob"},
+	} {
+		publish(t, b, &messaging.PublishRequest{Topic: "accounts", Message: msg})
+	}
+	// Fill sealed segments past the messages above, so only the fillers stay in the active one
+	for i := 0; i < 6; i++ {
+		publish(t, b, &messaging.PublishRequest{Topic: "accounts", Message: &messaging.Message{Id: fmt.Sprintf("filler-%d", i), Key: fmt.Sprintf("filler-%d", i), Payload: make([]byte, 100)}})
+	}
+	for deadline := time.Now().Add(2 * time.Second); recordsRemoved("accounts") < removed+3; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("compaction removed fewer than the 3 superseded messages and tombstone")
+		}
+	}
+	// Consumers reread the compacted log after a restart instead of the ring buffer
+	b = b.restart(t, cfg)
+
+	readAll := func() []string {
+		var got []string
+		for {
+			msgs := consume(t, b, &messaging.ConsumeRequest{Topic: "accounts", ConsumerGroup: "snapshot", BatchSize: 100})
+			if len(msgs) == 0 {
+				return got
+			}
+			for _, msg := range msgs {
+				got = append(got, fmt.Sprintf("%s@%d", msg.GetId(), msg.GetOffset()))
+				ack(t, b, "accounts", "snapshot", msg)
+			}
+		}
+	}
+	want := "[alice-2@2 carol-1@3 filler-0@5 filler-1@6 filler-2@7 filler-3@8 filler-4@9 filler-5@10]"
+	if got := fmt.Sprint(readAll()); got != want {
+		t.Fatalf("compacted topic has %v, want %v", got, want)
+	}
+
+	for _, seek := range []struct {
+		offset int64
+		want   string
+	}{
+		{1, "[alice-2@2 carol-1@3"},
+		{3, "[carol-1@3"},
+		{4, "[filler-0@5"},
+	} {
+		if _, err := b.Seek(context.Background(), &messaging.SeekRequest{Topic: "accounts", ConsumerGroup: "snapshot", Position: &messaging.SeekRequest_Offset{Offset: seek.offset}}); err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(readAll()); !strings.HasPrefix(got, seek.want) {
+			t.Fatalf("after seeking to offset %d got %v, want it to start %v", seek.offset, got, seek.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func pause(t *testing.T, b *broker, topic, group string) {
+	t.Helper()
+	resp, err := b.PauseConsumer(context.Background(), &messaging.PauseConsumerRequest{Topic: topic, ConsumerGroup: group})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetSuccess() {
+		t.Fatalf("pausing consumer group %s: %s", group, resp.GetError())
+	}
+}
+
+func resume(t *testing.T, b *broker, topic, group string) {
+	t.Helper()
+	resp, err := b.ResumeConsumer(context.Background(), &messaging.ResumeConsumerRequest{Topic: topic, ConsumerGroup: group})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetSuccess() {
+		t.Fatalf("resuming consumer group %s: %s", group, resp.GetError())
+	}
+}
+
+// TestPausedKeepsOffsets ensures a paused consumer group is sent nothing, stays paused across a
+// restart, and picks up where it left off once resumed
+func TestPausedKeepsOffsets(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", nil)
+		register(t, b, "orders", "billing")
+		req := &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 1}
+		for i := 0; i < 3; i++ {
+			publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: fmt.Sprintf("order-%d", i)}})
+		}
+		for _, msg := range consume(t, b, req) {
+			ack(t, b, "orders", "billing", msg)
+		}
+
+		pause(t, b, "orders", "billing")
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+		if got := consume(t, b, req); len(got) != 0 {
+			t.Fatalf("paused consumer group got %v", ids(got))
+		}
+
+		resume(t, b, "orders", "billing")
+		req.BatchSize = 10
+		if got := ids(consume(t, b, req)); len(got) != 2 || got[0] != "order-1" || got[1] != "order-2" {
+			t.Fatalf("after resuming got %v, want [order-1 order-2]", got)
+		}
+	})
+}
+
+// TestPausedDoesNotBlockProducers ensures producers stop waiting for a paused consumer group once
+// it lags PausedLag messages behind. Persistent topics catch it up from the log once resumed;
+// in memory it loses its oldest messages.
+func TestPausedDoesNotBlockProducers(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		cfg.BufferSize = 8
+		cfg.PausedLag = 2
+		b := start(t, cfg)
+		createTopic(t, b, "orders", &messaging.TopicConfig{OverflowTimeoutMs: 50})
+		register(t, b, "orders", "billing")
+		register(t, b, "orders", "audit")
+		pause(t, b, "orders", "audit")
+
+		billing := &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 10}
+		for i := 0; i < 20; i++ {
+			publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: fmt.Sprintf("order-%d", i)}})
+			for _, msg := range consume(t, b, billing) {
+				ack(t, b, "orders", "billing", msg)
+			}
+		}
+
+		resume(t, b, "orders", "audit")
+		audit := &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "audit", BatchSize: 10}
+		var got []string
+		for {
+			msgs := consume(t, b, audit)
+			if len(msgs) == 0 {
+				break
+			}
+			for _, msg := range msgs {
+				got = append(got, msg.GetId())
+				ack(t, b, "orders", "audit", msg)
+			}
+		}
+		if len(got) == 0 || got[len(got)-1] != "order-19" {
+			t.Fatalf("resumed consumer group got %v, want it to end with order-19", got)
+		}
+		if cfg.DataDir != "" && (len(got) != 20 || got[0] != "order-0") {
+			t.Fatalf("resumed consumer group got %v, want every message from the log", got)
+		}
+	})
+}
+
+// TestUnregisterFreesSlots ensures unregistering a consumer group that stopped reading frees the
+// ring buffer slots producers were waiting on
+func TestUnregisterFreesSlots(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		cfg.BufferSize = 8
+		b := start(t, cfg)
+		createTopic(t, b, "orders", &messaging.TopicConfig{OverflowTimeoutMs: 50})
+		register(t, b, "orders", "idle")
+
+		blocked := -1
+		for i := 0; i < 2*cfg.BufferSize; i++ {
+			_, err := b.Publish(context.Background(), &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: fmt.Sprintf("order-%d", i)}})
+			if err != nil {
+				if status.Code(err) != codes.ResourceExhausted {
+					t.Fatal(err)
+				}
+				blocked = i
+				break
+			}
+		}
+		if blocked < 0 {
+			t.Fatal("a consumer group that never reads did not hold back producers")
+		}
+
+		resp, err := b.UnregisterConsumerGroup(context.Background(), &messaging.UnregisterConsumerRequest{Topic: "orders", ConsumerGroup: "idle"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.GetSuccess() {
+			t.Fatalf("unregistering: %s", resp.GetError())
+		}
+		publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: fmt.Sprintf("order-%d", blocked)}})
+	})
+}
+
+// TestBroadcastMembers ensures every member of a group on a broadcast topic receives every message,
+// and acknowledges it for itself only
+func TestBroadcastMembers(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		resp, err := b.CreateTopic(context.Background(), &messaging.CreateTopicRequest{Topic: "alerts", Strategy: "broadcast", Config: &messaging.TopicConfig{Partitions: 2, VisibilityTimeoutMs: 100}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.GetSuccess() {
+			t.Fatalf("creating topic: %s", resp.GetError())
+		}
+		register(t, b, "alerts", "pagers")
+		for i := 0; i < 4; i++ {
+			publish(t, b, &messaging.PublishRequest{Topic: "alerts", Message: &messaging.Message{Id: fmt.Sprintf("alert-%d", i), Key: fmt.Sprintf("host-%d", i)}})
+		}
+		read := func(member string) []*messaging.Message {
+			return consume(t, b, &messaging.ConsumeRequest{Topic: "alerts", ConsumerGroup: "pagers", ConsumerId: member, BatchSize: 10})
+		}
+
+		phone, laptop := read("phone"), read("laptop")
+		if len(phone) != 4 || len(laptop) != 4 {
+			t.Fatalf("phone got %v and laptop %v, want every message each", ids(phone), ids(laptop))
+		}
+		acks := &messaging.AckRequest{Topic: "alerts", ConsumerGroup: "pagers", ConsumerId: "phone"}
+		for _, msg := range phone {
+			acks.Positions = append(acks.Positions, position(msg))
+		}
+		if resp, err := b.Acknowledge(context.Background(), acks); err != nil || !resp.GetSuccess() {
+			t.Fatalf("acknowledging: %v %s", err, resp.GetError())
+		}
+
+		time.Sleep(150 * time.Millisecond)
+		if got := read("phone"); len(got) != 0 {
+			t.Fatalf("phone got %v again after acknowledging", ids(got))
+		}
+		if got := read("laptop"); len(got) != 4 {
+			t.Fatalf("laptop got %v redelivered, want every message it left unacknowledged", ids(got))
+		}
+	})
+}
@@ -0,0 +1,400 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/urd"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const deadLettersDir = "deadletters"
+
+// deadLetterQueue holds the messages of a topic that could not be delivered or were rejected at
+// publish time, oldest first. With a data directory they are appended to a log of their own, and
+// removing one appends a tombstone: a record without dead-letter info whose offset names the dead
+// letter it removes. Segments left holding nothing live are deleted. Only where the live dead
+// letters are is kept in memory.
+type deadLetterQueue struct {
+	mu      sync.Mutex
+	log     *urd.Log             // Nil keeps dead letters in memory only
+	live    []deadLetterEntry    // Dead letters not removed, by offset
+	next    int64                // Offset of the next dead letter kept in memory
+	pending []*messaging.Message // Queued for the next flush to append
+}
+
+// deadLetterEntry locates a live dead letter
+type deadLetterEntry struct {
+	offset int64
+	id     string
+	group  string
+	msg    *messaging.Message // Held here only without a log
+}
+
+// openDeadLetterQueue opens the dead-letter log in dir, replaying it to find the live dead letters,
+// or keeps dead letters in memory when dir is empty
+func openDeadLetterQueue(dir string, opts urd.Options) (*deadLetterQueue, error) {
+	q := &deadLetterQueue{}
+	if dir == "" {
+		return q, nil
+	}
+	l, err := urd.Open(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var decodeErr error
+	err = l.ReadFrom(l.StartOffset(), func(offset int64, record []byte) bool {
+		msg := &messaging.Message{}
+		if decodeErr = proto.Unmarshal(record, msg); decodeErr != nil {
+			return false
+		}
+		if msg.GetDeadLetter() == nil {
+			q.drop(msg.GetOffset())
+		} else {
+			q.live = append(q.live, deadLetterEntry{offset: offset, id: msg.GetId(), group: msg.GetDeadLetter().GetConsumerGroup()})
+		}
+		return true
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		l.Close()
+		return nil, fmt.Errorf("reading dead letters: %w", err)
+	}
+	q.log = l
+	return q, nil
+}
+
+// add appends dead letters to the queue
+func (q *deadLetterQueue) add(msgs ...*messaging.Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, msgs...)
+	return q.flushLocked()
+}
+
+// queue adds dead letters, leaving them to the next flush to append to the log, for callers that
+// must not wait on the disk
+func (q *deadLetterQueue) queue(msgs ...*messaging.Message) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, msgs...)
+	if q.log == nil {
+		q.flushLocked()
+	}
+}
+
+// flush appends the dead letters queued since the last flush
+func (q *deadLetterQueue) flush() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.flushLocked()
+}
+
+// flushLocked appends the queued dead letters, keeping them queued if that fails; the caller holds
+// q.mu
+func (q *deadLetterQueue) flushLocked() error {
+	if len(q.pending) == 0 {
+		return nil
+	}
+	if q.log == nil {
+		for _, msg := range q.pending {
+			q.live = append(q.live, deadLetterEntry{offset: q.next, id: msg.GetId(), group: msg.GetDeadLetter().GetConsumerGroup(), msg: msg})
+			q.next++
+		}
+		q.pending = nil
+		return nil
+	}
+
+	records := make([][]byte, len(q.pending))
+	for i, msg := range q.pending {
+		record, err := proto.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		records[i] = record
+	}
+	first, err := q.log.Append(records...)
+	if err != nil {
+		return err
+	}
+	for i, msg := range q.pending {
+		q.live = append(q.live, deadLetterEntry{offset: first + int64(i), id: msg.GetId(), group: msg.GetDeadLetter().GetConsumerGroup()})
+	}
+	q.pending = nil
+	return nil
+}
+
+// list returns up to limit dead letters of a consumer group (every group when empty), oldest first.
+// A limit of 0 returns them all.
+func (q *deadLetterQueue) list(group string, limit int) ([]*messaging.Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.flushLocked(); err != nil {
+		return nil, err
+	}
+
+	var entries []deadLetterEntry
+	for _, e := range q.live {
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+		if group == "" || e.group == group {
+			entries = append(entries, e)
+		}
+	}
+	return q.read(entries)
+}
+
+// take removes and returns the dead letters with the given IDs, limited to a consumer group when set
+func (q *deadLetterQueue) take(group string, ids []string) ([]*messaging.Message, error) {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	return q.remove(func(e deadLetterEntry) bool {
+		return wanted[e.id] && (group == "" || e.group == group)
+	})
+}
+
+// purge removes every dead letter of a consumer group (every group when empty)
+func (q *deadLetterQueue) purge(group string) (int, error) {
+	removed, err := q.remove(func(e deadLetterEntry) bool {
+		return group == "" || e.group == group
+	})
+	return len(removed), err
+}
+
+// remove appends tombstones for the dead letters match selects and returns them, then deletes the
+// segments that no longer hold any live dead letter
+func (q *deadLetterQueue) remove(match func(deadLetterEntry) bool) ([]*messaging.Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.flushLocked(); err != nil {
+		return nil, err
+	}
+
+	var matched []deadLetterEntry
+	for _, e := range q.live {
+		if match(e) {
+			matched = append(matched, e)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+	removed, err := q.read(matched)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.log != nil {
+		tombstones := make([][]byte, len(matched))
+		for i, e := range matched {
+			if tombstones[i], err = proto.Marshal(&messaging.Message{Offset: e.offset}); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := q.log.Append(tombstones...); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range matched {
+		q.drop(e.offset)
+	}
+
+	if q.log != nil {
+		oldest := q.log.NextOffset()
+		if len(q.live) > 0 {
+			oldest = q.live[0].offset
+		}
+		if _, err := q.log.DeleteBefore(oldest); err != nil {
+			log.Printf("Failed to delete removed dead letters: %v", err)
+		}
+	}
+	return removed, nil
+}
+
+// drop forgets the live dead letter at offset, if there is one; the caller holds q.mu
+func (q *deadLetterQueue) drop(offset int64) {
+	i := sort.Search(len(q.live), func(i int) bool { return q.live[i].offset >= offset })
+	if i < len(q.live) && q.live[i].offset == offset {
+		q.live = append(q.live[:i], q.live[i+1:]...)
+	}
+}
+
+// read returns the dead letters of entries, which are in offset order; the caller holds q.mu
+func (q *deadLetterQueue) read(entries []deadLetterEntry) ([]*messaging.Message, error) {
+	msgs := make([]*messaging.Message, 0, len(entries))
+	if q.log == nil {
+		for _, e := range entries {
+			msgs = append(msgs, e.msg)
+		}
+		return msgs, nil
+	}
+	if len(entries) == 0 {
+		return msgs, nil
+	}
+
+	var decodeErr error
+	err := q.log.ReadFrom(entries[0].offset, func(offset int64, record []byte) bool {
+		if offset != entries[len(msgs)].offset {
+			return true
+		}
+		msg := &messaging.Message{}
+		if decodeErr = proto.Unmarshal(record, msg); decodeErr != nil {
+			return false
+		}
+		msgs = append(msgs, msg)
+		return len(msgs) < len(entries)
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	if err == nil && len(msgs) < len(entries) {
+		err = fmt.Errorf("dead letter at offset %d is missing from the log", entries[len(msgs)].offset)
+	}
+	return msgs, err
+}
+
+// close appends the queued dead letters and closes the log
+func (q *deadLetterQueue) close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	err := q.flushLocked()
+	if q.log != nil {
+		if cerr := q.log.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// deadLetter returns a copy of msg carrying the reason and origin of its failure
+func deadLetter(msg *messaging.Message, info *messaging.DeadLetterInfo) *messaging.Message {
+	dead := proto.Clone(msg).(*messaging.Message)
+	dead.DeliveryCount = 0
+	info.DeadLetteredAt = timestamppb.Now()
+	dead.DeadLetter = info
+	return dead
+}
+
+// original strips the broker-set fields from a dead letter so it can be delivered again
+func original(dead *messaging.Message) *messaging.Message {
+	msg := proto.Clone(dead).(*messaging.Message)
+	msg.DeadLetter = nil
+	msg.DeliveryCount = 0
+	return msg
+}
+
+// deadLetterExhausted moves leases that ran out of delivery attempts to the dead-letter queue. It
+// runs while fetching, so the queue is persisted later.
+func (t *topic) deadLetterExhausted(cursor string, exhausted []*lease) {
+	if len(exhausted) == 0 {
+		return
+	}
+	group, member, _ := strings.Cut(cursor, "/")
+	dead := make([]*messaging.Message, 0, len(exhausted))
+	for _, l := range exhausted {
+		dead = append(dead, deadLetter(l.msg, &messaging.DeadLetterInfo{
+			Reason:        fmt.Sprintf("not acknowledged after %d deliveries", l.deliveries),
+			Topic:         t.name,
+			Partition:     int32(l.partition.index),
+			Priority:      int32(l.partition.lane),
+			Offset:        l.offset,
+			DeliveryCount: l.deliveries,
+			ConsumerGroup: group,
+			ConsumerId:    member,
+		}))
+		log.Printf("Dead-lettered message with ID: %s on topic: %s after %d deliveries to consumer group %s", l.msg.GetId(), t.name, l.deliveries, group)
+	}
+	t.dlq.queue(dead...)
+}
+
+// GetDeadLetters returns a topic's dead letters, optionally only those of one consumer group
+func (s *Server) GetDeadLetters(ctx context.Context, req *messaging.DeadLetterRequest) (*messaging.DeadLetterResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	dead, err := t.dlq.list(req.GetConsumerGroup(), int(req.GetBatchSize()))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "reading dead letters: %v", err)
+	}
+	return &messaging.DeadLetterResponse{Messages: dead, Success: true}, nil
+}
+
+// ReprocessDeadLetter takes the given dead letters out of the queue and delivers them again: messages
+// that exhausted their deliveries go back to the consumer group that failed them, messages rejected
+// at publish time are published again.
+func (s *Server) ReprocessDeadLetter(ctx context.Context, req *messaging.ReprocessDeadLetterRequest) (*messaging.ReprocessDeadLetterResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+
+	taken, err := t.dlq.take(req.GetConsumerGroup(), req.GetMessageIds())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "removing dead letters: %v", err)
+	}
+
+	var failed []string
+	for _, dead := range taken {
+		info := dead.GetDeadLetter()
+		msg := original(dead)
+
+		if info.GetConsumerGroup() == "" {
+			resp, err := s.Publish(ctx, &messaging.PublishRequest{Topic: t.name, Message: msg})
+			if err != nil || !resp.GetSuccess() {
+				failed = append(failed, msg.GetId())
+			}
+			continue
+		}
+
+		cursor, err := t.cursorFor(info.GetConsumerGroup(), info.GetConsumerId())
+		var p *partition
+		if err == nil {
+			p, err = t.lane(int(info.GetPartition()), int(info.GetPriority()))
+		}
+		if err != nil {
+			// The group or its lane is gone; keep the dead letter rather than lose it
+			if err := t.dlq.add(dead); err != nil {
+				log.Printf("Failed to persist dead letters for topic %s: %v", t.name, err)
+			}
+			failed = append(failed, msg.GetId())
+			continue
+		}
+		t.leaseTable(cursor).release(msg, p, info.GetOffset())
+	}
+
+	log.Printf("Reprocessed %d dead letters on topic %s", len(taken)-len(failed), t.name)
+	if len(failed) > 0 {
+		return &messaging.ReprocessDeadLetterResponse{Success: false, Error: fmt.Sprintf("could not reprocess %v", failed)}, nil
+	}
+	return &messaging.ReprocessDeadLetterResponse{Success: true}, nil
+}
+
+// PurgeDeadLetter discards a topic's dead letters, optionally only those of one consumer group
+func (s *Server) PurgeDeadLetter(ctx context.Context, req *messaging.PurgeDeadLetterRequest) (*messaging.PurgeDeadLetterResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	purged, err := t.dlq.purge(req.GetConsumerGroup())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "persisting dead letters: %v", err)
+	}
+	log.Printf("Purged %d dead letters on topic %s", purged, t.name)
+	return &messaging.PurgeDeadLetterResponse{Success: true}, nil
+}
@@ -0,0 +1,158 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+)
+
+// TestExhaustedDeadLettered ensures a message delivered max_deliveries times without being
+// acknowledged is dead-lettered with where it was stored, kept across a restart, and delivered to
+// its consumer group again once reprocessed
+func TestExhaustedDeadLettered(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "jobs", &messaging.TopicConfig{MaxDeliveries: 2})
+		register(t, b, "jobs", "workers")
+		published := publish(t, b, &messaging.PublishRequest{Topic: "jobs", Message: &messaging.Message{Id: "job-1"}})
+		req := &messaging.ConsumeRequest{Topic: "jobs", ConsumerGroup: "workers", BatchSize: 10}
+
+		for i := 0; i < 2; i++ {
+			got := consume(t, b, req)
+			if len(got) != 1 {
+				t.Fatalf("delivery %d got %v, want job-1", i+1, ids(got))
+			}
+			nack(t, b, "jobs", "workers", 0, got...)
+		}
+		if got := consume(t, b, req); len(got) != 0 {
+			t.Fatalf("delivered %v past max_deliveries", ids(got))
+		}
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+
+		dead := deadLetters(t, b, "jobs")
+		if len(dead) != 1 || dead[0].GetId() != "job-1" {
+			t.Fatalf("dead letters = %v, want job-1", ids(dead))
+		}
+		info := dead[0].GetDeadLetter()
+		if info.GetReason() == "" || info.GetConsumerGroup() != "workers" || info.GetDeliveryCount() != 2 ||
+			info.GetPartition() != published.GetPartition() || info.GetOffset() != published.GetOffset() {
+			t.Fatalf("dead letter info = %v", info)
+		}
+
+		resp, err := b.ReprocessDeadLetter(context.Background(), &messaging.ReprocessDeadLetterRequest{Topic: "jobs", ConsumerGroup: "workers", MessageIds: []string{"job-1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.GetSuccess() {
+			t.Fatalf("reprocessing: %s", resp.GetError())
+		}
+		if dead := deadLetters(t, b, "jobs"); len(dead) != 0 {
+			t.Fatalf("dead letters after reprocessing = %v", ids(dead))
+		}
+		if got := consume(t, b, req); len(got) != 1 || got[0].GetId() != "job-1" {
+			t.Fatalf("after reprocessing got %v, want job-1", ids(got))
+		}
+	})
+}
+
+// TestInvalidDeadLettered ensures a payload the topic's schema rejects is dead-lettered instead of
+// written, and purging discards it
+func TestInvalidDeadLettered(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		schema := `{"type": "object", "properties": {"amount": {"type": "integer"}}, "required": ["amount"]}`
+		if _, err := b.Register(context.Background(), &messaging.RegisterSchemaRequest{Name: "Payment", Type: "json", Schema: schema}); err != nil {
+			t.Fatal(err)
+		}
+		createTopic(t, b, "payments", &messaging.TopicConfig{SchemaName: "Payment"})
+		register(t, b, "payments", "ledger")
+
+		publish(t, b, &messaging.PublishRequest{Topic: "payments", Message: &messaging.Message{Id: "valid", Payload: []byte(`{"amount": 5}`)}})
+		resp, err := b.Publish(context.Background(), &messaging.PublishRequest{Topic: "payments", Message: &messaging.Message{Id: "invalid", Payload: []byte(`{"note": "five"}`)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetSuccess() {
+			t.Fatal("publishing a payload the schema rejects succeeded")
+		}
+
+		if got := ids(consume(t, b, &messaging.ConsumeRequest{Topic: "payments", ConsumerGroup: "ledger", BatchSize: 10})); len(got) != 1 || got[0] != "valid" {
+			t.Fatalf("got %v, want [valid]", got)
+		}
+		dead := deadLetters(t, b, "payments")
+		if len(dead) != 1 || dead[0].GetId() != "invalid" {
+			t.Fatalf("dead letters = %v, want invalid", ids(dead))
+		}
+		if info := dead[0].GetDeadLetter(); info.GetReason() == "" || info.GetPartition() != -1 || info.GetOffset() != -1 {
+			t.Fatalf("dead letter info = %v", info)
+		}
+
+		if _, err := b.PurgeDeadLetter(context.Background(), &messaging.PurgeDeadLetterRequest{Topic: "payments"}); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+		if dead := deadLetters(t, b, "payments"); len(dead) != 0 {
+			t.Fatalf("dead letters after purging = %v", ids(dead))
+		}
+	})
+}
+
+// TestDeadLettersRemovedByGroup ensures reprocessing and purging remove only the dead letters they
+// name, the rest staying in order across a restart
+func TestDeadLettersRemovedByGroup(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		cfg.Log.SegmentBytes = 256
+		b := start(t, cfg)
+		createTopic(t, b, "jobs", &messaging.TopicConfig{MaxDeliveries: 1})
+		for _, group := range []string{"workers", "audit"} {
+			register(t, b, "jobs", group)
+		}
+		for i := 0; i < 3; i++ {
+			publish(t, b, &messaging.PublishRequest{Topic: "jobs", Message: &messaging.Message{Id: fmt.Sprintf("job-%d", i)}})
+		}
+		for _, group := range []string{"workers", "audit"} {
+			req := &messaging.ConsumeRequest{Topic: "jobs", ConsumerGroup: group, BatchSize: 10}
+			nack(t, b, "jobs", group, 0, consume(t, b, req)...)
+			if got := consume(t, b, req); len(got) != 0 {
+				t.Fatalf("%s got %v past max_deliveries", group, ids(got))
+			}
+		}
+		if dead := deadLetters(t, b, "jobs"); len(dead) != 6 {
+			t.Fatalf("dead letters = %v, want every message for both groups", ids(dead))
+		}
+
+		if _, err := b.PurgeDeadLetter(context.Background(), &messaging.PurgeDeadLetterRequest{Topic: "jobs", ConsumerGroup: "audit"}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := b.ReprocessDeadLetter(context.Background(), &messaging.ReprocessDeadLetterRequest{Topic: "jobs", ConsumerGroup: "workers", MessageIds: []string{"job-1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.GetSuccess() {
+			t.Fatalf("reprocessing: %s", resp.GetError())
+		}
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+		if got := ids(deadLetters(t, b, "jobs")); fmt.Sprint(got) != "[job-0 job-2]" {
+			t.Fatalf("dead letters = %v, want [job-0 job-2]", got)
+		}
+
+		if _, err := b.PurgeDeadLetter(context.Background(), &messaging.PurgeDeadLetterRequest{Topic: "jobs"}); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+		if dead := deadLetters(t, b, "jobs"); len(dead) != 0 {
+			t.Fatalf("dead letters after purging = %v", ids(dead))
+		}
+	})
+}
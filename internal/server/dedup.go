@@ -0,0 +1,148 @@
+package server
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// dedupWindow recognises messages published again, typically by a producer retrying after a lost
+// response. It remembers the newest messages of a partition by producer ID and sequence number,
+// and on topics that deduplicate IDs, by message ID when there is no sequence number. Retries
+// older than the window go through as new messages, except that while a producer has messages in
+// the window, its sequence numbers must follow on from the newest one: a sequence number no newer
+// than one that already left the window, or one that skips or goes back, is rejected. The
+// partition's mu serializes access.
+type dedupWindow struct {
+	byID      atomic.Bool  // Whether messages without a sequence number are deduplicated by ID
+	ring      []dedupEntry // The newest messages; empty when deduplication is off
+	next      int          // Ring slot the next message goes in
+	offsets   map[string]int64
+	producers map[string]*producerWindow
+}
+
+// dedupEntry identifies a message in the window
+type dedupEntry struct {
+	key         string
+	producer    string // Empty for messages identified by ID
+	sequence    int64
+	transaction string // Transaction the message was published in, if any
+}
+
+// producerWindow tracks how much of a producer the window still remembers
+type producerWindow struct {
+	remembered int   // Messages of the producer in the window
+	forgotten  int64 // Highest sequence number that left the window
+	last       int64 // Highest sequence number written
+}
+
+func newDedupWindow(size int) *dedupWindow {
+	return &dedupWindow{
+		ring:      make([]dedupEntry, max(size, 0)),
+		offsets:   make(map[string]int64),
+		producers: make(map[string]*producerWindow),
+	}
+}
+
+// entry returns what identifies a message in the window, or false if it is never deduplicated
+func (w *dedupWindow) entry(msg *messaging.Message) (dedupEntry, bool) {
+	if producer, seq := msg.GetProducerId(), msg.GetSequence(); producer != "" && seq > 0 {
+		return dedupEntry{key: "seq\x00" + producer + "\x00" + strconv.FormatInt(seq, 10), producer: producer, sequence: seq, transaction: msg.GetTransactionId()}, true
+	}
+	if id := msg.GetId(); id != "" && w.byID.Load() {
+		return dedupEntry{key: "id\x00" + id, transaction: msg.GetTransactionId()}, true
+	}
+	return dedupEntry{}, false
+}
+
+// lookup returns the offset a message was written at before, if the window remembers it. A new
+// sequence number must follow the producer's newest one, taken from batched when an earlier message
+// of the same run set it there.
+func (w *dedupWindow) lookup(e dedupEntry, batched map[string]int64) (int64, bool, error) {
+	if offset, ok := w.offsets[e.key]; ok {
+		return offset, true, nil
+	}
+	if e.producer == "" || len(w.ring) == 0 {
+		return 0, false, nil
+	}
+	pw := w.producers[e.producer]
+	if pw != nil && e.sequence <= pw.forgotten {
+		return 0, false, status.Errorf(codes.FailedPrecondition, "sequence number %d of producer %s is older than the deduplication window", e.sequence, e.producer)
+	}
+	last, known := batched[e.producer]
+	if !known && pw != nil {
+		last, known = pw.last, true
+	}
+	if known && e.sequence != last+1 {
+		return 0, false, status.Errorf(codes.FailedPrecondition, "out of order sequence number %d of producer %s, expected %d", e.sequence, e.producer, last+1)
+	}
+	return 0, false, nil
+}
+
+// add remembers a message written at offset, forgetting the oldest one once the window is full
+func (w *dedupWindow) add(e dedupEntry, offset int64) {
+	if len(w.ring) == 0 {
+		return
+	}
+	if old := w.ring[w.next]; old.key != "" {
+		delete(w.offsets, old.key)
+		if pw := w.producers[old.producer]; pw != nil {
+			pw.remembered--
+			pw.forgotten = max(pw.forgotten, old.sequence)
+			if pw.remembered == 0 {
+				delete(w.producers, old.producer)
+			}
+		}
+	}
+	w.ring[w.next] = e
+	w.next = (w.next + 1) % len(w.ring)
+	w.offsets[e.key] = offset
+	if e.producer != "" {
+		pw := w.producers[e.producer]
+		if pw == nil {
+			pw = &producerWindow{}
+			w.producers[e.producer] = pw
+		}
+		pw.remembered++
+		pw.last = max(pw.last, e.sequence)
+	}
+}
+
+// evict forgets the messages of an aborted transaction, so a producer may publish them again
+func (w *dedupWindow) evict(transaction string) {
+	evicted := make(map[string]bool)
+	for i, e := range w.ring {
+		if e.key == "" || e.transaction != transaction {
+			continue
+		}
+		delete(w.offsets, e.key)
+		if pw := w.producers[e.producer]; pw != nil {
+			if pw.remembered--; pw.remembered == 0 {
+				delete(w.producers, e.producer)
+			} else {
+				evicted[e.producer] = true
+			}
+		}
+		w.ring[i] = dedupEntry{}
+	}
+
+	// The producer carries on from its newest message left
+	for producer := range evicted {
+		pw := w.producers[producer]
+		pw.last = pw.forgotten
+		for _, e := range w.ring {
+			if e.producer == producer {
+				pw.last = max(pw.last, e.sequence)
+			}
+		}
+	}
+}
+
+// size returns how many of the newest messages the window remembers
+func (w *dedupWindow) size() int {
+	return len(w.ring)
+}
@@ -0,0 +1,121 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func sequenced(seq int64) *messaging.PublishRequest {
+	return &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: fmt.Sprintf("order-%d", seq), ProducerId: "producer", Sequence: seq}}
+}
+
+// TestRetriedPublishDuplicate ensures a message published again under the same producer ID and
+// sequence number is reported as a duplicate of the first, before and after a restart, and not
+// written twice
+func TestRetriedPublishDuplicate(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", nil)
+		register(t, b, "orders", "billing")
+		first := publish(t, b, sequenced(1))
+		second := publish(t, b, sequenced(2))
+
+		if retried := publish(t, b, sequenced(1)); !retried.GetDuplicate() || retried.GetOffset() != first.GetOffset() {
+			t.Fatalf("retry: duplicate %v at offset %d, want a duplicate of offset %d", retried.GetDuplicate(), retried.GetOffset(), first.GetOffset())
+		}
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+		if retried := publish(t, b, sequenced(2)); !retried.GetDuplicate() || retried.GetOffset() != second.GetOffset() {
+			t.Fatalf("retry: duplicate %v at offset %d, want a duplicate of offset %d", retried.GetDuplicate(), retried.GetOffset(), second.GetOffset())
+		}
+		if next := publish(t, b, sequenced(3)); next.GetDuplicate() {
+			t.Fatal("new sequence number reported as a duplicate")
+		}
+
+		got := ids(consume(t, b, &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 10}))
+		if fmt.Sprint(got) != "[order-1 order-2 order-3]" {
+			t.Fatalf("got %v, want each message once", got)
+		}
+	})
+}
+
+// TestSequenceOlderThanWindow ensures a retry whose sequence number already left the
+// deduplication window is rejected rather than written again
+func TestSequenceOlderThanWindow(t *testing.T) {
+	cfg := server.DefaultConfig()
+	cfg.DedupWindow = 2
+	b := start(t, cfg)
+	createTopic(t, b, "orders", nil)
+	for seq := int64(1); seq <= 3; seq++ {
+		publish(t, b, sequenced(seq))
+	}
+	if _, err := b.Publish(context.Background(), sequenced(1)); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("retry older than the window returned %v, want FailedPrecondition", err)
+	}
+}
+
+// TestDedupIDs ensures topics with dedup_ids drop a message whose ID was published before, and
+// other topics do not
+func TestDedupIDs(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		for _, dedup := range []bool{true, false} {
+			topic := fmt.Sprintf("orders-%v", dedup)
+			createTopic(t, b, topic, &messaging.TopicConfig{DedupIds: dedup})
+			first := publish(t, b, &messaging.PublishRequest{Topic: topic, Message: &messaging.Message{Id: "order-1"}})
+			again := publish(t, b, &messaging.PublishRequest{Topic: topic, Message: &messaging.Message{Id: "order-1"}})
+			if again.GetDuplicate() != dedup {
+				t.Errorf("dedup_ids %v: publishing an ID again reported duplicate %v", dedup, again.GetDuplicate())
+			}
+			if dedup && again.GetOffset() != first.GetOffset() {
+				t.Errorf("duplicate reported at offset %d, want %d", again.GetOffset(), first.GetOffset())
+			}
+		}
+	})
+}
+
+// TestSequenceOutOfOrder ensures a sequence number that skips ahead is rejected, before and after
+// a restart, as is one sent ahead of an earlier one in a batch, and the producer carries on from
+// its newest message
+func TestSequenceOutOfOrder(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", nil)
+		register(t, b, "orders", "billing")
+		publish(t, b, sequenced(1))
+		publish(t, b, sequenced(2))
+
+		rejected := func(what string, seq int64) {
+			t.Helper()
+			if _, err := b.Publish(context.Background(), sequenced(seq)); status.Code(err) != codes.FailedPrecondition {
+				t.Fatalf("%s (sequence %d) returned %v, want FailedPrecondition", what, seq, err)
+			}
+		}
+		rejected("gap", 4)
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+		rejected("gap after a restart", 5)
+
+		resp := publishBatch(t, b, &messaging.PublishBatchRequest{Topic: "orders", Messages: []*messaging.Message{
+			sequenced(3).GetMessage(), sequenced(5).GetMessage(), sequenced(4).GetMessage(),
+		}})
+		if results := resp.GetResults(); !results[0].GetSuccess() || results[1].GetSuccess() || !results[2].GetSuccess() {
+			t.Fatalf("batch of sequences 3, 5 and 4: results = %v, want 5 rejected", results)
+		}
+		publish(t, b, sequenced(5))
+
+		got := ids(consume(t, b, &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 10}))
+		if fmt.Sprint(got) != "[order-1 order-2 order-3 order-4 order-5]" {
+			t.Fatalf("got %v, want every sequence once and in order", got)
+		}
+	})
+}
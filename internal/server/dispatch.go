@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"expvar"
+	"log"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/maat"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// dispatchQuantum is the bytes a consumer group of weight 1 may read per turn while the dispatch
+// rate is contended
+const dispatchQuantum = 64 << 10
+
+// dispatchBytesServed counts the bytes delivered to consumers, keyed by topic and consumer group as
+// "topic/group" and published by expvar under /debug/vars
+var dispatchBytesServed = expvar.NewMap("dispatch_bytes_served")
+
+// dispatchFlow names the share of the dispatch rate a consumer group has on a topic
+func dispatchFlow(topic, group string) string {
+	return topic + "/" + group
+}
+
+// fetch reads from a topic once the dispatcher grants the consumer group a turn, charging the group
+// for the bytes it was sent. Cursor is the group's own or, on broadcast topics, its member's.
+func (s *Server) fetch(ctx context.Context, t *topic, group string, partitions []*partition, cursor string, batchSize int, filter *maat.Filter, committed bool) ([]*messaging.Message, error) {
+	flow := dispatchFlow(t.name, group)
+	release, err := s.dispatch.Acquire(ctx, flow)
+	if err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	messages, err := t.fetch(partitions, cursor, batchSize, filter, committed)
+	var served int64
+	for _, msg := range messages {
+		served += int64(proto.Size(msg))
+	}
+	release(served)
+	if served > 0 {
+		dispatchBytesServed.Add(flow, served)
+	}
+	return messages, err
+}
+
+// Admin Service: Set a consumer group's share of the dispatch rate on a topic
+func (s *Server) SetConsumerWeight(ctx context.Context, req *messaging.SetConsumerWeightRequest) (*messaging.SetConsumerWeightResponse, error) {
+	if req.GetWeight() < 0 {
+		return &messaging.SetConsumerWeightResponse{Success: false, Error: "weight must not be negative"}, nil
+	}
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	group, weight := req.GetConsumerGroup(), int(req.GetWeight())
+	changed, err := t.setWeight(group, weight)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return &messaging.SetConsumerWeightResponse{Success: true}, nil
+	}
+	s.dispatch.SetWeight(dispatchFlow(t.name, group), weight)
+	if err := s.saveTopicMeta(t); err != nil {
+		return nil, status.Errorf(codes.Internal, "persisting weight of consumer group %q: %v", group, err)
+	}
+	log.Printf("Set dispatch weight of consumer group: %s on topic: %s to %d", group, t.name, max(weight, 1))
+	return &messaging.SetConsumerWeightResponse{Success: true}, nil
+}
+
+// consumerWeights converts group weights for DescribeTopic
+func consumerWeights(weights map[string]int) map[string]int32 {
+	if len(weights) == 0 {
+		return nil
+	}
+	out := make(map[string]int32, len(weights))
+	for group, weight := range weights {
+		out[group] = int32(weight)
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package server_test
+
+import (
+	"context"
+	"expvar"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+)
+
+func setWeight(t *testing.T, b *broker, topic, group string, weight int32) {
+	t.Helper()
+	resp, err := b.SetConsumerWeight(context.Background(), &messaging.SetConsumerWeightRequest{Topic: topic, ConsumerGroup: group, Weight: weight})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetSuccess() {
+		t.Fatalf("setting weight of %s: %s", group, resp.GetError())
+	}
+}
+
+func consumerWeights(t *testing.T, b *broker, topic string) map[string]int32 {
+	t.Helper()
+	resp, err := b.DescribeTopic(context.Background(), &messaging.DescribeTopicRequest{Topic: topic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.GetConsumerWeights()
+}
+
+// bytesServed reads the dispatch_bytes_served counter of a consumer group
+func bytesServed(topic, group string) int64 {
+	served, _ := expvar.Get("dispatch_bytes_served").(*expvar.Map).Get(topic + "/" + group).(*expvar.Int)
+	if served == nil {
+		return 0
+	}
+	return served.Value()
+}
+
+// TestConsumerWeights ensures consumer group weights are reported by DescribeTopic, survive a
+// restart, and are reset by a weight of 0
+func TestConsumerWeights(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "events", nil)
+		register(t, b, "events", "analytics")
+		register(t, b, "events", "audit")
+
+		resp, err := b.SetConsumerWeight(context.Background(), &messaging.SetConsumerWeightRequest{Topic: "events", ConsumerGroup: "audit", Weight: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetSuccess() {
+			t.Fatal("set a negative weight")
+		}
+		setWeight(t, b, "events", "analytics", 3)
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+		if weights := consumerWeights(t, b, "events"); len(weights) != 1 || weights["analytics"] != 3 {
+			t.Fatalf("consumer weights = %v, want analytics at 3", weights)
+		}
+
+		setWeight(t, b, "events", "analytics", 0)
+		if weights := consumerWeights(t, b, "events"); len(weights) != 0 {
+			t.Fatalf("consumer weights = %v after resetting, want none", weights)
+		}
+	})
+}
+
+// TestDispatchShare ensures consumer groups reading at once past the dispatch rate are served bytes
+// in proportion to their weights
+func TestDispatchShare(t *testing.T) {
+	cfg := server.DefaultConfig()
+	cfg.DispatchRate = 1 << 20
+	b := start(t, cfg)
+	createTopic(t, b, "share", nil)
+	register(t, b, "share", "heavy")
+	register(t, b, "share", "light")
+	setWeight(t, b, "share", "heavy", 3)
+	for i := 0; i < 256; i++ {
+		publish(t, b, &messaging.PublishRequest{Topic: "share", Message: &messaging.Message{Id: strconv.Itoa(i), Payload: make([]byte, 16<<10)}})
+	}
+
+	// Several consumers per group keep turns queued for each, as a busy group would
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, group := range []string{"heavy", "light"} {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ctx.Err() == nil {
+					b.Consume(ctx, &messaging.ConsumeRequest{Topic: "share", ConsumerGroup: group, BatchSize: 4})
+				}
+			}()
+		}
+	}
+	// Whichever consumer starts first takes the initial burst; measure once it is spent
+	time.Sleep(200 * time.Millisecond)
+	heavy, light := bytesServed("share", "heavy"), bytesServed("share", "light")
+	time.Sleep(time.Second)
+	heavy, light = bytesServed("share", "heavy")-heavy, bytesServed("share", "light")-light
+	cancel()
+	wg.Wait()
+
+	if light == 0 || heavy < 2*light || heavy > 4*light {
+		t.Fatalf("served %d bytes to the group of weight 3 and %d to the group of weight 1, want about 3 to 1", heavy, light)
+	}
+}
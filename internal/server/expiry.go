@@ -0,0 +1,89 @@
+package server
+
+import (
+	"expvar"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+)
+
+const expiredReason = "expired" // Dead-letter reason of messages that outlived their time to live
+
+// messagesExpired counts the messages consumers skipped for outliving their time to live, each
+// once however many consumers skipped it, keyed by topic and published by expvar under /debug/vars
+var messagesExpired = expvar.NewMap("messages_expired")
+
+// expiredOffsets remembers which offsets of a partition consumers found expired, until every
+// consumer has moved past them, so each is counted and dead-lettered once
+type expiredOffsets struct {
+	mu      sync.Mutex
+	offsets map[int64]bool
+}
+
+// claim records an expired offset, returning false if a consumer already found it expired
+func (e *expiredOffsets) claim(offset int64) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.offsets[offset] {
+		return false
+	}
+	e.offsets[offset] = true
+	return true
+}
+
+// prune forgets the offsets before offset
+func (e *expiredOffsets) prune(offset int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for expired := range e.offsets {
+		if expired < offset {
+			delete(e.offsets, expired)
+		}
+	}
+}
+
+// expired reports whether a message outlived its time to live, or the topic's ttl when it sets none
+func expired(msg *messaging.Message, now time.Time, ttl time.Duration) bool {
+	if ms := msg.GetTtlMs(); ms > 0 {
+		ttl = time.Duration(ms) * time.Millisecond
+	}
+	return ttl > 0 && now.UnixMilli()-msg.GetTimestamp() >= ttl.Milliseconds()
+}
+
+// discardExpired drops messages a cursor skipped for outliving their time to live. If the topic
+// asks for it the first cursor to skip each moves it to the dead-letter queue, to be persisted
+// later as this runs while fetching.
+func (t *topic) discardExpired(cursor string, lapsed []*lease, config *topicConfig) {
+	var first []*lease
+	for _, l := range lapsed {
+		if l.partition.expired.claim(l.offset) {
+			first = append(first, l)
+		}
+	}
+	if len(first) == 0 {
+		return
+	}
+	messagesExpired.Add(t.name, int64(len(first)))
+	if !config.deadLetterExpired {
+		return
+	}
+	group, member, _ := strings.Cut(cursor, "/")
+	dead := make([]*messaging.Message, 0, len(first))
+	for _, l := range first {
+		dead = append(dead, deadLetter(l.msg, &messaging.DeadLetterInfo{
+			Reason:        expiredReason,
+			Topic:         t.name,
+			Partition:     int32(l.partition.index),
+			Priority:      int32(l.partition.lane),
+			Offset:        l.offset,
+			DeliveryCount: l.deliveries,
+			ConsumerGroup: group,
+			ConsumerId:    member,
+		}))
+	}
+	t.dlq.queue(dead...)
+	log.Printf("Dead-lettered %d expired messages on topic: %s for consumer group %s", len(dead), t.name, group)
+}
@@ -0,0 +1,67 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+)
+
+func deadLetters(t *testing.T, b *broker, topic string) []*messaging.Message {
+	t.Helper()
+	resp, err := b.GetDeadLetters(context.Background(), &messaging.DeadLetterRequest{Topic: topic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.GetMessages()
+}
+
+// TestExpiredSkipped ensures consumers skip messages past their time to live, whether set by the
+// topic or the message
+func TestExpiredSkipped(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "prices", &messaging.TopicConfig{MessageTtlMs: 50})
+		register(t, b, "prices", "quotes")
+		publish(t, b, &messaging.PublishRequest{Topic: "prices", Message: &messaging.Message{Id: "stale"}})
+		publish(t, b, &messaging.PublishRequest{Topic: "prices", Message: &messaging.Message{Id: "long-lived", TtlMs: 60000}})
+		time.Sleep(100 * time.Millisecond)
+		publish(t, b, &messaging.PublishRequest{Topic: "prices", Message: &messaging.Message{Id: "fresh"}})
+
+		got := ids(consume(t, b, &messaging.ConsumeRequest{Topic: "prices", ConsumerGroup: "quotes", BatchSize: 10}))
+		if len(got) != 2 || got[0] != "long-lived" || got[1] != "fresh" {
+			t.Fatalf("got %v, want [long-lived fresh]", got)
+		}
+		if dead := deadLetters(t, b, "prices"); len(dead) != 0 {
+			t.Fatalf("got %d dead letters from a topic that discards expired messages", len(dead))
+		}
+	})
+}
+
+// TestExpiredDeadLetteredOnce ensures an expired message skipped by several consumer groups goes
+// to the dead-letter queue once, and stays there across a restart
+func TestExpiredDeadLetteredOnce(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "prices", &messaging.TopicConfig{MessageTtlMs: 50, DeadLetterExpired: true})
+		register(t, b, "prices", "quotes")
+		register(t, b, "prices", "audit")
+		publish(t, b, &messaging.PublishRequest{Topic: "prices", Message: &messaging.Message{Id: "stale"}})
+		time.Sleep(100 * time.Millisecond)
+
+		for _, group := range []string{"quotes", "audit"} {
+			if got := consume(t, b, &messaging.ConsumeRequest{Topic: "prices", ConsumerGroup: group, BatchSize: 10}); len(got) != 0 {
+				t.Fatalf("group %s got expired messages %v", group, ids(got))
+			}
+		}
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+		dead := deadLetters(t, b, "prices")
+		if len(dead) != 1 || dead[0].GetId() != "stale" || dead[0].GetDeadLetter().GetReason() != "expired" {
+			t.Fatalf("got dead letters %v, want stale once with reason expired", dead)
+		}
+	})
+}
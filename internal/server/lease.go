@@ -0,0 +1,287 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const defaultVisibilityTimeout = 30 * time.Second // How long a delivered message waits for an ack before redelivery
+
+// lease is a delivered message waiting to be acknowledged
+type lease struct {
+	msg        *messaging.Message // The message as stored in the partition
+	partition  *partition
+	offset     int64
+	deliveries int32
+	deadline   time.Time // Redelivered on the next fetch after this passes
+	revived    bool      // Reprocessed from the dead-letter queue, so delivered even if expired
+}
+
+// leaseKey identifies a lease by where its message is stored, as message IDs need not be unique
+type leaseKey struct {
+	partition int // The partition's id
+	offset    int64
+}
+
+func (l *lease) key() leaseKey {
+	return leaseKey{partition: l.partition.id, offset: l.offset}
+}
+
+// leaseTable tracks the unacknowledged deliveries made through one cursor
+type leaseTable struct {
+	mu       sync.Mutex
+	leases   map[leaseKey]*lease
+	released chan struct{} // Closed and replaced whenever leases are acked or nacked
+
+	// How the cursor shares out reads between the lanes of each partition, by partition, for the
+	// topic configuration they were made for
+	turns       map[int]*laneTurns
+	turnsConfig *topicConfig
+}
+
+func newLeaseTable() *leaseTable {
+	return &leaseTable{leases: make(map[leaseKey]*lease), released: make(chan struct{})}
+}
+
+// laneTurns returns what shares out reads between the lanes of a partition, starting afresh when
+// the topic's configuration changed; the caller holds lt.mu
+func (lt *leaseTable) laneTurns(partition int, config *topicConfig) *laneTurns {
+	if lt.turnsConfig != config {
+		lt.turns = make(map[int]*laneTurns)
+		lt.turnsConfig = config
+	}
+	turns, ok := lt.turns[partition]
+	if !ok {
+		turns = newLaneTurns(config)
+		lt.turns[partition] = turns
+	}
+	return turns
+}
+
+// releases returns a channel that is closed the next time leases are acked or nacked
+func (lt *leaseTable) releases() <-chan struct{} {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	return lt.released
+}
+
+// signalRelease wakes everyone waiting on releases; the caller holds lt.mu
+func (lt *leaseTable) signalRelease() {
+	close(lt.released)
+	lt.released = make(chan struct{})
+}
+
+// lease records a first delivery and returns the copy handed to the consumer
+func (lt *leaseTable) lease(msg *messaging.Message, p *partition, offset int64, deadline time.Time) *messaging.Message {
+	l := &lease{msg: msg, partition: p, offset: offset, deadline: deadline}
+	lt.leases[l.key()] = l
+	return l.deliver()
+}
+
+// deliver counts a delivery and returns a copy of the message carrying the count and its position,
+// leaving the shared message in the ring buffer untouched
+func (l *lease) deliver() *messaging.Message {
+	l.deliveries++
+	delivered := proto.Clone(l.msg).(*messaging.Message)
+	delivered.DeliveryCount = l.deliveries
+	delivered.Partition = int32(l.partition.index)
+	delivered.Priority = int32(l.partition.lane)
+	delivered.Offset = l.offset
+	return delivered
+}
+
+// release puts a message back in flight, due for redelivery immediately, as when it is
+// reprocessed from the dead-letter queue
+func (lt *leaseTable) release(msg *messaging.Message, p *partition, offset int64) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	l := &lease{msg: msg, partition: p, offset: offset, deadline: time.Now(), revived: true}
+	lt.leases[l.key()] = l
+}
+
+// redeliver re-leases up to batchSize expired leases from the given partitions, oldest first.
+// Those already delivered maxDeliveries times (when non-zero) are dropped from the table and
+// returned as exhausted instead, and those whose message outlived its time to live (ttl when it
+// sets none) as lapsed.
+func (lt *leaseTable) redeliver(partitions []*partition, batchSize int, now time.Time, timeout time.Duration, maxDeliveries int32, ttl time.Duration) (messages []*messaging.Message, exhausted, lapsed []*lease) {
+	owned := ownedSet(partitions)
+	var due []*lease
+	for _, l := range lt.leases {
+		if owned[l.partition] && !now.Before(l.deadline) {
+			due = append(due, l)
+		}
+	}
+	sortLeases(due)
+
+	for _, l := range due {
+		if !l.revived && expired(l.msg, now, ttl) {
+			delete(lt.leases, l.key())
+			lapsed = append(lapsed, l)
+			continue
+		}
+		if maxDeliveries > 0 && l.deliveries >= maxDeliveries {
+			delete(lt.leases, l.key())
+			exhausted = append(exhausted, l)
+			continue
+		}
+		if len(messages) >= batchSize {
+			continue
+		}
+		l.deadline = now.Add(timeout)
+		messages = append(messages, l.deliver())
+	}
+	return messages, exhausted, lapsed
+}
+
+// forget drops every lease on a partition, as when the cursor is sought elsewhere; the caller
+// holds lt.mu
+func (lt *leaseTable) forget(p *partition) {
+	for key, l := range lt.leases {
+		if l.partition == p {
+			delete(lt.leases, key)
+		}
+	}
+}
+
+// ack releases the leases at the given positions and of the given message IDs, returning how
+// many were in flight
+func (lt *leaseTable) ack(ids []string, keys []leaseKey) int {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	matched := lt.match(ids, keys)
+	for _, l := range matched {
+		delete(lt.leases, l.key())
+	}
+	if len(matched) > 0 {
+		lt.signalRelease()
+	}
+	return len(matched)
+}
+
+// matching returns the leases at the given positions and of the given message IDs, without
+// releasing them
+func (lt *leaseTable) matching(ids []string, keys []leaseKey) []*lease {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	return lt.match(ids, keys)
+}
+
+// nack makes the leases at the given positions and of the given message IDs due for redelivery
+// after delay, returning how many were in flight
+func (lt *leaseTable) nack(ids []string, keys []leaseKey, delay time.Duration) int {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	deadline := time.Now().Add(delay)
+	matched := lt.match(ids, keys)
+	for _, l := range matched {
+		l.deadline = deadline
+	}
+	if len(matched) > 0 {
+		lt.signalRelease()
+	}
+	return len(matched)
+}
+
+// match returns the leases an ack or nack names: those at the given positions, and for each
+// message ID, the oldest lease of a message carrying it, so repeating an ID names as many leases
+// as it is repeated; the caller holds lt.mu
+func (lt *leaseTable) match(ids []string, keys []leaseKey) []*lease {
+	var matched []*lease
+	taken := make(map[*lease]bool)
+	for _, key := range keys {
+		if l, ok := lt.leases[key]; ok && !taken[l] {
+			taken[l] = true
+			matched = append(matched, l)
+		}
+	}
+	if len(ids) == 0 {
+		return matched
+	}
+
+	wanted := make(map[string]int, len(ids))
+	for _, id := range ids {
+		wanted[id]++
+	}
+	byID := make(map[string][]*lease)
+	for _, l := range lt.leases {
+		if id := l.msg.GetId(); wanted[id] > 0 && !taken[l] {
+			byID[id] = append(byID[id], l)
+		}
+	}
+	for id, leases := range byID {
+		sortLeases(leases)
+		matched = append(matched, leases[:min(wanted[id], len(leases))]...)
+	}
+	return matched
+}
+
+// outstanding counts the leases on the given partitions that have not yet expired
+func (lt *leaseTable) outstanding(partitions []*partition, now time.Time) int {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	owned := ownedSet(partitions)
+	n := 0
+	for _, l := range lt.leases {
+		if owned[l.partition] && now.Before(l.deadline) {
+			n++
+		}
+	}
+	return n
+}
+
+// nextDeadline returns when the earliest lease on the given partitions expires, or the zero time
+func (lt *leaseTable) nextDeadline(partitions []*partition) time.Time {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	owned := ownedSet(partitions)
+	var next time.Time
+	for _, l := range lt.leases {
+		if owned[l.partition] && (next.IsZero() || l.deadline.Before(next)) {
+			next = l.deadline
+		}
+	}
+	return next
+}
+
+// oldest returns the lowest leased offset on a partition, leaving out the leases in skip
+func (lt *leaseTable) oldest(p *partition, skip map[*lease]bool) (int64, bool) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	var oldest int64
+	found := false
+	for _, l := range lt.leases {
+		if l.partition == p && !skip[l] && (!found || l.offset < oldest) {
+			oldest, found = l.offset, true
+		}
+	}
+	return oldest, found
+}
+
+// ownedSet indexes partitions for membership checks
+func ownedSet(partitions []*partition) map[*partition]bool {
+	owned := make(map[*partition]bool, len(partitions))
+	for _, p := range partitions {
+		owned[p] = true
+	}
+	return owned
+}
+
+// sortLeases orders leases by partition, then offset
+func sortLeases(leases []*lease) {
+	sort.Slice(leases, func(i, j int) bool {
+		if leases[i].partition != leases[j].partition {
+			return leases[i].partition.id < leases[j].partition.id
+		}
+		return leases[i].offset < leases[j].offset
+	})
+}
@@ -0,0 +1,111 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+)
+
+func nack(t *testing.T, b *broker, topic, group string, delay time.Duration, msgs ...*messaging.Message) {
+	t.Helper()
+	req := &messaging.NackRequest{Topic: topic, ConsumerGroup: group, DelayMs: delay.Milliseconds()}
+	for _, msg := range msgs {
+		req.Positions = append(req.Positions, position(msg))
+	}
+	resp, err := b.Nack(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetSuccess() {
+		t.Fatalf("nacking: %s", resp.GetError())
+	}
+}
+
+// TestUnacknowledgedRedelivered ensures a message not acknowledged within the visibility timeout is
+// delivered again with its delivery count raised, and an acknowledged one is not
+func TestUnacknowledgedRedelivered(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "jobs", &messaging.TopicConfig{VisibilityTimeoutMs: 100})
+		register(t, b, "jobs", "workers")
+		publish(t, b, &messaging.PublishRequest{Topic: "jobs", Message: &messaging.Message{Id: "job-1"}})
+		req := &messaging.ConsumeRequest{Topic: "jobs", ConsumerGroup: "workers", BatchSize: 10}
+
+		got := consume(t, b, req)
+		if len(got) != 1 || got[0].GetDeliveryCount() != 1 {
+			t.Fatalf("first delivery = %v", got)
+		}
+		if got := consume(t, b, req); len(got) != 0 {
+			t.Fatalf("leased message delivered again at once: %v", ids(got))
+		}
+		time.Sleep(150 * time.Millisecond)
+		got = consume(t, b, req)
+		if len(got) != 1 || got[0].GetId() != "job-1" || got[0].GetDeliveryCount() != 2 {
+			t.Fatalf("after the visibility timeout got %v, want job-1 delivered a second time", got)
+		}
+
+		if cfg.DataDir != "" {
+			// Unacknowledged messages are delivered again after a restart
+			b = b.restart(t, cfg)
+			if got = consume(t, b, req); len(got) != 1 || got[0].GetId() != "job-1" {
+				t.Fatalf("after restart got %v, want job-1", ids(got))
+			}
+		}
+		ack(t, b, "jobs", "workers", got[0])
+		time.Sleep(150 * time.Millisecond)
+		if got := consume(t, b, req); len(got) != 0 {
+			t.Fatalf("acknowledged message delivered again: %v", ids(got))
+		}
+	})
+}
+
+// TestNackDelay ensures a nacked message is delivered again after the delay asked for, or at once
+func TestNackDelay(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "jobs", nil)
+		register(t, b, "jobs", "workers")
+		publish(t, b, &messaging.PublishRequest{Topic: "jobs", Message: &messaging.Message{Id: "job-1"}})
+		req := &messaging.ConsumeRequest{Topic: "jobs", ConsumerGroup: "workers", BatchSize: 10}
+
+		got := consume(t, b, req)
+		nack(t, b, "jobs", "workers", 200*time.Millisecond, got...)
+		if got := consume(t, b, req); len(got) != 0 {
+			t.Fatalf("nacked message delivered before its delay: %v", ids(got))
+		}
+		time.Sleep(250 * time.Millisecond)
+		got = consume(t, b, req)
+		if len(got) != 1 || got[0].GetDeliveryCount() != 2 {
+			t.Fatalf("after the nack delay got %v, want job-1 delivered a second time", got)
+		}
+
+		nack(t, b, "jobs", "workers", 0, got...)
+		if got := consume(t, b, req); len(got) != 1 || got[0].GetDeliveryCount() != 3 {
+			t.Fatalf("after a nack without delay got %v, want job-1 delivered a third time", got)
+		}
+	})
+}
+
+// TestAckByPosition ensures acknowledging one of two deliveries sharing an ID leaves the other
+// leased
+func TestAckByPosition(t *testing.T) {
+	b := start(t, server.DefaultConfig())
+	createTopic(t, b, "jobs", &messaging.TopicConfig{VisibilityTimeoutMs: 100})
+	register(t, b, "jobs", "workers")
+	publish(t, b, &messaging.PublishRequest{Topic: "jobs", Message: &messaging.Message{Id: "job"}})
+	publish(t, b, &messaging.PublishRequest{Topic: "jobs", Message: &messaging.Message{Id: "job"}})
+	req := &messaging.ConsumeRequest{Topic: "jobs", ConsumerGroup: "workers", BatchSize: 10}
+
+	got := consume(t, b, req)
+	if len(got) != 2 {
+		t.Fatalf("got %d messages, want 2", len(got))
+	}
+	ack(t, b, "jobs", "workers", got[1])
+	time.Sleep(150 * time.Millisecond)
+	if got := consume(t, b, req); len(got) != 1 || got[0].GetOffset() != 0 {
+		t.Fatalf("redelivered %v, want only the message at offset 0", got)
+	}
+}
@@ -2,12 +2,21 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/a1mart/kafkaesque/internal/draupnir"
 	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+const maxConsumeWait = 30 * time.Second // Upper bound on ConsumeRequest.max_wait_ms
+
 // Publish a message
 func (s *Server) Publish(ctx context.Context, req *messaging.PublishRequest) (*messaging.PublishResponse, error) {
 	message := req.GetMessage()
@@ -16,16 +25,207 @@ func (s *Server) Publish(ctx context.Context, req *messaging.PublishRequest) (*m
 		return nil, fmt.Errorf("invalid message")
 	}
 
-	s.rb.Put(message)
-	log.Printf("Published message with ID: %s", message.GetId())
-	return &messaging.PublishResponse{Success: true}, nil
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	if err := t.beginPublish(); err != nil {
+		return nil, err
+	}
+	defer t.endPublish()
+	txn, err := s.joinTransaction(req.GetTransactionId())
+	if err != nil {
+		return nil, err
+	}
+	if txn != nil {
+		defer txn.mu.Unlock()
+	}
+	txn.claim(message)
+	at, err := deliverAt(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !at.IsZero() && (txn != nil || message.GetId() == "") {
+		return nil, status.Error(codes.InvalidArgument, "scheduled messages need an ID and cannot be published in a transaction")
+	}
+
+	if err := s.validate(t, t.config(), message); err != nil {
+		var invalid *schemaError
+		if !errors.As(err, &invalid) {
+			return nil, err
+		}
+		if err := s.deadLetterInvalid(t, message, invalid); err != nil {
+			return nil, err
+		}
+		return &messaging.PublishResponse{Success: false, Error: invalid.Error()}, nil
+	}
+
+	pin := -1
+	if req.Partition != nil {
+		// Producers retrying with a sequence number pin the partition, so the retry meets the original
+		if pin = int(req.GetPartition()); pin < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "topic %q has no partition %d", t.name, pin)
+		}
+	}
+	p, err := t.route(message, pin)
+	if err != nil {
+		return nil, err
+	}
+	if !at.IsZero() {
+		if err := t.scheduled.schedule(&messaging.ScheduledMessage{Message: message, DeliverAt: at.UnixMilli(), Partition: int32(pin)}); err != nil {
+			return nil, err
+		}
+		s.wakeScheduler()
+		log.Printf("Scheduled message with ID: %s on topic: %s for %s", message.GetId(), t.name, at.Format(time.RFC3339Nano))
+		return &messaging.PublishResponse{Success: true, Scheduled: true, Partition: -1, Offset: -1}, nil
+	}
+
+	txn.enlist(t, p)
+	results, err := p.append(message)
+	if errors.Is(err, draupnir.ErrDropped) {
+		log.Printf("Dropped message with ID: %s on full topic: %s partition: %d", message.GetId(), t.name, p.index)
+		return &messaging.PublishResponse{Success: false, Error: err.Error(), Partition: int32(p.index), Priority: int32(p.lane)}, nil
+	}
+	if err != nil {
+		return nil, appendError(t, p, err)
+	}
+	result := results[0]
+	if result.err != nil {
+		return nil, result.err
+	}
+	if result.duplicate {
+		log.Printf("Ignored duplicate of message with ID: %s on topic: %s partition: %d offset: %d", message.GetId(), t.name, p.index, result.offset)
+	} else {
+		log.Printf("Published message with ID: %s to topic: %s partition: %d offset: %d", message.GetId(), t.name, p.index, result.offset)
+	}
+	return &messaging.PublishResponse{Success: true, Partition: int32(p.index), Offset: result.offset, Duplicate: result.duplicate, Priority: int32(p.lane)}, nil
+}
+
+// schemaError reports a payload rejected by its topic's schema; such messages are dead-lettered
+type schemaError struct {
+	err error
+}
+
+func (e *schemaError) Error() string {
+	return fmt.Sprintf("schema validation failed, message moved to dead-letter queue: %v", e.err)
+}
+
+// validate checks a message against the limits, cleanup policy and schema of its topic
+func (s *Server) validate(t *topic, config *topicConfig, message *messaging.Message) error {
+	if _, ok := message.GetHeaders()[""]; ok {
+		return status.Error(codes.InvalidArgument, "message headers need a name")
+	}
+	if message.GetTtlMs() < 0 {
+		return status.Error(codes.InvalidArgument, "message ttl_ms must not be negative")
+	}
+	if config.maxMessageBytes > 0 {
+		if size := proto.Size(message); size > config.maxMessageBytes {
+			return status.Errorf(codes.InvalidArgument, "message of %d bytes exceeds the %d byte limit of topic %q", size, config.maxMessageBytes, t.name)
+		}
+	}
+	tombstone := config.cleanupPolicy == cleanupCompact && isTombstone(message)
+	if config.cleanupPolicy == cleanupCompact && message.GetKey() == "" {
+		return status.Errorf(codes.InvalidArgument, "topic %q is compacted by key; messages need a key", t.name)
+	}
+	if config.schemaName != "" && !tombstone {
+		schema, err := s.schemas.lookup(config.schemaName, config.schemaVersion)
+		if err != nil {
+			return status.Errorf(codes.FailedPrecondition, "topic %q: %v", t.name, err)
+		}
+		if err := s.schemas.validate(schema, message.GetPayload()); err != nil {
+			return &schemaError{err: err}
+		}
+	}
+	return nil
+}
+
+// deadLetterInvalid moves a message its topic's schema rejected to the dead-letter queue
+func (s *Server) deadLetterInvalid(t *topic, message *messaging.Message, invalid *schemaError) error {
+	dead := deadLetter(message, &messaging.DeadLetterInfo{Reason: invalid.err.Error(), Topic: t.name, Partition: -1, Offset: -1})
+	if err := t.dlq.add(dead); err != nil {
+		return status.Errorf(codes.Internal, "writing dead letter: %v", err)
+	}
+	log.Printf("Dead-lettered message with ID: %s on topic: %s: %v", message.GetId(), t.name, invalid.err)
+	return nil
+}
+
+// appendError converts an error from appending to a partition into the status Publish returns
+func appendError(t *topic, p *partition, err error) error {
+	if errors.Is(err, draupnir.ErrFull) || errors.Is(err, draupnir.ErrTimeout) {
+		return status.Errorf(codes.ResourceExhausted, "topic %q partition %d: %v", t.name, p.index, err)
+	}
+	log.Printf("Publish to topic %s partition %d failed: %v", t.name, p.index, err)
+	return status.Errorf(codes.Internal, "writing to log: %v", err)
 }
 
 // Consume messages
 func (s *Server) Consume(ctx context.Context, req *messaging.ConsumeRequest) (*messaging.ConsumeResponse, error) {
-	messages := s.rb.Get(int(req.GetBatchSize()), int(req.GetConsumerGroup()))
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+
+	group := req.GetConsumerGroup()
+	filter, err := compileFilter(req.GetFilterExpression())
+	if err != nil {
+		return nil, err
+	}
+	committed, err := isolationLevel(req.GetIsolationLevel())
+	if err != nil {
+		return nil, err
+	}
+	owned, cursor, err := t.assign(group, req.GetConsumerId())
+	if err != nil {
+		return nil, err
+	}
+
+	wait := time.Duration(req.GetMaxWaitMs()) * time.Millisecond
+	if wait > maxConsumeWait {
+		wait = maxConsumeWait
+	}
+	deadline := time.Now().Add(wait)
+
+	// A paused group receives nothing; a long-poll waits for it to be resumed
+	if paused, resumed := t.pauseState(group); paused {
+		waitCtx, cancel := context.WithDeadline(ctx, deadline)
+		defer cancel()
+		select {
+		case <-resumed:
+		case <-waitCtx.Done():
+			return &messaging.ConsumeResponse{Success: true}, nil
+		}
+	}
+
+	batchSize := int(req.GetBatchSize())
+	messages, err := s.fetch(ctx, t, group, owned, cursor, batchSize, filter, committed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Long-poll until a message arrives on an owned partition or the wait runs out
+	if len(messages) == 0 && wait > 0 {
+		// Wake early for the next lease that falls due for redelivery
+		if next := t.leaseTable(cursor).nextDeadline(owned); !next.IsZero() && next.Before(deadline) {
+			deadline = next
+		}
+		waitCtx, cancel := context.WithDeadline(ctx, deadline)
+		// Keep waiting while new messages are all filtered out
+		for err == nil && len(messages) == 0 && t.wait(waitCtx, owned, cursor, committed) && !t.isDeleted() {
+			messages, err = s.fetch(ctx, t, group, owned, cursor, batchSize, filter, committed)
+		}
+		cancel()
+		if err == nil && len(messages) == 0 {
+			messages, err = s.fetch(ctx, t, group, owned, cursor, batchSize, filter, committed)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(messages) == 0 && t.isDeleted() {
+		return nil, t.errDeleted()
+	}
 	if len(messages) == 0 {
-		log.Printf("No messages to consume for consumer group %d", req.GetConsumerGroup())
+		log.Printf("No messages to consume on topic %s for consumer group %s", t.name, group)
 	}
 
 	for _, msg := range messages {
@@ -37,18 +237,129 @@ func (s *Server) Consume(ctx context.Context, req *messaging.ConsumeRequest) (*m
 	return &messaging.ConsumeResponse{Messages: messages, Success: true}, nil
 }
 
+// Acknowledge releases delivered messages so they are never redelivered
 func (s *Server) Acknowledge(ctx context.Context, req *messaging.AckRequest) (*messaging.AckResponse, error) {
-	log.Printf("Acknowledging messages: %v for consumer group %s", req.GetMessageIds(), req.GetConsumerGroup())
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := t.cursorFor(req.GetConsumerGroup(), req.GetConsumerId())
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := t.leaseKeys(req.GetPositions())
+	if err != nil {
+		return nil, err
+	}
+
+	acked := t.leaseTable(cursor).ack(req.GetMessageIds(), keys)
+	log.Printf("Acknowledged %d of %d messages for consumer group %s on topic %s", acked, len(req.GetMessageIds())+len(keys), req.GetConsumerGroup(), t.name)
 	return &messaging.AckResponse{Success: true}, nil
 }
 
-func (s *Server) GetDeadLetters(ctx context.Context, req *messaging.DeadLetterRequest) (*messaging.DeadLetterResponse, error) {
-	log.Println("Fetching dead letter messages...")
-	return &messaging.DeadLetterResponse{Messages: nil, Success: true}, nil
+// Nack hands delivered messages back for redelivery after an optional delay
+func (s *Server) Nack(ctx context.Context, req *messaging.NackRequest) (*messaging.NackResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := t.cursorFor(req.GetConsumerGroup(), req.GetConsumerId())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetDelayMs() < 0 {
+		return &messaging.NackResponse{Success: false, Error: "Delay must not be negative"}, nil
+	}
+	keys, err := t.leaseKeys(req.GetPositions())
+	if err != nil {
+		return nil, err
+	}
+
+	delay := time.Duration(req.GetDelayMs()) * time.Millisecond
+	nacked := t.leaseTable(cursor).nack(req.GetMessageIds(), keys, delay)
+	log.Printf("Nacked %d of %d messages for consumer group %s on topic %s, redelivering in %v", nacked, len(req.GetMessageIds())+len(keys), req.GetConsumerGroup(), t.name, delay)
+	return &messaging.NackResponse{Success: true}, nil
 }
 
+// RegisterConsumerGroup adds a consumer group to a topic. Registering an existing group is a no-op.
 func (s *Server) RegisterConsumerGroup(ctx context.Context, req *messaging.RegisterConsumerRequest) (*messaging.RegisterConsumerResponse, error) {
-	s.consumerMap[req.GetConsumerGroup()] = true
-	log.Printf("Registered consumer group: %s", req.GetConsumerGroup())
+	group := req.GetConsumerGroup()
+	if !validTopicName.MatchString(group) {
+		return &messaging.RegisterConsumerResponse{Success: false, Error: "Consumer group names may only contain letters, digits, '.', '_' and '-'"}, nil
+	}
+
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	if !t.register(group) {
+		return &messaging.RegisterConsumerResponse{Success: true}, nil
+	}
+	if err := s.saveTopicMeta(t); err != nil {
+		return nil, status.Errorf(codes.Internal, "persisting consumer group %q: %v", group, err)
+	}
+	log.Printf("Registered consumer group: %s on topic: %s", group, t.name)
 	return &messaging.RegisterConsumerResponse{Success: true}, nil
 }
+
+// UnregisterConsumerGroup drops a consumer group from a topic, releasing the messages it held
+// back and its unacknowledged deliveries
+func (s *Server) UnregisterConsumerGroup(ctx context.Context, req *messaging.UnregisterConsumerRequest) (*messaging.UnregisterConsumerResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	group := req.GetConsumerGroup()
+	if !t.unregister(group) {
+		return nil, status.Errorf(codes.NotFound, "consumer group %q is not registered on topic %q", group, t.name)
+	}
+	if err := s.saveTopicMeta(t); err != nil {
+		return nil, status.Errorf(codes.Internal, "persisting removal of consumer group %q: %v", group, err)
+	}
+	s.dispatch.Forget(dispatchFlow(t.name, group))
+	log.Printf("Unregistered consumer group: %s from topic: %s", group, t.name)
+	return &messaging.UnregisterConsumerResponse{Success: true}, nil
+}
+
+// PauseConsumer stops deliveries to a consumer group without moving its offsets. Once the group
+// lags more than the configured bound it stops holding producers back.
+func (s *Server) PauseConsumer(ctx context.Context, req *messaging.PauseConsumerRequest) (*messaging.PauseConsumerResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setPaused(t, req.GetConsumerGroup(), true); err != nil {
+		return nil, err
+	}
+	return &messaging.PauseConsumerResponse{Success: true}, nil
+}
+
+// ResumeConsumer restarts deliveries to a paused consumer group from where it left off
+func (s *Server) ResumeConsumer(ctx context.Context, req *messaging.ResumeConsumerRequest) (*messaging.ResumeConsumerResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setPaused(t, req.GetConsumerGroup(), false); err != nil {
+		return nil, err
+	}
+	return &messaging.ResumeConsumerResponse{Success: true}, nil
+}
+
+// setPaused pauses or resumes a consumer group and persists the change
+func (s *Server) setPaused(t *topic, group string, paused bool) error {
+	changed, err := t.setPaused(group, paused)
+	if err != nil || !changed {
+		return err
+	}
+	if err := s.saveTopicMeta(t); err != nil {
+		return status.Errorf(codes.Internal, "persisting consumer group %q: %v", group, err)
+	}
+	if paused {
+		log.Printf("Paused consumer group: %s on topic: %s", group, t.name)
+	} else {
+		log.Printf("Resumed consumer group: %s on topic: %s", group, t.name)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestUnknownTopic ensures publishing, consuming and seeking on a topic that was never created
+// fail with NotFound
+func TestUnknownTopic(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", nil)
+		ctx := context.Background()
+
+		calls := map[string]func() error{
+			"Publish": func() error {
+				_, err := b.Publish(ctx, &messaging.PublishRequest{Topic: "invoices", Message: &messaging.Message{Id: "invoice-1"}})
+				return err
+			},
+			"Consume": func() error {
+				_, err := b.Consume(ctx, &messaging.ConsumeRequest{Topic: "invoices", ConsumerGroup: "billing", BatchSize: 1})
+				return err
+			},
+			"Seek": func() error {
+				_, err := b.Seek(ctx, &messaging.SeekRequest{Topic: "invoices", ConsumerGroup: "billing", Position: &messaging.SeekRequest_Offset{Offset: 0}})
+				return err
+			},
+		}
+		for name, call := range calls {
+			if err := call(); status.Code(err) != codes.NotFound {
+				t.Errorf("%s on an unknown topic returned %v, want NotFound", name, err)
+			}
+		}
+	})
+}
+
+// TestFilterSkips ensures consumers and streams with a filter are sent only matching messages, and
+// move past the rest without leasing them
+func TestFilterSkips(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "readings", &messaging.TopicConfig{VisibilityTimeoutMs: 100})
+		register(t, b, "readings", "alerts")
+		register(t, b, "readings", "dashboard")
+		for i, temperature := range []int{10, 40, 20, 50} {
+			payload := fmt.Sprintf(`{"temperature": %d}`, temperature)
+			publish(t, b, &messaging.PublishRequest{Topic: "readings", Message: &messaging.Message{Id: fmt.Sprintf("reading-%d", i), Payload: []byte(payload)}})
+		}
+		const hot = "temperature > 30"
+
+		got := consume(t, b, &messaging.ConsumeRequest{Topic: "readings", ConsumerGroup: "alerts", BatchSize: 10, FilterExpression: hot})
+		if fmt.Sprint(ids(got)) != "[reading-1 reading-3]" {
+			t.Fatalf("filtered consume got %v, want [reading-1 reading-3]", ids(got))
+		}
+		for _, msg := range got {
+			ack(t, b, "readings", "alerts", msg)
+		}
+
+		stream, cancel := openStream(b, &messaging.StreamRequest{Topic: "readings", ConsumerGroup: "dashboard", ConsumerId: "screen", FilterExpression: hot})
+		var streamed []*messaging.Message
+		for len(streamed) < 2 {
+			msgs := stream.receive(time.Second)
+			if len(msgs) == 0 {
+				break
+			}
+			streamed = append(streamed, msgs...)
+		}
+		if fmt.Sprint(ids(streamed)) != "[reading-1 reading-3]" {
+			t.Fatalf("filtered stream got %v, want [reading-1 reading-3]", ids(streamed))
+		}
+		for _, msg := range streamed {
+			ack(t, b, "readings", "dashboard", msg)
+		}
+		cancel()
+
+		// Skipped messages are past the cursor and hold no lease, so nothing falls due again
+		time.Sleep(150 * time.Millisecond)
+		publish(t, b, &messaging.PublishRequest{Topic: "readings", Message: &messaging.Message{Id: "reading-4", Payload: []byte(`{"temperature": 5}`)}})
+		for _, group := range []string{"alerts", "dashboard"} {
+			got := consume(t, b, &messaging.ConsumeRequest{Topic: "readings", ConsumerGroup: group, BatchSize: 10})
+			if fmt.Sprint(ids(got)) != "[reading-4]" {
+				t.Fatalf("%s got %v after filtering, want only [reading-4]", group, ids(got))
+			}
+		}
+	})
+}
+
+// TestMetadataRoundTrip ensures headers, content type and correlation IDs reach consumers and
+// streams as published, across a restart, and filters can match on headers
+func TestMetadataRoundTrip(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", nil)
+		for _, group := range []string{"billing", "shipping", "europe"} {
+			register(t, b, "orders", group)
+		}
+		published := []*messaging.Message{
+			{Id: "order-1", Headers: map[string]string{"region": "eu", "x-trace-id": "trace-1"}, ContentType: "application/json", CorrelationId: "checkout-1", CausationId: "cart-1", ProducerId: "shop"},
+			{Id: "order-2", Headers: map[string]string{"region": "us"}, ContentType: "application/protobuf", CorrelationId: "checkout-2", CausationId: "cart-2", ProducerId: "shop"},
+		}
+		for _, msg := range published {
+			publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: msg})
+		}
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+
+		check := func(how string, got []*messaging.Message, want ...*messaging.Message) {
+			t.Helper()
+			if len(got) != len(want) {
+				t.Fatalf("%s got %v, want %v", how, ids(got), ids(want))
+			}
+			for i, msg := range got {
+				w := want[i]
+				if msg.GetId() != w.GetId() || fmt.Sprint(msg.GetHeaders()) != fmt.Sprint(w.GetHeaders()) ||
+					msg.GetContentType() != w.GetContentType() || msg.GetCorrelationId() != w.GetCorrelationId() ||
+					msg.GetCausationId() != w.GetCausationId() || msg.GetProducerId() != w.GetProducerId() {
+					t.Fatalf("%s got %v, want metadata of %v", how, msg, w)
+				}
+			}
+		}
+		check("consume", consume(t, b, &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 10}), published...)
+
+		stream, cancel := openStream(b, &messaging.StreamRequest{Topic: "orders", ConsumerGroup: "shipping", ConsumerId: "warehouse"})
+		var streamed []*messaging.Message
+		for len(streamed) < len(published) {
+			msgs := stream.receive(time.Second)
+			if len(msgs) == 0 {
+				break
+			}
+			streamed = append(streamed, msgs...)
+		}
+		cancel()
+		check("stream", streamed, published...)
+
+		filter := `headers.region == "eu" && headers["x-trace-id"] == "trace-1"`
+		check("header filter", consume(t, b, &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "europe", BatchSize: 10, FilterExpression: filter}), published[0])
+	})
+}
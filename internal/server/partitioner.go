@@ -0,0 +1,83 @@
+package server
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+)
+
+const stickyBatchSize = 64 // Keyless messages written to one partition before the sticky partitioner moves on
+
+// Partitioner picks the partition a message is written to
+type Partitioner interface {
+	Partition(msg *messaging.Message, numPartitions int) int
+}
+
+// newPartitioner resolves a partitioner by its topic config name
+func newPartitioner(name string) (Partitioner, error) {
+	switch name {
+	case "", "hash":
+		return &hashPartitioner{}, nil
+	case "round_robin":
+		return &roundRobinPartitioner{}, nil
+	case "sticky":
+		return &stickyPartitioner{}, nil
+	default:
+		return nil, fmt.Errorf("unknown partitioner %q", name)
+	}
+}
+
+// hashPartitioner hashes the message key so every message with the same key keeps its order on one partition.
+// Keyless messages fall back to round robin.
+type hashPartitioner struct {
+	roundRobinPartitioner
+}
+
+func (p *hashPartitioner) Partition(msg *messaging.Message, numPartitions int) int {
+	if msg.GetKey() == "" {
+		return p.roundRobinPartitioner.Partition(msg, numPartitions)
+	}
+	return hashKey(msg.GetKey(), numPartitions)
+}
+
+// roundRobinPartitioner spreads messages evenly across partitions, ignoring keys
+type roundRobinPartitioner struct {
+	next uint64
+}
+
+func (p *roundRobinPartitioner) Partition(msg *messaging.Message, numPartitions int) int {
+	return int((atomic.AddUint64(&p.next, 1) - 1) % uint64(numPartitions))
+}
+
+// stickyPartitioner hashes keyed messages and keeps keyless messages on one partition for a batch
+// before moving to the next, trading a little balance for locality
+type stickyPartitioner struct {
+	mu        sync.Mutex
+	current   int
+	remaining int
+}
+
+func (p *stickyPartitioner) Partition(msg *messaging.Message, numPartitions int) int {
+	if msg.GetKey() != "" {
+		return hashKey(msg.GetKey(), numPartitions)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.remaining == 0 {
+		p.current = (p.current + 1) % numPartitions
+		p.remaining = stickyBatchSize
+	}
+	p.remaining--
+	return p.current % numPartitions
+}
+
+func hashKey(key string, numPartitions int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(numPartitions))
+}
@@ -0,0 +1,117 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+)
+
+func listPartitions(t *testing.T, b *broker, topic string) []*messaging.PartitionInfo {
+	t.Helper()
+	resp, err := b.ListPartitions(context.Background(), &messaging.ListPartitionsRequest{Topic: topic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.GetPartitions()
+}
+
+// TestKeyedMessagesKeepTheirPartition ensures messages with the same key land on one partition and
+// are consumed in order, and that partitions and their offsets are reported, across a restart
+func TestKeyedMessagesKeepTheirPartition(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", &messaging.TopicConfig{Partitions: 4})
+		register(t, b, "orders", "billing")
+
+		partitionOf := make(map[string]int32)
+		for i := 0; i < 5; i++ {
+			for k := 0; k < 8; k++ {
+				key := fmt.Sprintf("customer-%d", k)
+				resp := publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: fmt.Sprintf("%s/%d", key, i), Key: key}})
+				if p, seen := partitionOf[key]; seen && p != resp.GetPartition() {
+					t.Fatalf("key %s went to partitions %d and %d", key, p, resp.GetPartition())
+				}
+				partitionOf[key] = resp.GetPartition()
+			}
+		}
+
+		topics, err := b.ListTopics(context.Background(), &messaging.ListTopicsRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(topics.GetTopics()) != 1 || topics.GetTopics()[0].GetPartitions() != 4 {
+			t.Fatalf("ListTopics = %v, want orders with 4 partitions", topics.GetTopics())
+		}
+
+		next := make(map[string]int)
+		for _, msg := range consume(t, b, &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 100}) {
+			key := msg.GetKey()
+			_, seq, _ := strings.Cut(msg.GetId(), "/")
+			if i, _ := strconv.Atoi(seq); i != next[key] {
+				t.Fatalf("got %s, want message %d of key %s", msg.GetId(), next[key], key)
+			}
+			next[key]++
+			ack(t, b, "orders", "billing", msg)
+		}
+		for k := 0; k < 8; k++ {
+			if key := fmt.Sprintf("customer-%d", k); next[key] != 5 {
+				t.Fatalf("got %d messages of key %s, want 5", next[key], key)
+			}
+		}
+
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+		partitions := listPartitions(t, b, "orders")
+		if len(partitions) != 4 {
+			t.Fatalf("ListPartitions returned %d partitions, want 4", len(partitions))
+		}
+		var total int64
+		for _, p := range partitions {
+			total += p.GetNextOffset()
+			if read := p.GetConsumerOffsets()["billing"]; read != p.GetNextOffset()-1 {
+				t.Fatalf("partition %d: billing read up to %d, want %d", p.GetPartition(), read, p.GetNextOffset()-1)
+			}
+		}
+		if total != 40 {
+			t.Fatalf("partitions hold %d messages, want 40", total)
+		}
+	})
+}
+
+// TestMembersSplitPartitions ensures the members of a consumer group read disjoint partitions that
+// together cover the topic
+func TestMembersSplitPartitions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", &messaging.TopicConfig{Partitions: 4, Partitioner: "round_robin"})
+		register(t, b, "orders", "billing")
+		members := []string{"a", "b"}
+		for _, member := range members {
+			consume(t, b, &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", ConsumerId: member, BatchSize: 1})
+		}
+		for i := 0; i < 40; i++ {
+			publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: fmt.Sprintf("order-%d", i)}})
+		}
+
+		owner := make(map[int32]string)
+		total := 0
+		for _, member := range members {
+			for _, msg := range consume(t, b, &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", ConsumerId: member, BatchSize: 100}) {
+				if other, ok := owner[msg.GetPartition()]; ok && other != member {
+					t.Fatalf("members %s and %s both read partition %d", other, member, msg.GetPartition())
+				}
+				owner[msg.GetPartition()] = member
+				total++
+			}
+		}
+		if total != 40 || len(owner) != 4 {
+			t.Fatalf("members read %d messages from %d partitions, want 40 from 4", total, len(owner))
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/kronos"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Priority policies, deciding how consumers share out reads between the lanes of a partition
+const (
+	priorityStrict   = "strict"               // The highest lane with messages always goes first
+	priorityWeighted = "weighted_round_robin" // Lanes take turns, each reading up to its weight per round
+	priorityMLFQ     = "mlfq"                 // Lanes that keep using their whole turn drop behind the others
+)
+
+const (
+	maxPriorityLevels = 16
+	mlfqQuantum       = 16               // Messages a lane on the top MLFQ level reads per turn
+	mlfqBoostEvery    = 256              // Turns after which MLFQ lanes return to the top level
+	laneItem          = time.Millisecond // Run time a lane is charged per message, as schedulers deal in time
+)
+
+// defaultPriorityWeights weighs each lane by its level plus one
+func defaultPriorityWeights(levels int) []int {
+	weights := make([]int, levels)
+	for lane := range weights {
+		weights[lane] = lane + 1
+	}
+	return weights
+}
+
+// laneTurns shares out a cursor's reads between the lanes of a partition. Each lane is a task of
+// the scheduler the topic's priority policy picks, its Priority the lane number. A lane that runs
+// out of messages leaves the scheduler until the next fetch, and one whose turn a full batch cut
+// short resumes it on the next.
+type laneTurns struct {
+	scheduler kronos.Scheduler
+	lanes     []*kronos.Task
+	queued    []bool       // Whether each lane is in the scheduler or taking its turn
+	turn      *kronos.Task // Lane taking its turn, if any
+	left      int          // Messages the lane may still read this turn; 0 for no limit
+	ran       int          // Messages the lane read this turn
+}
+
+func newLaneTurns(config *topicConfig) *laneTurns {
+	var scheduler kronos.Scheduler
+	switch config.priorityPolicy {
+	case priorityStrict:
+		scheduler = kronos.NewPriority()
+	case priorityMLFQ:
+		scheduler = kronos.NewFeedbackQueue(config.priorityLevels, mlfqQuantum*laneItem, mlfqBoostEvery)
+	default:
+		scheduler = kronos.NewWeightedRoundRobin(laneItem)
+	}
+	lt := &laneTurns{scheduler: scheduler, lanes: make([]*kronos.Task, config.priorityLevels), queued: make([]bool, config.priorityLevels)}
+	for lane := range lt.lanes {
+		lt.lanes[lane] = &kronos.Task{ID: strconv.Itoa(lane), Priority: lane, Weight: config.priorityWeights[lane]}
+	}
+	return lt
+}
+
+// start begins a fetch, queueing again the lanes that ran out of messages, highest first, as they
+// may have some by now
+func (lt *laneTurns) start() {
+	for lane := len(lt.lanes) - 1; lane >= 0; lane-- {
+		if !lt.queued[lane] {
+			lt.scheduler.Push(lt.lanes[lane])
+			lt.queued[lane] = true
+		}
+	}
+}
+
+// next returns the lane to read next and how many messages it may read, 0 meaning no limit, or
+// false if every lane ran out of messages
+func (lt *laneTurns) next() (lane, quota int, ok bool) {
+	if lt.turn == nil {
+		task, slice, ok := lt.scheduler.Pop()
+		if !ok {
+			return 0, 0, false
+		}
+		lt.turn, lt.left, lt.ran = task, int(slice/laneItem), 0
+		if slice > 0 {
+			lt.left = max(lt.left, 1)
+		}
+	}
+	return lt.turn.Priority, lt.left, true
+}
+
+// served reports how many messages the lane next picked read, and whether it ran out of them. A
+// lane that used its whole turn goes back to the scheduler, charged for what it read.
+func (lt *laneTurns) served(n int, drained bool) {
+	task := lt.turn
+	lt.ran += n
+	switch {
+	case drained:
+		lt.queued[task.Priority] = false
+	case lt.left > 0 && n < lt.left:
+		lt.left -= n
+		return // The batch is full; the turn carries over to the next fetch
+	default:
+		lt.scheduler.Yield(task, time.Duration(lt.ran)*laneItem)
+	}
+	lt.turn = nil
+}
+
+// route returns the lane a message is published to: the one of its priority, in the partition
+// pinned or unless pin is -1, in the one the partitioner picks
+func (t *topic) route(msg *messaging.Message, pin int) (*partition, error) {
+	if pin == -1 {
+		pin = t.partitioner.Partition(msg, t.partitionCount())
+	}
+	lanes, err := t.lanes(pin)
+	if err != nil {
+		return nil, err
+	}
+	return lanes[min(max(int(msg.GetPriority()), 0), t.levels-1)], nil
+}
+
+// partitionCount returns the number of partitions clients see
+func (t *topic) partitionCount() int {
+	return len(t.partitions) / t.levels
+}
+
+// lanes returns the lanes of a partition, lowest priority first
+func (t *topic) lanes(partition int) ([]*partition, error) {
+	if partition < 0 || partition >= t.partitionCount() {
+		return nil, status.Errorf(codes.InvalidArgument, "topic %q has no partition %d", t.name, partition)
+	}
+	return t.partitions[partition*t.levels : (partition+1)*t.levels], nil
+}
+
+// lane returns a lane of a partition, as a client names it
+func (t *topic) lane(partition, priority int) (*partition, error) {
+	lanes, err := t.lanes(partition)
+	if err != nil {
+		return nil, err
+	}
+	if priority < 0 || priority >= len(lanes) {
+		return nil, status.Errorf(codes.InvalidArgument, "topic %q has no priority level %d", t.name, priority)
+	}
+	return lanes[priority], nil
+}
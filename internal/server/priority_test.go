@@ -0,0 +1,143 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+)
+
+// publishLane publishes n messages at a priority, with IDs of prefix followed by a count
+func publishLane(t *testing.T, b *broker, topic string, priority, n int, prefix string) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		publish(t, b, &messaging.PublishRequest{Topic: topic, Message: &messaging.Message{Id: fmt.Sprintf("%s%d", prefix, i), Priority: int32(priority)}})
+	}
+}
+
+// drain consumes in batches of batchSize until nothing is left, returning the IDs in order
+func drain(t *testing.T, b *broker, topic, group string, batchSize int) string {
+	t.Helper()
+	var got []string
+	for {
+		batch := ids(consume(t, b, &messaging.ConsumeRequest{Topic: topic, ConsumerGroup: group, BatchSize: int32(batchSize)}))
+		if len(batch) == 0 {
+			return strings.Join(got, " ")
+		}
+		got = append(got, batch...)
+	}
+}
+
+func TestPriorityStrict(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "agents", &messaging.TopicConfig{PriorityLevels: 3, PriorityPolicy: "strict"})
+		register(t, b, "agents", "workers")
+		publishLane(t, b, "agents", 0, 2, "l")
+		publishLane(t, b, "agents", 2, 2, "h")
+		publishLane(t, b, "agents", 1, 1, "m")
+
+		if got, want := drain(t, b, "agents", "workers", 10), "h1 h2 m1 l1 l2"; got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	})
+}
+
+// TestPriorityWeighted ensures lanes read up to their weight per round, and that a turn cut short
+// by the batch size carries over to the next fetch
+func TestPriorityWeighted(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		want := "h1 h2 h3 l1 h4 h5 h6 l2 h7 h8 l3 l4"
+		for _, batchSize := range []int{4, 2} {
+			b := start(t, cfg)
+			topic := fmt.Sprintf("agents-%d", batchSize)
+			createTopic(t, b, topic, &messaging.TopicConfig{PriorityLevels: 2, PriorityPolicy: "weighted_round_robin", PriorityWeights: []int32{1, 3}})
+			register(t, b, topic, "workers")
+			publishLane(t, b, topic, 0, 4, "l")
+			publishLane(t, b, topic, 1, 8, "h")
+
+			if got := drain(t, b, topic, "workers", batchSize); got != want {
+				t.Fatalf("batches of %d: got %q, want %q", batchSize, got, want)
+			}
+			b.stop()
+		}
+	})
+}
+
+// TestPriorityMLFQ ensures a lane that uses its whole turn drops behind the others
+func TestPriorityMLFQ(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "agents", &messaging.TopicConfig{PriorityLevels: 2, PriorityPolicy: "mlfq"})
+		register(t, b, "agents", "workers")
+		publishLane(t, b, "agents", 1, 40, "h")
+		publishLane(t, b, "agents", 0, 5, "l")
+
+		var want []string
+		for i := 1; i <= 16; i++ {
+			want = append(want, fmt.Sprintf("h%d", i))
+		}
+		want = append(want, "l1", "l2", "l3", "l4", "l5")
+		for i := 17; i <= 40; i++ {
+			want = append(want, fmt.Sprintf("h%d", i))
+		}
+		if got := drain(t, b, "agents", "workers", 100); got != strings.Join(want, " ") {
+			t.Fatalf("got %q, want %q", got, strings.Join(want, " "))
+		}
+	})
+}
+
+// TestPriorityLanePositions ensures each lane of a partition is reported with offsets of its own,
+// and that seeking one lane replays only its messages
+func TestPriorityLanePositions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "agents", &messaging.TopicConfig{Partitions: 2, PriorityLevels: 2, PriorityPolicy: "strict"})
+		register(t, b, "agents", "workers")
+		pinned := int32(1)
+		for i, priority := range []int32{0, 1, 0, 1} {
+			resp := publish(t, b, &messaging.PublishRequest{Topic: "agents", Partition: &pinned, Message: &messaging.Message{Id: fmt.Sprintf("%d", i), Priority: priority}})
+			if resp.GetPartition() != pinned || resp.GetPriority() != priority || resp.GetOffset() != int64(i/2) {
+				t.Fatalf("message %d written to partition %d lane %d offset %d, want partition %d lane %d offset %d",
+					i, resp.GetPartition(), resp.GetPriority(), resp.GetOffset(), pinned, priority, i/2)
+			}
+		}
+		for _, msg := range consume(t, b, &messaging.ConsumeRequest{Topic: "agents", ConsumerGroup: "workers", BatchSize: 10}) {
+			ack(t, b, "agents", "workers", msg)
+		}
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+
+		partitions := listPartitions(t, b, "agents")
+		if len(partitions) != 4 {
+			t.Fatalf("listed %d partitions, want 2 partitions of 2 lanes", len(partitions))
+		}
+		seen := make(map[string]bool)
+		for _, p := range partitions {
+			lane := fmt.Sprintf("%d/%d", p.GetPartition(), p.GetPriority())
+			if seen[lane] || p.GetPartition() > 1 || p.GetPriority() > 1 {
+				t.Fatalf("listed partition %d lane %d unexpectedly", p.GetPartition(), p.GetPriority())
+			}
+			seen[lane] = true
+			want := int64(0)
+			if p.GetPartition() == pinned {
+				want = 2
+			}
+			if p.GetNextOffset() != want {
+				t.Fatalf("partition %d lane %d next offset %d, want %d", p.GetPartition(), p.GetPriority(), p.GetNextOffset(), want)
+			}
+		}
+
+		lane := int32(1)
+		if _, err := b.Seek(context.Background(), &messaging.SeekRequest{Topic: "agents", ConsumerGroup: "workers", Priority: &lane, Position: &messaging.SeekRequest_Offset{Offset: 0}}); err != nil {
+			t.Fatal(err)
+		}
+		if got := drain(t, b, "agents", "workers", 10); got != "1 3" {
+			t.Fatalf("after seeking lane 1 got %q, want %q", got, "1 3")
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"expvar"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/urd"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Retention metrics, published by expvar under /debug/vars and keyed by topic
+var (
+	retentionBytesReclaimed  = expvar.NewMap("retention_bytes_reclaimed")
+	retentionMessagesExpired = expvar.NewMap("retention_messages_expired")
+)
+
+// retentionLoop periodically deletes messages that outlived their topic's retention limits
+func (s *Server) retentionLoop() {
+	defer s.wg.Done()
+	interval := s.cfg.RetentionInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closed:
+			return
+		case now := <-ticker.C:
+			// Holding the read lock keeps DeleteTopic from closing a topic being cleaned
+			s.mu.RLock()
+			for _, t := range s.topics {
+				t.enforceRetention(now)
+				t.forgetPassed()
+			}
+			s.mu.RUnlock()
+		}
+	}
+}
+
+// enforceRetention expires the messages of every partition that are older than the topic's
+// retention time or beyond its retention bytes. Compacted topics are left to the compactor.
+func (t *topic) enforceRetention(now time.Time) {
+	config := t.config()
+	if config.cleanupPolicy != cleanupDelete || (config.retention <= 0 && config.retentionBytes <= 0) {
+		return
+	}
+	for _, p := range t.partitions {
+		expired, reclaimed, err := p.expire(config.retention, config.retentionBytes, now)
+		if err != nil {
+			log.Printf("Retention on topic %s partition %d failed: %v", t.name, p.id, err)
+		}
+		if expired > 0 {
+			retentionMessagesExpired.Add(t.name, expired)
+			retentionBytesReclaimed.Add(t.name, reclaimed)
+			log.Printf("Expired %d messages (%d bytes) from topic %s partition %d", expired, reclaimed, t.name, p.id)
+		}
+	}
+}
+
+// expire deletes the oldest messages of the partition up to the first one published within
+// retention, and further while the partition holds more than retentionBytes. Zero limits are
+// ignored. With a log only whole sealed segments are deleted; in memory messages are dropped one
+// by one. Returns the number of messages expired and the bytes they took up.
+func (p *partition) expire(retention time.Duration, retentionBytes int64, now time.Time) (int64, int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	start := p.startOffset()
+	before := start
+	if retention > 0 {
+		before = p.offsetForTime(now.Add(-retention).UnixMilli())
+	}
+
+	var reclaimed int64
+	if p.log != nil {
+		if retentionBytes > 0 {
+			before = max(before, segmentsOver(p.log.Segments(), retentionBytes))
+		}
+		var err error
+		if reclaimed, err = p.log.DeleteBefore(before); err != nil {
+			return 0, reclaimed, err
+		}
+		before = p.log.StartOffset()
+	} else {
+		end := p.rb.WriteCursor()
+		if retentionBytes > 0 {
+			var size int64
+			for offset := end - 1; offset >= before; offset-- {
+				msg, ok := p.rb.At(offset)
+				if !ok {
+					break
+				}
+				if size += int64(proto.Size(msg)); size > retentionBytes {
+					before = offset + 1
+					break
+				}
+			}
+		}
+		for offset := start; offset < before; offset++ {
+			if msg, ok := p.rb.At(offset); ok {
+				reclaimed += int64(proto.Size(msg))
+			}
+		}
+		p.floor = max(p.floor, before)
+	}
+	if before <= start {
+		return 0, reclaimed, nil
+	}
+
+	p.rb.Expire(before)
+	p.times.trim(before)
+	p.txns.prune(before)
+	p.strandCursors(before)
+	return before - start, reclaimed, nil
+}
+
+// segmentsOver returns the offset before which the oldest segments must go for the rest to fit in
+// limit bytes; the active segment always stays
+func segmentsOver(segments []urd.SegmentInfo, limit int64) int64 {
+	var total int64
+	for _, s := range segments {
+		total += s.Size
+	}
+	before := int64(0)
+	for _, s := range segments[:len(segments)-1] {
+		if total <= limit {
+			break
+		}
+		total -= s.Size
+		before = s.NextOffset
+	}
+	return before
+}
+
+// strandCursors moves cursors whose next message expired off the ring buffer, so they stop holding
+// back producers. They stay at their offset, out of range until sought, rather than silently
+// skipping ahead. The caller holds p.mu.
+func (p *partition) strandCursors(start int64) {
+	p.cursorMu.Lock()
+	defer p.cursorMu.Unlock()
+
+	last := p.rb.WriteCursor() - 1
+	for cursor, seq := range p.rb.ReadCursors() {
+		if _, replaying := p.replays[cursor]; replaying || seq+1 >= start {
+			continue
+		}
+		p.replays[cursor] = seq + 1
+		p.rb.AdvanceReadCursor(cursor, last)
+		log.Printf("Consumer %s on partition %d is at expired offset %d; the earliest available is %d", cursor, p.id, seq+1, start)
+	}
+}
+
+// checkRange returns an OutOfRange status if the next message of a cursor has expired
+func (p *partition) checkRange(cursor string) error {
+	p.cursorMu.Lock()
+	next, replaying := p.replays[cursor]
+	p.cursorMu.Unlock()
+	if !replaying {
+		return nil // Cursors are moved off the ring buffer before their messages expire
+	}
+	if start := p.startOffset(); next < start {
+		return errOutOfRange(cursor, p, next, start)
+	}
+	return nil
+}
+
+// errOutOfRange reports a consumer positioned before the earliest message a partition holds, with
+// the offsets in the status details so clients can seek to the earliest one
+func errOutOfRange(cursor string, p *partition, offset, earliest int64) error {
+	st := status.Newf(codes.OutOfRange, "consumer %s is at offset %d of partition %d, which has expired; the earliest available offset is %d", cursor, offset, p.index, earliest)
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: "OFFSET_OUT_OF_RANGE",
+		Domain: "kafkaesque",
+		Metadata: map[string]string{
+			"partition":       strconv.Itoa(p.index),
+			"priority":        strconv.Itoa(p.lane),
+			"offset":          strconv.FormatInt(offset, 10),
+			"earliest_offset": strconv.FormatInt(earliest, 10),
+		},
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
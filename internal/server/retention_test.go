@@ -0,0 +1,93 @@
+package server_test
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// bytesReclaimed reads the retention_bytes_reclaimed counter of a topic
+func bytesReclaimed(topic string) int64 {
+	reclaimed, _ := expvar.Get("retention_bytes_reclaimed").(*expvar.Map).Get(topic).(*expvar.Int)
+	if reclaimed == nil {
+		return 0
+	}
+	return reclaimed.Value()
+}
+
+// earliestOffset checks err is an OutOfRange status and returns the earliest offset in its details
+func earliestOffset(t *testing.T, err error) int64 {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != codes.OutOfRange {
+		t.Fatalf("got %v, want OutOfRange", err)
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			earliest, err := strconv.ParseInt(info.GetMetadata()["earliest_offset"], 10, 64)
+			if err != nil {
+				t.Fatalf("earliest_offset in %v: %v", info, err)
+			}
+			return earliest
+		}
+	}
+	t.Fatalf("OutOfRange status %v has no ErrorInfo details", st)
+	return 0
+}
+
+// TestRetentionOutOfRange ensures retention reclaims the oldest messages, counting the bytes, and a
+// consumer group left behind, or seeking back, gets OutOfRange with the earliest offset, until it
+// seeks to that offset
+func TestRetentionOutOfRange(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		cfg.RetentionInterval = 20 * time.Millisecond
+		cfg.Log.SegmentBytes = 256
+		b := start(t, cfg)
+		topic := "metrics"
+		createTopic(t, b, topic, &messaging.TopicConfig{RetentionBytes: 300})
+		register(t, b, topic, "reports")
+		reclaimed := bytesReclaimed(topic)
+		for i := 0; i < 20; i++ {
+			publish(t, b, &messaging.PublishRequest{Topic: topic, Message: &messaging.Message{Id: fmt.Sprintf("sample-%d", i), Payload: make([]byte, 64)}})
+		}
+		for deadline := time.Now().Add(2 * time.Second); bytesReclaimed(topic) == reclaimed; time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("retention reclaimed no bytes")
+			}
+		}
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+
+		req := &messaging.ConsumeRequest{Topic: topic, ConsumerGroup: "reports", BatchSize: 100}
+		_, err := b.Consume(context.Background(), req)
+		earliest := earliestOffset(t, err)
+		if earliest <= 0 || earliest >= 20 {
+			t.Fatalf("earliest offset %d, want some but not all samples expired", earliest)
+		}
+		_, err = b.Seek(context.Background(), &messaging.SeekRequest{Topic: topic, ConsumerGroup: "reports", Position: &messaging.SeekRequest_Offset{Offset: 0}})
+		if got := earliestOffset(t, err); got < earliest {
+			t.Fatalf("seeking to an expired offset reported earliest offset %d, want at least %d", got, earliest)
+		}
+
+		resp, err := b.Seek(context.Background(), &messaging.SeekRequest{Topic: topic, ConsumerGroup: "reports", Position: &messaging.SeekRequest_Timestamp{Timestamp: 0}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resumed := resp.GetPositions()[0].GetOffset()
+		got := consume(t, b, req)
+		if len(got) == 0 || got[0].GetId() != fmt.Sprintf("sample-%d", resumed) || got[len(got)-1].GetId() != "sample-19" {
+			t.Fatalf("after seeking to offset %d got %v, want every sample from there", resumed, ids(got))
+		}
+	})
+}
@@ -0,0 +1,362 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/draupnir"
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/kronos"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+const (
+	scheduledFile       = "scheduled.pb"
+	maxScheduledPerPass = 256                    // Scheduled messages taken off a topic's queue at a time
+	scheduleRetryDelay  = 1 * time.Second        // Wait before retrying a scheduled message its partition had no room for
+	scheduleWorkers     = 4                      // Topics delivering scheduled messages at once
+	scheduleSlice       = 100 * time.Millisecond // Time a topic delivers for before the others get a turn
+)
+
+// scheduledMessages holds the messages of a topic waiting for their delivery time. Every change
+// is appended to a journal, which is rewritten without the delivered and cancelled messages once
+// they make up most of it.
+type scheduledMessages struct {
+	queue      *kronos.DelayQueue[*messaging.ScheduledMessage]
+	delivering atomic.Bool // Whether the topic has a delivery task in the server's pool
+
+	mu       sync.Mutex // Serializes journal writes
+	path     string     // Empty keeps scheduled messages in memory only
+	journal  *os.File
+	removed  int                                    // Removal entries in the journal
+	inflight map[string]*messaging.ScheduledMessage // Taken off the queue but not yet delivered
+}
+
+// openScheduledMessages loads the scheduled messages journaled at path, if any
+func openScheduledMessages(path string) (*scheduledMessages, error) {
+	sm := &scheduledMessages{
+		queue:    kronos.NewDelayQueue[*messaging.ScheduledMessage](),
+		path:     path,
+		inflight: make(map[string]*messaging.ScheduledMessage),
+	}
+	if path == "" {
+		return sm, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		defer f.Close()
+		r := bufio.NewReader(f)
+		for {
+			entry := &messaging.ScheduledMessage{}
+			if err := protodelim.UnmarshalFrom(r, entry); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("reading scheduled messages: %w", err)
+			}
+			id := entry.GetMessage().GetId()
+			if entry.GetRemoved() {
+				sm.queue.Cancel(id)
+				continue
+			}
+			sm.queue.Add(id, time.UnixMilli(entry.GetDeliverAt()), entry)
+		}
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if err := sm.rewrite(); err != nil {
+		return nil, fmt.Errorf("rewriting scheduled messages: %w", err)
+	}
+	return sm, nil
+}
+
+// schedule queues a message for delivery at its time, failing if one with the same ID is queued
+func (sm *scheduledMessages) schedule(entry *messaging.ScheduledMessage) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	id := entry.GetMessage().GetId()
+	if _, delivering := sm.inflight[id]; delivering || !sm.queue.Add(id, time.UnixMilli(entry.GetDeliverAt()), entry) {
+		return status.Errorf(codes.AlreadyExists, "a message with ID %q is already scheduled", id)
+	}
+	if err := sm.write(entry); err != nil {
+		sm.queue.Cancel(id)
+		return status.Errorf(codes.Internal, "journaling scheduled message: %v", err)
+	}
+	return nil
+}
+
+// cancel removes a scheduled message before its delivery time, returning false if none is queued
+// under id
+func (sm *scheduledMessages) cancel(id string) (bool, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if _, ok := sm.queue.Cancel(id); !ok {
+		return false, nil
+	}
+	return true, sm.remove(id)
+}
+
+// due takes the messages whose delivery time has come off the queue. They stay in the journal until
+// delivered, so they are not lost if the broker stops in between.
+func (sm *scheduledMessages) due(now time.Time) []*messaging.ScheduledMessage {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	due := sm.queue.PopDue(now, maxScheduledPerPass)
+	for _, entry := range due {
+		sm.inflight[entry.GetMessage().GetId()] = entry
+	}
+	return due
+}
+
+// delivered journals that a message taken by due has been published
+func (sm *scheduledMessages) delivered(id string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	delete(sm.inflight, id)
+	return sm.remove(id)
+}
+
+// retry puts back a message taken by due that could not be published yet
+func (sm *scheduledMessages) retry(entry *messaging.ScheduledMessage, at time.Time) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	delete(sm.inflight, entry.GetMessage().GetId())
+	sm.queue.Add(entry.GetMessage().GetId(), at, entry)
+}
+
+// remove journals that a message left the queue, compacting the journal once removals outnumber
+// the messages still queued; the caller holds sm.mu
+func (sm *scheduledMessages) remove(id string) error {
+	if sm.path == "" {
+		return nil
+	}
+	sm.removed++
+	if sm.removed > sm.queue.Len()+len(sm.inflight) {
+		return sm.rewrite()
+	}
+	return sm.write(&messaging.ScheduledMessage{Message: &messaging.Message{Id: id}, Removed: true})
+}
+
+// write appends an entry to the journal; the caller holds sm.mu
+func (sm *scheduledMessages) write(entry *messaging.ScheduledMessage) error {
+	if sm.path == "" {
+		return nil
+	}
+	_, err := protodelim.MarshalTo(sm.journal, entry)
+	return err
+}
+
+// rewrite replaces the journal with the messages still to deliver; the caller holds sm.mu
+func (sm *scheduledMessages) rewrite() error {
+	if sm.path == "" {
+		return nil
+	}
+	var buf bytes.Buffer
+	pending := sm.queue.Values()
+	for _, entry := range sm.inflight {
+		pending = append(pending, entry)
+	}
+	for _, entry := range pending {
+		if _, err := protodelim.MarshalTo(&buf, entry); err != nil {
+			return err
+		}
+	}
+	if sm.journal != nil {
+		sm.journal.Close()
+		sm.journal = nil
+	}
+	if err := writeFileAtomic(sm.path, buf.Bytes()); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(sm.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	sm.journal = f
+	sm.removed = 0
+	return nil
+}
+
+func (sm *scheduledMessages) close() error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.journal == nil {
+		return nil
+	}
+	err := sm.journal.Close()
+	sm.journal = nil
+	return err
+}
+
+// scheduledPath returns where a topic journals its scheduled messages
+func scheduledPath(cfg Config, topic string) string {
+	if cfg.DataDir == "" {
+		return ""
+	}
+	return filepath.Join(cfg.DataDir, topic, scheduledFile)
+}
+
+// deliverAt returns when a publish asks for its message to be delivered, or the zero time for now
+func deliverAt(req *messaging.PublishRequest, now time.Time) (time.Time, error) {
+	switch {
+	case req.GetDeliverAt() < 0 || req.GetDelayMs() < 0:
+		return time.Time{}, status.Error(codes.InvalidArgument, "deliver_at and delay_ms must not be negative")
+	case req.GetDeliverAt() > 0 && req.GetDelayMs() > 0:
+		return time.Time{}, status.Error(codes.InvalidArgument, "set deliver_at or delay_ms, not both")
+	case req.GetDelayMs() > 0:
+		return now.Add(time.Duration(req.GetDelayMs()) * time.Millisecond), nil
+	case req.GetDeliverAt() > now.UnixMilli():
+		return time.UnixMilli(req.GetDeliverAt()), nil
+	}
+	return time.Time{}, nil // Due already
+}
+
+// CancelScheduled cancels a scheduled message before its delivery time
+func (s *Server) CancelScheduled(ctx context.Context, req *messaging.CancelScheduledRequest) (*messaging.CancelScheduledResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	cancelled, err := t.scheduled.cancel(req.GetMessageId())
+	if err != nil {
+		log.Printf("Failed to journal cancellation of scheduled message %s on topic %s: %v", req.GetMessageId(), t.name, err)
+	}
+	if !cancelled {
+		return nil, status.Errorf(codes.NotFound, "no message with ID %q is scheduled on topic %q", req.GetMessageId(), t.name)
+	}
+	log.Printf("Cancelled scheduled message with ID: %s on topic: %s", req.GetMessageId(), t.name)
+	return &messaging.CancelScheduledResponse{Success: true}, nil
+}
+
+// scheduleLoop publishes scheduled messages once their delivery time comes
+func (s *Server) scheduleLoop() {
+	defer s.wg.Done()
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		// Sleep until the earliest delivery time of a topic not delivering already, or until a
+		// publish schedules an earlier one or a topic finishes delivering
+		wait := time.Hour
+		s.mu.RLock()
+		for _, t := range s.topics {
+			if next, ok := t.scheduled.queue.Next(); ok && !t.scheduled.delivering.Load() {
+				wait = min(wait, time.Until(next))
+			}
+		}
+		s.mu.RUnlock()
+		timer.Reset(max(wait, 0))
+
+		select {
+		case <-s.closed:
+			return
+		case <-s.rescheduled:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			continue
+		case <-timer.C:
+		}
+
+		// Publishing may wait on back-pressure, so each topic delivers in a task of its own, where
+		// a full partition holds up only that topic
+		now := time.Now()
+		s.mu.RLock()
+		for _, t := range s.topics {
+			if next, ok := t.scheduled.queue.Next(); ok && !next.After(now) && t.scheduled.delivering.CompareAndSwap(false, true) {
+				if err := s.deliveries.Submit(&kronos.Task{ID: t.name, Work: s.deliveryTask(t)}); err != nil {
+					t.scheduled.delivering.Store(false)
+				}
+			}
+		}
+		s.mu.RUnlock()
+	}
+}
+
+// deliveryTask returns the work of delivering a topic's due messages, which yields to the other
+// topics after every slice and finishes once none is due
+func (s *Server) deliveryTask(t *topic) kronos.Work {
+	return func(ctx context.Context, slice time.Duration) bool {
+		start := time.Now()
+		full := make(map[*partition]bool)
+		for {
+			due := t.scheduled.due(time.Now())
+			if len(due) == 0 {
+				t.scheduled.delivering.Store(false)
+				s.wakeScheduler() // Messages may have fallen due since the last look
+				return true
+			}
+			for _, entry := range due {
+				s.deliverScheduled(t, entry, full)
+			}
+			if slice > 0 && time.Since(start) >= slice {
+				return false
+			}
+		}
+	}
+}
+
+// wakeScheduler has scheduleLoop recompute when the next scheduled message is due
+func (s *Server) wakeScheduler() {
+	select {
+	case s.rescheduled <- struct{}{}:
+	default:
+	}
+}
+
+// deliverScheduled publishes a scheduled message whose time has come. When its partition has no
+// room it is retried later, as are the messages for partitions in full, to which it is added; a
+// message published again after a restart that came between publishing and journaling it may be
+// delivered twice.
+func (s *Server) deliverScheduled(t *topic, entry *messaging.ScheduledMessage, full map[*partition]bool) {
+	if err := t.beginPublish(); err != nil {
+		return // The topic is being deleted, scheduled messages and all
+	}
+	defer t.endPublish()
+
+	msg := entry.GetMessage()
+	p, err := t.route(msg, int(entry.GetPartition()))
+	if err != nil {
+		p, _ = t.route(msg, -1) // Partitions are fixed, so a pin checked when scheduling stays valid
+	}
+	if full[p] {
+		t.scheduled.retry(entry, time.Now().Add(scheduleRetryDelay))
+		return
+	}
+	results, err := p.append(msg)
+	if err == nil {
+		err = results[0].err
+	}
+	if errors.Is(err, draupnir.ErrFull) || errors.Is(err, draupnir.ErrDropped) || errors.Is(err, draupnir.ErrTimeout) {
+		full[p] = true
+		log.Printf("No room for scheduled message with ID: %s on topic: %s partition: %d; retrying in %v", msg.GetId(), t.name, p.index, scheduleRetryDelay)
+		t.scheduled.retry(entry, time.Now().Add(scheduleRetryDelay))
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to deliver scheduled message with ID: %s on topic: %s: %v", msg.GetId(), t.name, err)
+	} else {
+		log.Printf("Delivered scheduled message with ID: %s to topic: %s partition: %d offset: %d", msg.GetId(), t.name, p.index, results[0].offset)
+	}
+	if err := t.scheduled.delivered(msg.GetId()); err != nil {
+		log.Printf("Failed to journal delivery of scheduled message %s on topic %s: %v", msg.GetId(), t.name, err)
+	}
+}
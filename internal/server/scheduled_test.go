@@ -0,0 +1,128 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func schedule(t *testing.T, b *broker, topic, id string, delay time.Duration) {
+	t.Helper()
+	resp := publish(t, b, &messaging.PublishRequest{Topic: topic, Message: &messaging.Message{Id: id}, DelayMs: delay.Milliseconds()})
+	if !resp.GetScheduled() {
+		t.Fatalf("message %s was published at once instead of scheduled", id)
+	}
+}
+
+// await long-polls a consumer group for up to wait, returning the IDs it was sent
+func await(t *testing.T, b *broker, topic, group string, wait time.Duration) []string {
+	t.Helper()
+	return ids(consume(t, b, &messaging.ConsumeRequest{Topic: topic, ConsumerGroup: group, BatchSize: 10, MaxWaitMs: wait.Milliseconds()}))
+}
+
+func TestScheduledDelivery(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "reminders", nil)
+		register(t, b, "reminders", "mailer")
+
+		schedule(t, b, "reminders", "reminder-1", 100*time.Millisecond)
+		if got := await(t, b, "reminders", "mailer", 0); len(got) != 0 {
+			t.Fatalf("got %v before the delivery time", got)
+		}
+		if got := await(t, b, "reminders", "mailer", 2*time.Second); len(got) != 1 || got[0] != "reminder-1" {
+			t.Fatalf("got %v, want [reminder-1]", got)
+		}
+	})
+}
+
+func TestCancelScheduled(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "reminders", nil)
+		register(t, b, "reminders", "mailer")
+
+		schedule(t, b, "reminders", "reminder-1", 200*time.Millisecond)
+		if _, err := b.CancelScheduled(context.Background(), &messaging.CancelScheduledRequest{Topic: "reminders", MessageId: "reminder-1"}); err != nil {
+			t.Fatal(err)
+		}
+		_, err := b.CancelScheduled(context.Background(), &messaging.CancelScheduledRequest{Topic: "reminders", MessageId: "reminder-1"})
+		if status.Code(err) != codes.NotFound {
+			t.Fatalf("cancelling twice: got %v, want NotFound", err)
+		}
+		if got := await(t, b, "reminders", "mailer", 500*time.Millisecond); len(got) != 0 {
+			t.Fatalf("got cancelled messages %v", got)
+		}
+	})
+}
+
+// TestScheduledSurvivesRestart ensures scheduled messages are journaled, and those cancelled stay
+// cancelled
+func TestScheduledSurvivesRestart(t *testing.T) {
+	cfg := server.DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	b := start(t, cfg)
+	createTopic(t, b, "reminders", nil)
+	register(t, b, "reminders", "mailer")
+	schedule(t, b, "reminders", "reminder-1", 300*time.Millisecond)
+	schedule(t, b, "reminders", "reminder-2", 300*time.Millisecond)
+	if _, err := b.CancelScheduled(context.Background(), &messaging.CancelScheduledRequest{Topic: "reminders", MessageId: "reminder-2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	b = b.restart(t, cfg)
+	if got := await(t, b, "reminders", "mailer", 2*time.Second); len(got) != 1 || got[0] != "reminder-1" {
+		t.Fatalf("got %v after restart, want [reminder-1]", got)
+	}
+	if got := await(t, b, "reminders", "mailer", 500*time.Millisecond); len(got) != 0 {
+		t.Fatalf("got %v more", got)
+	}
+}
+
+// TestScheduledRetryAfterDrop ensures a scheduled message dropped by a full partition is delivered
+// once there is room, rather than lost
+func TestScheduledRetryAfterDrop(t *testing.T) {
+	cfg := server.DefaultConfig()
+	cfg.BufferSize = 2
+	b := start(t, cfg)
+	createTopic(t, b, "reminders", &messaging.TopicConfig{OverflowPolicy: "drop_newest"})
+	register(t, b, "reminders", "mailer")
+	publish(t, b, &messaging.PublishRequest{Topic: "reminders", Message: &messaging.Message{Id: "a"}})
+	publish(t, b, &messaging.PublishRequest{Topic: "reminders", Message: &messaging.Message{Id: "b"}})
+
+	schedule(t, b, "reminders", "reminder-1", 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond) // Due, and dropped
+	for _, msg := range consume(t, b, &messaging.ConsumeRequest{Topic: "reminders", ConsumerGroup: "mailer", BatchSize: 10}) {
+		ack(t, b, "reminders", "mailer", msg)
+	}
+	if got := await(t, b, "reminders", "mailer", 3*time.Second); len(got) != 1 || got[0] != "reminder-1" {
+		t.Fatalf("got %v, want the dropped reminder-1 retried", got)
+	}
+}
+
+// TestScheduledNotHeldUpByFullTopic ensures a topic waiting for room does not delay the scheduled
+// messages of other topics
+func TestScheduledNotHeldUpByFullTopic(t *testing.T) {
+	cfg := server.DefaultConfig()
+	cfg.BufferSize = 2
+	b := start(t, cfg)
+	createTopic(t, b, "full", &messaging.TopicConfig{OverflowPolicy: "block", OverflowTimeoutMs: 2000})
+	register(t, b, "full", "stuck")
+	publish(t, b, &messaging.PublishRequest{Topic: "full", Message: &messaging.Message{Id: "a"}})
+	publish(t, b, &messaging.PublishRequest{Topic: "full", Message: &messaging.Message{Id: "b"}})
+	createTopic(t, b, "reminders", nil)
+	register(t, b, "reminders", "mailer")
+
+	schedule(t, b, "full", "blocked", 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond) // Waiting for room
+	schedule(t, b, "reminders", "reminder-1", 10*time.Millisecond)
+	if got := await(t, b, "reminders", "mailer", time.Second); len(got) != 1 || got[0] != "reminder-1" {
+		t.Fatalf("got %v, want reminder-1 while topic full waits", got)
+	}
+}
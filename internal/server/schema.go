@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/midas/schemavalidator"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const schemasFile = "schemas.json"
+
+// Schema registry types mapped to the formats the validator understands
+var schemaFormats = map[string]string{
+	"json":     "json",
+	"avro":     "avro",
+	"protobuf": "proto",
+	"proto":    "proto",
+}
+
+// schemaCatalog numbers schema versions per name on top of the schema registry and keeps the
+// registered schemas on disk so topics can keep validating after a restart
+type schemaCatalog struct {
+	mu        sync.Mutex
+	path      string // Empty keeps schemas in memory only
+	registry  *schemavalidator.SchemaRegistry
+	validator *schemavalidator.SchemaValidator
+	latest    map[string]int
+	schemas   []*schemavalidator.Schema
+}
+
+// openSchemaCatalog loads the schemas stored at path, if any
+func openSchemaCatalog(path string) (*schemaCatalog, error) {
+	registry := schemavalidator.NewSchemaRegistry()
+	c := &schemaCatalog{
+		path:      path,
+		registry:  registry,
+		validator: schemavalidator.NewSchemaValidator(registry),
+		latest:    make(map[string]int),
+	}
+	if path == "" {
+		return c, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored []*schemavalidator.Schema
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, fmt.Errorf("reading schemas: %w", err)
+	}
+	for _, schema := range stored {
+		if err := c.add(schema); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// register adds the next version of a named schema
+func (c *schemaCatalog) register(name, format, content string) (*schemavalidator.Schema, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	schema := &schemavalidator.Schema{Name: name, Version: strconv.Itoa(c.latest[name] + 1), Format: format, Content: content}
+	if err := c.add(schema); err != nil {
+		return nil, err
+	}
+	if err := c.save(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// add registers a schema with the registry and tracks its version; the caller holds c.mu
+func (c *schemaCatalog) add(schema *schemavalidator.Schema) error {
+	id, err := c.registry.RegisterSchema(schema.ID, schema.Name, schema.Version, schema.Format, schema.Content)
+	if err != nil {
+		return err
+	}
+	schema.ID = id
+	if version, err := strconv.Atoi(schema.Version); err == nil && version > c.latest[schema.Name] {
+		c.latest[schema.Name] = version
+	}
+	c.schemas = append(c.schemas, schema)
+	return nil
+}
+
+func (c *schemaCatalog) save() error {
+	if c.path == "" {
+		return nil
+	}
+	raw, err := json.Marshal(c.schemas)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, raw)
+}
+
+// lookup finds a schema by name and version, defaulting to the latest version
+func (c *schemaCatalog) lookup(name, version string) (*schemavalidator.Schema, error) {
+	if version == "" {
+		c.mu.Lock()
+		latest, exists := c.latest[name]
+		c.mu.Unlock()
+		if !exists {
+			return nil, fmt.Errorf("schema %q is not registered", name)
+		}
+		version = strconv.Itoa(latest)
+	}
+	schema, err := c.registry.GetSchema(name, version)
+	if err != nil {
+		return nil, fmt.Errorf("schema %q version %s: %w", name, version, err)
+	}
+	return schema, nil
+}
+
+// validate checks a JSON payload against a schema
+func (c *schemaCatalog) validate(schema *schemavalidator.Schema, payload []byte) error {
+	var data map[string]interface{}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return fmt.Errorf("payload is not a JSON object: %v", err)
+	}
+	return c.validator.Validate(schema.ID, "", data)
+}
+
+// Schema Registry Service: Register a schema, assigning it the next version under its name
+func (s *Server) Register(ctx context.Context, req *messaging.RegisterSchemaRequest) (*messaging.RegisterSchemaResponse, error) {
+	format, ok := schemaFormats[req.GetType()]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported schema type %q", req.GetType())
+	}
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "schema name is required")
+	}
+	if !json.Valid([]byte(req.GetSchema())) {
+		return nil, status.Error(codes.InvalidArgument, "schema must be a JSON document")
+	}
+
+	schema, err := s.schemas.register(req.GetName(), format, req.GetSchema())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "registering schema %q: %v", req.GetName(), err)
+	}
+	version, _ := strconv.Atoi(schema.Version)
+	log.Printf("Registered schema: %s version %d", schema.Name, version)
+	return &messaging.RegisterSchemaResponse{Id: schema.ID, Version: int32(version)}, nil
+}
+
+// schemasPath returns where registered schemas are kept, or "" when running in memory
+func schemasPath(cfg Config) string {
+	if cfg.DataDir == "" {
+		return ""
+	}
+	return filepath.Join(cfg.DataDir, schemasFile)
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// read returns up to n messages after a cursor, none at or after limit, and the offset of the
+// first: from the log while the cursor is replaying, otherwise from the ring buffer
+func (p *partition) read(cursor string, n int, limit int64) ([]*messaging.Message, int64) {
+	p.cursorMu.Lock()
+	next, replaying := p.replays[cursor]
+	p.cursorMu.Unlock()
+	if !replaying {
+		next = p.rb.ReadCursor(cursor) + 1
+	}
+	if limit-next < int64(n) {
+		n = int(max(limit-next, 0))
+	}
+	if n == 0 {
+		return nil, next
+	}
+	if !replaying {
+		return p.rb.Read(n, cursor)
+	}
+	if p.log == nil || next < p.startOffset() {
+		return nil, next // Expired by retention; the cursor is out of range until sought
+	}
+
+	var batch []*messaging.Message
+	first := next
+	err := p.log.ReadFrom(next, func(offset int64, record []byte) bool {
+		if offset != first+int64(len(batch)) {
+			// Compaction left a gap: skip it, but only at the start so the batch stays contiguous
+			if len(batch) > 0 {
+				return false
+			}
+			first = offset
+		}
+		msg := &messaging.Message{}
+		if err := proto.Unmarshal(record, msg); err != nil {
+			log.Printf("Skipping unreadable message at offset %d on partition %d: %v", offset, p.id, err)
+			if len(batch) == 0 {
+				first = offset + 1
+				return true
+			}
+			return false // Keep the batch contiguous; the next read skips it
+		}
+		batch = append(batch, msg)
+		return len(batch) < n
+	})
+	if err != nil {
+		log.Printf("Failed to replay partition %d from offset %d: %v", p.id, next, err)
+		return nil, next
+	}
+
+	p.cursorMu.Lock()
+	defer p.cursorMu.Unlock()
+	if len(batch) > 0 || first > next {
+		p.replays[cursor] = first + int64(len(batch))
+		return batch, first
+	}
+	// Caught up with the log. Everything after it is either in the ring buffer, which has been
+	// keeping the cursor at its write cursor, or still being appended.
+	delete(p.replays, cursor)
+	p.rb.SetReadCursor(cursor, next-1)
+	return p.rb.Read(n, cursor)
+}
+
+// readPositions returns the last offset read through every cursor, including those replaying
+func (p *partition) readPositions() map[string]int64 {
+	positions := p.rb.ReadCursors()
+	p.cursorMu.Lock()
+	defer p.cursorMu.Unlock()
+	for cursor, next := range p.replays {
+		if _, ok := positions[cursor]; ok {
+			positions[cursor] = next - 1
+		}
+	}
+	return positions
+}
+
+// offsetForTime returns the offset of the first message published at or after timestamp, or the
+// next offset to be written if there is none yet; the caller holds p.mu
+func (p *partition) offsetForTime(timestamp int64) int64 {
+	from := p.times.lookup(timestamp)
+	if start := p.startOffset(); from < start {
+		from = start
+	}
+
+	found := p.rb.WriteCursor()
+	match := func(offset int64, msg *messaging.Message) bool {
+		if msg.GetTimestamp() >= timestamp {
+			found = offset
+			return false
+		}
+		return true
+	}
+
+	if p.log == nil {
+		for offset := from; offset < p.rb.WriteCursor(); offset++ {
+			msg, ok := p.rb.At(offset)
+			if ok && !match(offset, msg) {
+				break
+			}
+		}
+		return found
+	}
+	err := p.log.ReadFrom(from, func(offset int64, record []byte) bool {
+		msg := &messaging.Message{}
+		if err := proto.Unmarshal(record, msg); err != nil {
+			return true
+		}
+		return match(offset, msg)
+	})
+	if err != nil {
+		log.Printf("Failed to scan partition %d for time %d: %v", p.id, timestamp, err)
+	}
+	return found
+}
+
+// seekCursor moves a cursor so the next message it reads is the one at offset, clamped to the
+// messages the partition holds, and returns that offset. Messages older than the ring buffer holds
+// are replayed from the log. The caller holds p.mu.
+func (p *partition) seekCursor(cursor string, offset int64) int64 {
+	if start := p.startOffset(); offset < start {
+		offset = start
+	}
+	if end := p.rb.WriteCursor(); offset > end {
+		offset = end
+	}
+	p.recallAborted(offset)
+
+	p.cursorMu.Lock()
+	defer p.cursorMu.Unlock()
+	if p.rb.Seek(cursor, offset-1) {
+		delete(p.replays, cursor)
+		return offset
+	}
+	if p.log == nil {
+		// The oldest messages went while the lock was being taken; start from what is left
+		offset = p.startOffset()
+		p.rb.Seek(cursor, offset-1)
+		delete(p.replays, cursor)
+		return offset
+	}
+	p.rb.Seek(cursor, p.rb.WriteCursor()-1)
+	p.replays[cursor] = offset
+	return offset
+}
+
+// seek moves a consumer group on one partition to offset, or when byTime is set to the first
+// message published at or after timestamp, and returns the offset it resumes from. On broadcast
+// topics every member moves with the group. The group's unacknowledged deliveries from the
+// partition are dropped.
+func (t *topic) seek(p *partition, group string, offset, timestamp int64, byTime bool) int64 {
+	cursors := []string{group}
+	if t.strategy == strategyBroadcast {
+		for _, cursor := range p.rb.Consumers() {
+			if strings.HasPrefix(cursor, group+"/") {
+				cursors = append(cursors, cursor)
+			}
+		}
+	}
+
+	// Lock every lease table first so no fetch hands out messages from the old position
+	tables := make([]*leaseTable, len(cursors))
+	for i, cursor := range cursors {
+		tables[i] = t.leaseTable(cursor)
+		tables[i].mu.Lock()
+		defer tables[i].mu.Unlock()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if byTime {
+		offset = p.offsetForTime(timestamp)
+	}
+	for i, cursor := range cursors {
+		tables[i].forget(p)
+		offset = p.seekCursor(cursor, offset)
+	}
+	return offset
+}
+
+// Seek rewinds or fast-forwards a consumer group to an offset or a point in time, on one
+// partition or all of them. Seeking to an offset that has expired fails with OutOfRange.
+func (s *Server) Seek(ctx context.Context, req *messaging.SeekRequest) (*messaging.SeekResponse, error) {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.cursorFor(req.GetConsumerGroup(), ""); err != nil {
+		return nil, err
+	}
+
+	var offset, timestamp int64
+	byTime := false
+	switch pos := req.GetPosition().(type) {
+	case *messaging.SeekRequest_Offset:
+		offset = pos.Offset
+	case *messaging.SeekRequest_Timestamp:
+		timestamp, byTime = pos.Timestamp, true
+	default:
+		return nil, status.Error(codes.InvalidArgument, "an offset or timestamp to seek to is required")
+	}
+
+	partitions := t.partitions
+	if req.Partition != nil {
+		if partitions, err = t.lanes(int(req.GetPartition())); err != nil {
+			return nil, err
+		}
+	}
+	if req.Priority != nil {
+		priority := int(req.GetPriority())
+		if priority < 0 || priority >= t.levels {
+			return nil, status.Errorf(codes.InvalidArgument, "topic %q has no priority level %d", t.name, priority)
+		}
+		var lanes []*partition
+		for _, p := range partitions {
+			if p.lane == priority {
+				lanes = append(lanes, p)
+			}
+		}
+		partitions = lanes
+	}
+
+	// An offset retention already deleted is refused, as a consume from there would be
+	if !byTime {
+		for _, p := range partitions {
+			p.mu.Lock()
+			start := p.startOffset()
+			p.mu.Unlock()
+			if offset < start {
+				return nil, errOutOfRange(req.GetConsumerGroup(), p, offset, start)
+			}
+		}
+	}
+
+	positions := make([]*messaging.SeekPosition, 0, len(partitions))
+	for _, p := range partitions {
+		resumed := t.seek(p, req.GetConsumerGroup(), offset, timestamp, byTime)
+		positions = append(positions, &messaging.SeekPosition{Partition: int32(p.index), Priority: int32(p.lane), Offset: resumed})
+		log.Printf("Consumer group %s on topic %s partition %d lane %d resumes at offset %d", req.GetConsumerGroup(), t.name, p.index, p.lane, resumed)
+	}
+	if err := t.checkpoint(); err != nil {
+		log.Printf("Failed to checkpoint topic %s after seek: %v", t.name, err)
+	}
+	return &messaging.SeekResponse{Success: true, Positions: positions}, nil
+}
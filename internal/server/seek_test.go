@@ -0,0 +1,89 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+)
+
+// seekTime seeks a consumer group to a point in time and returns where its only partition resumes
+func seekTime(t *testing.T, b *broker, topic, group string, timestamp int64) int64 {
+	t.Helper()
+	resp, err := b.Seek(context.Background(), &messaging.SeekRequest{Topic: topic, ConsumerGroup: group, Position: &messaging.SeekRequest_Timestamp{Timestamp: timestamp}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetSuccess() || len(resp.GetPositions()) != 1 {
+		t.Fatalf("seeking to %d: %s %v", timestamp, resp.GetError(), resp.GetPositions())
+	}
+	return resp.GetPositions()[0].GetOffset()
+}
+
+// TestSeekTimestamp ensures seeking to a time resumes at the first message published at or after
+// it, before and after a restart rebuilds the time index, and that the broker sets the publish time
+func TestSeekTimestamp(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "events", nil)
+		register(t, b, "events", "replay")
+		before := time.Now().UnixMilli()
+		for i := 0; i < 3; i++ {
+			publish(t, b, &messaging.PublishRequest{Topic: "events", Message: &messaging.Message{Id: fmt.Sprintf("event-%d", i), Timestamp: 1}})
+			time.Sleep(20 * time.Millisecond)
+		}
+		req := &messaging.ConsumeRequest{Topic: "events", ConsumerGroup: "replay", BatchSize: 10}
+		readAll := func() []string {
+			msgs := consume(t, b, req)
+			for _, msg := range msgs {
+				ack(t, b, "events", "replay", msg)
+			}
+			return ids(msgs)
+		}
+
+		published := consume(t, b, req)
+		if len(published) != 3 {
+			t.Fatalf("got %v, want three events", ids(published))
+		}
+		for _, msg := range published {
+			ack(t, b, "events", "replay", msg)
+			if msg.GetTimestamp() < before {
+				t.Fatalf("%s kept timestamp %d set by the producer, want the publish time", msg.GetId(), msg.GetTimestamp())
+			}
+		}
+		first, last := published[0].GetTimestamp(), published[2].GetTimestamp()
+		between := published[1].GetTimestamp() - 1
+
+		seeks := []struct {
+			name      string
+			timestamp int64
+			offset    int64
+			want      string
+		}{
+			{"between messages", between, 1, "[event-1 event-2]"},
+			{"before the first", first - 1000, 0, "[event-0 event-1 event-2]"},
+			{"after the last", last + 1000, 3, "[]"},
+		}
+		for _, seek := range seeks {
+			if offset := seekTime(t, b, "events", "replay", seek.timestamp); offset != seek.offset {
+				t.Fatalf("seeking %s resumed at offset %d, want %d", seek.name, offset, seek.offset)
+			}
+			if got := fmt.Sprint(readAll()); got != seek.want {
+				t.Fatalf("after seeking %s got %v, want %v", seek.name, got, seek.want)
+			}
+		}
+
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+			if offset := seekTime(t, b, "events", "replay", between); offset != 1 {
+				t.Fatalf("seeking between messages after a restart resumed at offset %d, want 1", offset)
+			}
+			if got := fmt.Sprint(readAll()); got != "[event-1 event-2]" {
+				t.Fatalf("after seeking between messages after a restart got %v, want [event-1 event-2]", got)
+			}
+		}
+	})
+}
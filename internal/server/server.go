@@ -1,29 +1,171 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
 	"time"
 
 	"github.com/a1mart/kafkaesque/internal/draupnir"
 	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/kronos"
 	"github.com/a1mart/kafkaesque/internal/mnemosyne"
+	"github.com/a1mart/kafkaesque/internal/urd"
 )
 
+// Config holds broker-wide settings
+type Config struct {
+	BufferSize         int           // Ring buffer size allocated per partition
+	TTL                time.Duration // MemTable entry lifetime
+	DataDir            string        // Directory holding topic logs; empty keeps messages in memory only
+	Log                urd.Options   // Segment, index and fsync settings for topic logs
+	PausedLag          int           // Unread messages a paused consumer group may hold back per partition before producers stop waiting for it
+	AllowDeleteTopics  bool          // Whether DeleteTopic may remove topics along with their data
+	RetentionInterval  time.Duration // Time between retention checks
+	CompactionInterval time.Duration // Time between compactions of topics with the compact cleanup policy
+	DedupWindow        int           // Newest messages per partition checked for retried duplicates
+	TransactionTimeout time.Duration // How long transactions may stay open unless BeginTransaction sets a timeout
+	DispatchRate       int64         // Bytes per second consumers may read altogether, shared fairly between consumer groups; 0 means unlimited
+	Topics             TopicDefaults // Settings topics inherit unless CreateTopic sets them
+}
+
+// DefaultConfig returns an in-memory broker configuration
+func DefaultConfig() Config {
+	return Config{
+		BufferSize:         1024,
+		TTL:                time.Minute,
+		PausedLag:          512,
+		RetentionInterval:  30 * time.Second,
+		CompactionInterval: 5 * time.Minute,
+		DedupWindow:        10000,
+		TransactionTimeout: time.Minute,
+		Log:                urd.DefaultOptions(),
+		Topics: TopicDefaults{
+			Partitions:        1,
+			OverflowPolicy:    draupnir.OverflowBlock,
+			OverflowTimeout:   draupnir.DefaultOverflowTimeout,
+			VisibilityTimeout: defaultVisibilityTimeout,
+			MaxMessageBytes:   defaultMaxMessageBytes,
+			CleanupPolicy:     cleanupDelete,
+			DeleteRetention:   defaultDeleteRetention,
+		},
+	}
+}
+
 // gRPC Server
 type Server struct {
 	messaging.UnimplementedMessagingServiceServer
 	messaging.UnimplementedAdminServiceServer // Implement AdminService
-	rb                                        *draupnir.RingBuffer
-	memTable                                  *mnemosyne.MemTable
-	topics                                    map[string]string // Store topics and their strategies
-	consumerMap                               map[string]bool   // Tracks registered consumers
+	messaging.UnimplementedSchemaRegistryServiceServer
+	cfg      Config
+	memTable *mnemosyne.MemTable
+	schemas  *schemaCatalog
+	mu       sync.RWMutex
+	topics   map[string]*topic // Store topics and their partitions
+	txns     transactions
+	dispatch *kronos.FairShare // Shares DispatchRate between the consumer groups of every topic
+	closed   chan struct{}
+	wg       sync.WaitGroup
+
+	rescheduled chan struct{} // Nudges scheduleLoop when a message is scheduled, in case it is due first
+	deliveries  *kronos.Pool  // Delivers the scheduled messages of each topic once due
 }
 
-func NewServer(size int, numConsumers int, ttl time.Duration) *Server {
+// NewServer creates a broker, rebuilding topics, offsets and ring buffers from cfg.DataDir when set
+func NewServer(cfg Config) (*Server, error) {
 	s := &Server{
-		rb:          draupnir.NewRingBuffer(size, numConsumers),
-		memTable:    mnemosyne.NewMemTable(ttl),
-		topics:      make(map[string]string),
-		consumerMap: make(map[string]bool),
+		cfg:         cfg,
+		memTable:    mnemosyne.NewMemTable(cfg.TTL),
+		topics:      make(map[string]*topic),
+		txns:        transactions{byID: make(map[string]*transaction)},
+		dispatch:    kronos.NewFairShare(cfg.DispatchRate, dispatchQuantum, nil),
+		closed:      make(chan struct{}),
+		rescheduled: make(chan struct{}, 1),
+		deliveries:  kronos.NewPool(kronos.NewRoundRobin(scheduleSlice), scheduleWorkers, nil),
+	}
+
+	if p := cfg.Topics.CleanupPolicy; p != "" && p != cleanupDelete && p != cleanupCompact {
+		return nil, fmt.Errorf("default cleanup policy must be %q or %q, not %q", cleanupDelete, cleanupCompact, p)
+	}
+
+	var err error
+	if s.schemas, err = openSchemaCatalog(schemasPath(cfg)); err != nil {
+		return nil, err
+	}
+
+	if cfg.DataDir != "" {
+		if err := s.loadTopics(); err != nil {
+			s.closeTopics()
+			return nil, err
+		}
+		if err := s.recoverTransactions(); err != nil {
+			s.closeTopics()
+			return nil, fmt.Errorf("recovering transactions: %w", err)
+		}
+		s.wg.Add(2)
+		go s.checkpointLoop()
+		go s.compactionLoop()
+	}
+	s.deliveries.Start(context.Background())
+	s.wg.Add(2)
+	go s.retentionLoop()
+	go s.scheduleLoop()
+	return s, nil
+}
+
+// Close stops background work and flushes every topic log and consumer offset to disk
+func (s *Server) Close() error {
+	close(s.closed)
+	s.txns.stopTimers()
+	s.memTable.Close()
+	s.wg.Wait()
+	s.deliveries.Close()
+	return s.closeTopics()
+}
+
+func (s *Server) closeTopics() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	for _, t := range s.topics {
+		if cerr := t.close(); cerr != nil {
+			log.Printf("Failed to close topic %s: %v", t.name, cerr)
+			if err == nil {
+				err = cerr
+			}
+		}
+	}
+	return err
+}
+
+// checkpointLoop periodically persists consumer group offsets and the dead letters queued while
+// fetching
+func (s *Server) checkpointLoop() {
+	defer s.wg.Done()
+	interval := s.cfg.Log.SyncInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+			s.mu.RLock()
+			for _, t := range s.topics {
+				if err := t.checkpoint(); err != nil {
+					log.Printf("Failed to checkpoint offsets for topic %s: %v", t.name, err)
+				}
+				if err := t.dlq.flush(); err != nil {
+					log.Printf("Failed to persist dead letters for topic %s: %v", t.name, err)
+				}
+			}
+			s.mu.RUnlock()
+		}
 	}
-	return s
 }
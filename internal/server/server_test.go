@@ -0,0 +1,125 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+)
+
+// forEachStore runs a test against a broker keeping messages in memory and one keeping them in
+// topic logs under a temporary directory
+func forEachStore(t *testing.T, test func(t *testing.T, cfg server.Config)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, server.DefaultConfig())
+	})
+	t.Run("log", func(t *testing.T) {
+		cfg := server.DefaultConfig()
+		cfg.DataDir = t.TempDir()
+		test(t, cfg)
+	})
+}
+
+// broker is a server under test; stop closes it, and runs when the test ends if the test did not
+type broker struct {
+	*server.Server
+	stopped bool
+}
+
+func start(t *testing.T, cfg server.Config) *broker {
+	t.Helper()
+	s, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &broker{Server: s}
+	t.Cleanup(b.stop)
+	return b
+}
+
+func (b *broker) stop() {
+	if !b.stopped {
+		b.stopped = true
+		b.Close()
+	}
+}
+
+// restart closes the broker and starts another on its data directory
+func (b *broker) restart(t *testing.T, cfg server.Config) *broker {
+	t.Helper()
+	b.stop()
+	return start(t, cfg)
+}
+
+func createTopic(t *testing.T, b *broker, name string, config *messaging.TopicConfig) {
+	t.Helper()
+	resp, err := b.CreateTopic(context.Background(), &messaging.CreateTopicRequest{Topic: name, Strategy: "round_robin", Config: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetSuccess() {
+		t.Fatalf("creating topic %s: %s", name, resp.GetError())
+	}
+}
+
+func register(t *testing.T, b *broker, topic, group string) {
+	t.Helper()
+	resp, err := b.RegisterConsumerGroup(context.Background(), &messaging.RegisterConsumerRequest{Topic: topic, ConsumerGroup: group})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetSuccess() {
+		t.Fatalf("registering consumer group %s: %s", group, resp.GetError())
+	}
+}
+
+func publish(t *testing.T, b *broker, req *messaging.PublishRequest) *messaging.PublishResponse {
+	t.Helper()
+	resp, err := b.Publish(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetSuccess() {
+		t.Fatalf("publishing to %s: %s", req.GetTopic(), resp.GetError())
+	}
+	return resp
+}
+
+func consume(t *testing.T, b *broker, req *messaging.ConsumeRequest) []*messaging.Message {
+	t.Helper()
+	resp, err := b.Consume(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetSuccess() {
+		t.Fatalf("consuming from %s: %s", req.GetTopic(), resp.GetError())
+	}
+	return resp.GetMessages()
+}
+
+// ids returns the IDs of messages in order
+func ids(messages []*messaging.Message) []string {
+	out := make([]string, len(messages))
+	for i, msg := range messages {
+		out[i] = msg.GetId()
+	}
+	return out
+}
+
+// ack acknowledges a delivered message by its position
+func ack(t *testing.T, b *broker, topic, group string, msg *messaging.Message) {
+	t.Helper()
+	resp, err := b.Acknowledge(context.Background(), &messaging.AckRequest{Topic: topic, ConsumerGroup: group, Positions: []*messaging.MessagePosition{position(msg)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetSuccess() {
+		t.Fatalf("acknowledging %s: %s", msg.GetId(), resp.GetError())
+	}
+}
+
+// position returns where a delivered message is stored
+func position(msg *messaging.Message) *messaging.MessagePosition {
+	return &messaging.MessagePosition{Partition: msg.GetPartition(), Priority: msg.GetPriority(), Offset: msg.GetOffset()}
+}
@@ -0,0 +1,573 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/draupnir"
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/urd"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	topicMetaFile = "topic.json"
+	offsetsFile   = "offsets.json"
+)
+
+// topicMeta is the on-disk description of a topic, stored next to its partition logs
+type topicMeta struct {
+	Strategy            string         `json:"strategy"`
+	Partitions          int            `json:"partitions"`
+	Partitioner         string         `json:"partitioner"`
+	OverflowPolicy      string         `json:"overflow_policy"`
+	OverflowTimeoutMs   int64          `json:"overflow_timeout_ms"`
+	WaitStrategy        string         `json:"wait_strategy"`
+	VisibilityTimeoutMs int64          `json:"visibility_timeout_ms"`
+	MaxDeliveries       int32          `json:"max_deliveries"`
+	SchemaName          string         `json:"schema_name"`
+	SchemaVersion       string         `json:"schema_version"`
+	RetentionMs         int64          `json:"retention_ms"`
+	RetentionBytes      int64          `json:"retention_bytes"`
+	MaxMessageBytes     int            `json:"max_message_bytes"`
+	CleanupPolicy       string         `json:"cleanup_policy"`
+	DeleteRetentionMs   int64          `json:"delete_retention_ms"`
+	DedupIDs            bool           `json:"dedup_ids,omitempty"`
+	MessageTTLMs        int64          `json:"message_ttl_ms,omitempty"`
+	DeadLetterExpired   bool           `json:"dead_letter_expired,omitempty"`
+	PriorityLevels      int            `json:"priority_levels,omitempty"`
+	PriorityPolicy      string         `json:"priority_policy,omitempty"`
+	PriorityWeights     []int          `json:"priority_weights,omitempty"`
+	Groups              []string       `json:"groups"`
+	PausedGroups        []string       `json:"paused_groups,omitempty"`
+	GroupWeights        map[string]int `json:"group_weights,omitempty"`
+}
+
+// loadTopics rebuilds every topic found in the data dir
+func (s *Server) loadTopics() error {
+	if err := os.MkdirAll(s.cfg.DataDir, 0o755); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(s.cfg.DataDir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		raw, err := os.ReadFile(filepath.Join(s.cfg.DataDir, name, topicMetaFile))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var meta topicMeta
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return fmt.Errorf("topic %s: %w", name, err)
+		}
+		policy, err := draupnir.ParseOverflowPolicy(meta.OverflowPolicy)
+		if err != nil {
+			return fmt.Errorf("topic %s: %w", name, err)
+		}
+		settings := topicSettings{
+			topicConfig: topicConfig{
+				partitions:        meta.Partitions,
+				partitioner:       meta.Partitioner,
+				overflowPolicy:    policy,
+				overflowTimeout:   time.Duration(meta.OverflowTimeoutMs) * time.Millisecond,
+				waitStrategy:      meta.WaitStrategy,
+				visibilityTimeout: time.Duration(meta.VisibilityTimeoutMs) * time.Millisecond,
+				maxDeliveries:     meta.MaxDeliveries,
+				schemaName:        meta.SchemaName,
+				schemaVersion:     meta.SchemaVersion,
+				retention:         time.Duration(meta.RetentionMs) * time.Millisecond,
+				retentionBytes:    meta.RetentionBytes,
+				maxMessageBytes:   meta.MaxMessageBytes,
+				cleanupPolicy:     meta.CleanupPolicy,
+				deleteRetention:   time.Duration(meta.DeleteRetentionMs) * time.Millisecond,
+				dedupIDs:          meta.DedupIDs,
+				messageTTL:        time.Duration(meta.MessageTTLMs) * time.Millisecond,
+				deadLetterExpired: meta.DeadLetterExpired,
+				priorityLevels:    meta.PriorityLevels,
+				priorityPolicy:    meta.PriorityPolicy,
+				priorityWeights:   meta.PriorityWeights,
+			},
+			groups:  meta.Groups,
+			paused:  meta.PausedGroups,
+			weights: meta.GroupWeights,
+		}
+		if settings.overflowTimeout <= 0 {
+			settings.overflowTimeout = draupnir.DefaultOverflowTimeout
+		}
+		if settings.deleteRetention <= 0 {
+			settings.deleteRetention = s.cfg.Topics.DeleteRetention
+		}
+		t, err := newTopic(name, meta.Strategy, settings, s.cfg)
+		if err != nil {
+			return fmt.Errorf("topic %s: %w", name, err)
+		}
+		for group, weight := range t.groupWeights() {
+			s.dispatch.SetWeight(dispatchFlow(name, group), weight)
+		}
+		s.topics[name] = t
+		log.Printf("Recovered topic: %s with %d partitions", name, meta.Partitions)
+	}
+	return nil
+}
+
+// saveTopicMeta records a topic's configuration and consumer groups so it can be rebuilt on startup
+func (s *Server) saveTopicMeta(t *topic) error {
+	if s.cfg.DataDir == "" {
+		return nil
+	}
+	config := t.config()
+	raw, err := json.Marshal(topicMeta{
+		Strategy:            t.strategy,
+		Partitions:          config.partitions,
+		Partitioner:         config.partitioner,
+		OverflowPolicy:      config.overflowPolicy.String(),
+		OverflowTimeoutMs:   config.overflowTimeout.Milliseconds(),
+		WaitStrategy:        config.waitStrategy,
+		VisibilityTimeoutMs: config.visibilityTimeout.Milliseconds(),
+		MaxDeliveries:       config.maxDeliveries,
+		SchemaName:          config.schemaName,
+		SchemaVersion:       config.schemaVersion,
+		RetentionMs:         config.retention.Milliseconds(),
+		RetentionBytes:      config.retentionBytes,
+		MaxMessageBytes:     config.maxMessageBytes,
+		CleanupPolicy:       config.cleanupPolicy,
+		DeleteRetentionMs:   config.deleteRetention.Milliseconds(),
+		DedupIDs:            config.dedupIDs,
+		MessageTTLMs:        config.messageTTL.Milliseconds(),
+		DeadLetterExpired:   config.deadLetterExpired,
+		PriorityLevels:      config.priorityLevels,
+		PriorityPolicy:      config.priorityPolicy,
+		PriorityWeights:     config.priorityWeights,
+		Groups:              t.groupNames(),
+		PausedGroups:        t.pausedGroups(),
+		GroupWeights:        t.groupWeights(),
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.cfg.DataDir, t.name, topicMetaFile), raw)
+}
+
+// open opens the partition log and replays its tail into the ring buffer, restoring consumer offsets
+func (p *partition) open(opts urd.Options) error {
+	l, err := urd.Open(p.dir, opts)
+	if err != nil {
+		return err
+	}
+	p.log = l
+
+	committed, err := p.readOffsets()
+	if err != nil {
+		return err
+	}
+	if p.times, err = openTimeIndex(filepath.Join(p.dir, timeIndexFile), l.NextOffset()); err != nil {
+		return err
+	}
+
+	// The ring buffer holds at most its size worth of the newest messages; start from the oldest
+	// message any consumer group still needs that fits.
+	next := l.NextOffset()
+	start := next - int64(p.rb.Size())
+	minCommitted := next - 1
+	for _, group := range p.rb.Consumers() {
+		if offset, ok := committed[group]; ok && offset < minCommitted {
+			minCommitted = offset
+		} else if !ok {
+			minCommitted = l.StartOffset() - 1
+		}
+	}
+	if start < minCommitted+1 {
+		start = minCommitted + 1
+	}
+	if start < l.StartOffset() {
+		start = l.StartOffset()
+	}
+
+	p.rb.Restore(start)
+	var replayErr error
+	err = l.ReadFrom(start, func(offset int64, record []byte) bool {
+		if offset != p.rb.WriteCursor() {
+			// The ring buffer cannot hold the gaps compaction left; start over after the gap and
+			// let groups that need the messages before it replay them from the log
+			p.rb.Expire(offset)
+			p.rb.Restore(offset)
+			start = offset
+		}
+		msg := &messaging.Message{}
+		if replayErr = proto.Unmarshal(record, msg); replayErr != nil {
+			return false
+		}
+		if msg.GetTimestamp() > p.lastTimestamp {
+			p.lastTimestamp = msg.GetTimestamp()
+		}
+		_, replayErr = p.rb.Put(msg)
+		return replayErr == nil
+	})
+	if err == nil {
+		err = replayErr
+	}
+	if err != nil {
+		return fmt.Errorf("replaying partition %d: %w", p.id, err)
+	}
+	if next != p.rb.WriteCursor() {
+		// The newest sealed messages were compacted away and nothing has been appended since
+		p.rb.Expire(next)
+		p.rb.Restore(next)
+		start = next
+	}
+
+	if err := p.rebuildDedup(); err != nil {
+		return fmt.Errorf("rebuilding the deduplication window of partition %d: %w", p.id, err)
+	}
+
+	for _, group := range p.rb.Consumers() {
+		offset, ok := committed[group]
+		if !ok {
+			offset = start - 1
+		}
+		if offset < start-1 {
+			// Older than the in-memory tail: read from the log until the group catches up. Offsets
+			// deleted by retention stay out of range until the group seeks.
+			p.replays[group] = offset + 1
+			log.Printf("Consumer group %s on partition %d is behind the in-memory tail; replaying from offset %d", group, p.id, offset+1)
+			offset = start - 1
+		}
+		p.rb.SetReadCursor(group, offset)
+	}
+	return nil
+}
+
+// rebuildDedup fills the deduplication window with the newest messages of the log, so producers
+// retrying across a restart are still recognised
+func (p *partition) rebuildDedup() error {
+	if p.dedup.size() == 0 {
+		return nil
+	}
+	end := p.log.NextOffset()
+	from := max(p.log.StartOffset(), end-int64(p.dedup.size()))
+	var decodeErr error
+	err := p.log.ReadFrom(from, func(offset int64, record []byte) bool {
+		if offset >= end {
+			return false
+		}
+		msg := &messaging.Message{}
+		if decodeErr = proto.Unmarshal(record, msg); decodeErr != nil {
+			return false
+		}
+		if e, ok := p.dedup.entry(msg); ok {
+			p.dedup.add(e, offset)
+		}
+		if msg.GetTransactionMarker() == markerAbort {
+			p.dedup.evict(msg.GetTransactionId())
+		}
+		return true
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	return err
+}
+
+// appended is the outcome of appending one message: the offset it was written at, or for a
+// retried duplicate the offset it was first written at, or why it was rejected
+type appended struct {
+	offset    int64
+	duplicate bool
+	err       error
+}
+
+// append stamps the messages with their publish time and writes the ones not already published
+// to the log (when persistent) and then to the ring buffer as one contiguous run. The error is
+// for the whole run; otherwise there is an outcome per message.
+func (p *partition) append(msgs ...*messaging.Message) ([]appended, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.appendLocked(msgs)
+}
+
+// appendLocked is append for callers already holding p.mu
+func (p *partition) appendLocked(msgs []*messaging.Message) ([]appended, error) {
+	results := make([]appended, len(msgs))
+	fresh := make([]*messaging.Message, 0, len(msgs))
+	var entries []dedupEntry
+	var firsts map[string]int    // Index of the first message with each key
+	var batched map[string]int64 // Newest sequence number of each producer in msgs
+	copies := make(map[int]int)  // Messages repeated within msgs, by the index of the first
+	if p.dedup.size() > 0 {
+		entries = make([]dedupEntry, 0, len(msgs))
+		firsts = make(map[string]int)
+		batched = make(map[string]int64)
+	}
+	for i, msg := range msgs {
+		if entries != nil {
+			e, ok := p.dedup.entry(msg)
+			if ok {
+				if first, seen := firsts[e.key]; seen {
+					copies[i] = first
+					continue
+				}
+				offset, dup, err := p.dedup.lookup(e, batched)
+				if dup || err != nil {
+					results[i] = appended{offset: offset, duplicate: dup, err: err}
+					continue
+				}
+				firsts[e.key] = i
+				if e.producer != "" {
+					batched[e.producer] = e.sequence
+				}
+			}
+			entries = append(entries, e)
+		}
+		p.stamp(msg)
+		fresh = append(fresh, msg)
+	}
+
+	// Open transactions are tracked before their first message can be read, so read_committed
+	// consumers never get past it
+	var started []string
+	for j, msg := range fresh {
+		if id := msg.GetTransactionId(); id != "" && msg.GetTransactionMarker() == "" && p.txns.begin(id, p.rb.WriteCursor()+int64(j)) {
+			started = append(started, id)
+		}
+	}
+	first, err := p.write(fresh)
+	if err != nil {
+		for _, id := range started {
+			p.txns.forget(id)
+		}
+		return nil, err
+	}
+	ended := false
+	for j, msg := range fresh {
+		if marker := msg.GetTransactionMarker(); marker != "" {
+			p.settleTransaction(msg.GetTransactionId(), marker, first+int64(j))
+			ended = true
+		}
+	}
+	if ended {
+		// Consumers woken by the marker may have looked before the transaction ended
+		p.rb.WaitStrategy().Signal()
+	}
+	j := 0
+	for i := range results {
+		if _, copied := copies[i]; copied || results[i].duplicate || results[i].err != nil {
+			continue
+		}
+		results[i].offset = first + int64(j)
+		if entries != nil && entries[j].key != "" {
+			p.dedup.add(entries[j], results[i].offset)
+		}
+		j++
+	}
+	for i, index := range copies {
+		results[i] = appended{offset: results[index].offset, duplicate: true}
+	}
+	return results, nil
+}
+
+// write writes the messages to the log (when persistent) and then to the ring buffer as one
+// contiguous run, returning the offset of the first
+func (p *partition) write(msgs []*messaging.Message) (int64, error) {
+	p.followReplays()
+	if len(msgs) == 0 {
+		return p.rb.WriteCursor(), nil
+	}
+	if p.log == nil {
+		offset, err := p.rb.Put(msgs...)
+		if err != nil {
+			return 0, err
+		}
+		p.indexTimes(msgs, offset)
+		p.times.trim(p.startOffset())
+		return offset, nil
+	}
+
+	records := make([][]byte, len(msgs))
+	for i, msg := range msgs {
+		record, err := proto.Marshal(msg)
+		if err != nil {
+			return 0, err
+		}
+		records[i] = record
+	}
+
+	// Apply back-pressure before the messages become durable so the log never holds a message
+	// the ring buffer refused
+	if err := p.rb.AwaitCapacity(len(msgs)); err != nil {
+		return 0, err
+	}
+	offset, err := p.log.Append(records...)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := p.rb.Put(msgs...); err != nil {
+		return 0, err
+	}
+	p.indexTimes(msgs, offset)
+	return offset, nil
+}
+
+// reserve waits, under the overflow policy, until n more messages fit in the ring buffer. The
+// caller holds p.mu, so the room is still there when it appends them.
+func (p *partition) reserve(n int) error {
+	p.followReplays()
+	return p.rb.AwaitCapacity(n)
+}
+
+// indexTimes records the publish times of messages appended from offset on
+func (p *partition) indexTimes(msgs []*messaging.Message, offset int64) {
+	for i, msg := range msgs {
+		if err := p.times.add(msg.GetTimestamp(), offset+int64(i)); err != nil {
+			// The index only speeds up seeks by time; a missing entry makes them scan further
+			log.Printf("Failed to index publish time on partition %d: %v", p.id, err)
+			return
+		}
+	}
+}
+
+// stamp sets the publish time of a message, never going back in time within the partition. A
+// timestamp set by the producer is overwritten, as the time index and seeks rely on the order. The
+// caller holds p.mu.
+func (p *partition) stamp(msg *messaging.Message) {
+	ts := time.Now().UnixMilli()
+	if ts < p.lastTimestamp {
+		ts = p.lastTimestamp
+	}
+	p.lastTimestamp = ts
+	msg.Timestamp = ts
+}
+
+// followReplays moves the ring buffer cursors of replaying consumers up to the last message
+// written, so they never hold back the next one; the caller holds p.mu
+func (p *partition) followReplays() {
+	p.cursorMu.Lock()
+	defer p.cursorMu.Unlock()
+	if len(p.paused) > 0 || (p.log != nil && p.txns.lastStable() != math.MaxInt64) {
+		p.releaseLagging()
+	}
+	for cursor := range p.replays {
+		p.rb.AdvanceReadCursor(cursor, p.rb.WriteCursor()-1)
+	}
+}
+
+// releaseLagging stops paused groups that lag more than pausedLag messages from holding back the
+// next message. With a log they switch to replaying from it, to catch up once resumed; in memory
+// they give up their oldest messages. With a log, read_committed consumers waiting that far
+// behind for an open transaction to end switch to replaying too, so the transaction's marker
+// always fits. The caller holds p.mu and p.cursorMu.
+func (p *partition) releaseLagging() {
+	next := p.rb.WriteCursor()
+	stable := p.txns.lastStable()
+	for cursor, seq := range p.rb.ReadCursors() {
+		group, _, _ := strings.Cut(cursor, "/")
+		if _, replaying := p.replays[cursor]; replaying || next-seq <= p.pausedLag {
+			continue
+		}
+		if p.log != nil && !p.paused[group] && seq+1 >= stable {
+			p.replays[cursor] = seq + 1
+			log.Printf("Consumer %s on partition %d is %d messages behind an open transaction; it will catch up from the log", cursor, p.id, next-seq)
+			continue
+		}
+		if !p.paused[group] {
+			continue
+		}
+		if p.log != nil {
+			p.replays[cursor] = seq + 1
+			log.Printf("Paused consumer %s on partition %d is %d messages behind; it will catch up from the log", cursor, p.id, next-seq)
+			continue
+		}
+		to := min(next-p.pausedLag, next-1) // The message being appended is not there to skip yet
+		p.rb.AdvanceReadCursor(cursor, to)
+		log.Printf("Paused consumer %s on partition %d is %d messages behind; skipped offsets %d-%d", cursor, p.id, next-seq, seq+1, to)
+	}
+}
+
+// startOffset returns the oldest offset the partition still holds: in the log when persistent,
+// otherwise in the ring buffer
+func (p *partition) startOffset() int64 {
+	if p.log != nil {
+		return p.log.StartOffset()
+	}
+	return max(p.rb.WriteCursor()-int64(p.rb.Size()), p.floor, 0)
+}
+
+// checkpoint persists consumer group offsets
+func (p *partition) checkpoint(offsets map[string]int64) error {
+	if p.log == nil {
+		return nil
+	}
+	raw, err := json.Marshal(offsets)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(p.dir, offsetsFile), raw)
+}
+
+func (p *partition) readOffsets() (map[string]int64, error) {
+	raw, err := os.ReadFile(filepath.Join(p.dir, offsetsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]int64{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var offsets map[string]int64
+	if err := json.Unmarshal(raw, &offsets); err != nil {
+		return nil, err
+	}
+	return offsets, nil
+}
+
+func (p *partition) close() error {
+	err := p.times.close()
+	if p.log == nil {
+		return err
+	}
+	if cerr := p.log.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeFileAtomic replaces path with data via a synced temporary file and rename
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultStreamWindow = 100                      // Unacknowledged messages a stream may have outstanding
+	streamHeartbeat     = memberSessionTimeout / 3 // Longest a stream sleeps before renewing its membership
+)
+
+// Stream pushes messages to a consumer as soon as they reach its partitions. At most window
+// messages are outstanding at once: once the window is full the stream pauses until the consumer
+// acknowledges or nacks some of them, or their visibility timeout runs out. The stream ends when
+// the client disconnects; whatever it left unacknowledged is redelivered after the timeout.
+func (s *Server) Stream(req *messaging.StreamRequest, stream messaging.MessagingService_StreamServer) error {
+	t, err := s.getTopic(req.GetTopic())
+	if err != nil {
+		return err
+	}
+	if req.GetWindow() < 0 || req.GetBatchSize() < 0 {
+		return status.Error(codes.InvalidArgument, "window and batch_size must not be negative")
+	}
+	filter, err := compileFilter(req.GetFilterExpression())
+	if err != nil {
+		return err
+	}
+	committed, err := isolationLevel(req.GetIsolationLevel())
+	if err != nil {
+		return err
+	}
+
+	window := int(req.GetWindow())
+	if window == 0 {
+		window = defaultStreamWindow
+	}
+	batchSize := int(req.GetBatchSize())
+	if batchSize == 0 || batchSize > window {
+		batchSize = window
+	}
+
+	group, member := req.GetConsumerGroup(), req.GetConsumerId()
+	ctx := stream.Context()
+	log.Printf("Streaming topic %s to consumer %s in group %s", t.name, member, group)
+
+	for {
+		if t.isDeleted() {
+			log.Printf("Stream of topic %s to consumer %s closed: topic deleted", t.name, member)
+			return t.errDeleted()
+		}
+		// Re-assigning on every pass keeps the membership alive and picks up rebalances
+		owned, cursor, err := t.assign(group, member)
+		if err != nil {
+			return err
+		}
+		if paused, resumed := t.pauseState(group); paused {
+			if err := sleepUntil(ctx, resumed, streamHeartbeat); err != nil {
+				log.Printf("Stream of topic %s to consumer %s closed by client", t.name, member)
+				return err
+			}
+			continue
+		}
+		lt := t.leaseTable(cursor)
+		released := lt.releases()
+
+		credit := window - lt.outstanding(owned, time.Now())
+		if credit > 0 {
+			messages, err := s.fetch(ctx, t, group, owned, cursor, min(batchSize, credit), filter, committed)
+			if err != nil {
+				log.Printf("Stream of topic %s to consumer %s ended: %v", t.name, member, err)
+				return err
+			}
+			if len(messages) > 0 {
+				if err := stream.Send(&messaging.StreamResponse{Messages: messages}); err != nil {
+					log.Printf("Stream of topic %s to consumer %s ended: %v", t.name, member, err)
+					return err
+				}
+				continue
+			}
+		}
+
+		// Sleep until there is something to send: a new message when the window has room, an ack
+		// when it is full, or a lease falling due for redelivery either way
+		deadline := time.Now().Add(streamHeartbeat)
+		if next := lt.nextDeadline(owned); !next.IsZero() && next.Before(deadline) {
+			deadline = next
+		}
+		waitCtx, cancel := context.WithDeadline(ctx, deadline)
+		if credit > 0 {
+			t.wait(waitCtx, owned, cursor, committed)
+		} else {
+			select {
+			case <-released:
+			case <-t.deleted:
+			case <-waitCtx.Done():
+			}
+		}
+		cancel()
+
+		if ctx.Err() != nil {
+			log.Printf("Stream of topic %s to consumer %s closed by client", t.name, member)
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// sleepUntil waits for wake to close or d to pass, returning an error status if ctx ends first.
+// Paused groups are woken on resume, unregistration and topic deletion alike.
+func sleepUntil(ctx context.Context, wake <-chan struct{}, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-wake:
+	case <-timer.C:
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// streamServer hands what the broker streams to the test; the embedded grpc.ServerStream is nil,
+// as the broker only calls Context and Send. Done receives what Stream returned.
+type streamServer struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses chan *messaging.StreamResponse
+	done      chan error
+}
+
+func (s *streamServer) Context() context.Context { return s.ctx }
+
+func (s *streamServer) Send(resp *messaging.StreamResponse) error {
+	select {
+	case s.responses <- resp:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// openStream starts streaming to a consumer; cancel ends the stream and returns what Stream did
+func openStream(b *broker, req *messaging.StreamRequest) (stream *streamServer, cancel func() error) {
+	ctx, stop := context.WithCancel(context.Background())
+	stream = &streamServer{ctx: ctx, responses: make(chan *messaging.StreamResponse), done: make(chan error, 1)}
+	go func() { stream.done <- b.Stream(req, stream) }()
+	return stream, func() error {
+		stop()
+		return <-stream.done
+	}
+}
+
+// receive returns the next messages streamed, or none if nothing arrives within wait
+func (s *streamServer) receive(wait time.Duration) []*messaging.Message {
+	select {
+	case resp := <-s.responses:
+		return resp.GetMessages()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// TestStreamPushes ensures a stream delivers messages published while it is open, without the
+// consumer asking again
+func TestStreamPushes(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "events", nil)
+		register(t, b, "events", "feed")
+		stream, cancel := openStream(b, &messaging.StreamRequest{Topic: "events", ConsumerGroup: "feed", ConsumerId: "reader"})
+		defer cancel()
+
+		for i := 0; i < 3; i++ {
+			id := fmt.Sprintf("event-%d", i)
+			publish(t, b, &messaging.PublishRequest{Topic: "events", Message: &messaging.Message{Id: id}})
+			got := stream.receive(time.Second)
+			if len(got) != 1 || got[0].GetId() != id {
+				t.Fatalf("streamed %v, want [%s]", ids(got), id)
+			}
+		}
+	})
+}
+
+// TestStreamWindow ensures a stream stops sending once window messages are unacknowledged, resumes
+// as they are acknowledged, and ends cleanly when the consumer goes away
+func TestStreamWindow(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "events", nil)
+		register(t, b, "events", "feed")
+		for i := 0; i < 3; i++ {
+			publish(t, b, &messaging.PublishRequest{Topic: "events", Message: &messaging.Message{Id: fmt.Sprintf("event-%d", i)}})
+		}
+		stream, cancel := openStream(b, &messaging.StreamRequest{Topic: "events", ConsumerGroup: "feed", ConsumerId: "reader", Window: 2})
+
+		var outstanding []*messaging.Message
+		for len(outstanding) < 2 {
+			got := stream.receive(time.Second)
+			if len(got) == 0 {
+				t.Fatalf("streamed %v, want two messages before the window fills", ids(outstanding))
+			}
+			outstanding = append(outstanding, got...)
+		}
+		if got := stream.receive(100 * time.Millisecond); len(got) != 0 {
+			t.Fatalf("streamed %v past a full window", ids(got))
+		}
+
+		ack(t, b, "events", "feed", outstanding[0])
+		if got := stream.receive(time.Second); len(got) != 1 || got[0].GetId() != "event-2" {
+			t.Fatalf("after an ack streamed %v, want [event-2]", ids(got))
+		}
+
+		if err := cancel(); status.Code(err) != codes.Canceled {
+			t.Fatalf("cancelled stream returned %v, want Canceled", err)
+		}
+	})
+}
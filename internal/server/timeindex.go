@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	timeIndexFile      = "timeindex"
+	timeIndexEntrySize = 16              // timestamp (8) + offset (8)
+	timeIndexInterval  = 1 * time.Second // Publish time between entries
+)
+
+// timeIndexEntry records the publish time of the message at offset
+type timeIndexEntry struct {
+	timestamp int64 // Milliseconds since the epoch
+	offset    int64
+}
+
+// timeIndex is a sparse map from publish times to partition offsets, holding one entry per
+// timeIndexInterval of traffic. Timestamps never decrease within a partition, so a lookup only
+// has to scan forward from the last entry before the time wanted.
+type timeIndex struct {
+	file    *os.File // Nil when running in memory
+	entries []timeIndexEntry
+}
+
+// openTimeIndex loads the index stored at path, dropping entries for offsets at or beyond end
+// (lost from the log in a crash). An empty path keeps the index in memory.
+func openTimeIndex(path string, end int64) (*timeIndex, error) {
+	ti := &timeIndex{}
+	if path == "" {
+		return ti, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	for i := 0; i+timeIndexEntrySize <= len(raw); i += timeIndexEntrySize {
+		e := timeIndexEntry{
+			timestamp: int64(binary.BigEndian.Uint64(raw[i:])),
+			offset:    int64(binary.BigEndian.Uint64(raw[i+8:])),
+		}
+		if e.offset >= end {
+			break
+		}
+		ti.entries = append(ti.entries, e)
+	}
+	// Cut off a torn entry or entries past the end of the log
+	if err := file.Truncate(int64(len(ti.entries) * timeIndexEntrySize)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	ti.file = file
+	return ti, nil
+}
+
+// add indexes the message published at timestamp with the given offset, if enough time has passed
+// since the last entry; the caller serializes appends to the partition
+func (ti *timeIndex) add(timestamp, offset int64) error {
+	if n := len(ti.entries); n > 0 && timestamp-ti.entries[n-1].timestamp < timeIndexInterval.Milliseconds() {
+		return nil
+	}
+	e := timeIndexEntry{timestamp: timestamp, offset: offset}
+	ti.entries = append(ti.entries, e)
+	if ti.file == nil {
+		return nil
+	}
+	var buf [timeIndexEntrySize]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(e.timestamp))
+	binary.BigEndian.PutUint64(buf[8:], uint64(e.offset))
+	_, err := ti.file.WriteAt(buf[:], int64(len(ti.entries)-1)*timeIndexEntrySize)
+	return err
+}
+
+// lookup returns the offset to scan from for the first message published at or after timestamp,
+// or -1 to scan from the start of the partition
+func (ti *timeIndex) lookup(timestamp int64) int64 {
+	i := sort.Search(len(ti.entries), func(i int) bool {
+		return ti.entries[i].timestamp >= timestamp
+	})
+	if i == 0 {
+		return -1
+	}
+	return ti.entries[i-1].offset
+}
+
+// trim forgets entries for offsets below start, which the partition no longer holds. Only
+// in-memory indexes are trimmed.
+func (ti *timeIndex) trim(start int64) {
+	if ti.file != nil {
+		return
+	}
+	i := 0
+	for i < len(ti.entries) && ti.entries[i].offset < start {
+		i++
+	}
+	ti.entries = ti.entries[i:]
+}
+
+func (ti *timeIndex) close() error {
+	if ti.file == nil {
+		return nil
+	}
+	return ti.file.Close()
+}
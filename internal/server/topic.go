@@ -0,0 +1,829 @@
+package server
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/draupnir"
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/maat"
+	"github.com/a1mart/kafkaesque/internal/urd"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const memberSessionTimeout = 30 * time.Second // Members not seen for this long lose their partitions
+
+// Topic names double as directory names under the data dir, and group names as offset keys;
+// neither may contain '/', which separates a broadcast member's cursor from its group
+var validTopicName = regexp.MustCompile(`^[A-Za-z0-9._-]{1,249}$`)
+
+// Distribution strategies within a consumer group
+const (
+	strategyRoundRobin = "round_robin" // Members share the group cursor and split the partitions
+	strategyBroadcast  = "broadcast"   // Every member reads every message through its own cursor
+)
+
+// topic owns the partitions (each with its own ring buffer and consumer group cursors) backing a single topic
+type topic struct {
+	name        string
+	strategy    string
+	cfg         atomic.Pointer[topicConfig] // Replaced wholesale by AlterTopicConfig
+	partitioner Partitioner
+	waiter      draupnir.WaitStrategy // Shared by every partition so consumers can wait on all of them at once
+	partitions  []*partition          // Every lane of every partition, partition by partition, lowest priority first
+	levels      int                   // Priority lanes per partition
+	dlq         *deadLetterQueue
+	scheduled   *scheduledMessages
+
+	mu         sync.Mutex
+	groups     map[string]*groupMembers // Registered consumer groups and their live members
+	leases     map[string]*leaseTable   // Unacknowledged deliveries, by cursor
+	deleted    chan struct{}            // Closed once DeleteTopic starts tearing the topic down
+	publishers sync.WaitGroup           // Publishes in flight, drained before the partitions close
+}
+
+// partition is an independently ordered slice of a topic: one of its partitions, or on topics with
+// priority levels, one lane of a partition. When persistence is enabled every message is appended
+// to log before it is put in the ring buffer, so ring sequences and log offsets match.
+type partition struct {
+	id            int // Position among the topic's partitions, counting every lane
+	index         int // Partition number clients see
+	lane          int // Priority lane within that partition
+	rb            *draupnir.RingBuffer
+	mu            sync.Mutex // Serializes appends, and holds producers off while cursors are sought back
+	log           *urd.Log
+	dir           string
+	times         *timeIndex
+	lastTimestamp int64 // Publish time of the newest message, in milliseconds since the epoch
+	floor         int64 // In memory, the offset retention has expired messages up to
+	dedup         *dedupWindow
+	txns          *partitionTxns
+	expired       *expiredOffsets
+
+	// Cursors sought (or restored) further back than the ring buffer holds read from the log until
+	// they catch up, by next offset to read. Their ring buffer cursors follow the write cursor so
+	// they do not hold back producers in the meantime.
+	cursorMu  sync.Mutex
+	replays   map[string]int64
+	paused    map[string]bool // Paused consumer groups
+	pausedLag int64           // Unread messages a paused group may hold back before it stops gating producers
+}
+
+// groupMembers tracks which consumers of a registered group are alive
+type groupMembers struct {
+	lastSeen map[string]time.Time
+	next     int           // Rotates the first partition fetched so no assigned partition is starved
+	paused   bool          // Deliveries stopped by PauseConsumer
+	resumed  chan struct{} // Closed when a paused group is resumed
+	weight   int           // Share of the dispatch rate set by SetConsumerWeight; 0 for the default
+}
+
+func newGroupMembers() *groupMembers {
+	return &groupMembers{lastSeen: make(map[string]time.Time), resumed: make(chan struct{})}
+}
+
+// topicSettings carries a topic's configuration along with the state it is restored with
+type topicSettings struct {
+	topicConfig
+	groups  []string       // Consumer groups registered before a restart
+	paused  []string       // Of those, the ones paused
+	weights map[string]int // And their dispatch weights
+}
+
+// newTopic creates a topic, opening (and replaying) its partition logs when the broker persists to disk
+func newTopic(name, strategy string, settings topicSettings, cfg Config) (*topic, error) {
+	if settings.partitioner == "" {
+		settings.partitioner = "hash"
+	}
+	partitioner, err := newPartitioner(settings.partitioner)
+	if err != nil {
+		return nil, err
+	}
+	if settings.waitStrategy == "" {
+		settings.waitStrategy = "blocking"
+	}
+	waiter, err := draupnir.ParseWaitStrategy(settings.waitStrategy)
+	if err != nil {
+		return nil, err
+	}
+	if settings.visibilityTimeout <= 0 {
+		settings.visibilityTimeout = defaultVisibilityTimeout
+	}
+	if settings.cleanupPolicy == "" {
+		settings.cleanupPolicy = cleanupDelete
+	}
+	if settings.priorityLevels < 1 {
+		settings.priorityLevels = 1
+	}
+	if settings.priorityPolicy == "" {
+		settings.priorityPolicy = priorityWeighted
+	}
+	if len(settings.priorityWeights) != settings.priorityLevels {
+		settings.priorityWeights = defaultPriorityWeights(settings.priorityLevels)
+	}
+
+	t := &topic{
+		name:        name,
+		strategy:    strategy,
+		partitioner: partitioner,
+		waiter:      waiter,
+		partitions:  make([]*partition, settings.partitions*settings.priorityLevels),
+		levels:      settings.priorityLevels,
+		groups:      make(map[string]*groupMembers, len(settings.groups)),
+		leases:      make(map[string]*leaseTable),
+		deleted:     make(chan struct{}),
+	}
+	config := settings.topicConfig
+	t.cfg.Store(&config)
+	for _, group := range settings.groups {
+		t.groups[group] = newGroupMembers()
+		t.groups[group].weight = settings.weights[group]
+	}
+	dlqDir := ""
+	if cfg.DataDir != "" {
+		dlqDir = filepath.Join(cfg.DataDir, name, deadLettersDir)
+	}
+	if t.dlq, err = openDeadLetterQueue(dlqDir, cfg.Log); err != nil {
+		return nil, err
+	}
+	if t.scheduled, err = openScheduledMessages(scheduledPath(cfg, name)); err != nil {
+		return nil, err
+	}
+	for i := range t.partitions {
+		p := &partition{
+			id:        i,
+			index:     i / settings.priorityLevels,
+			lane:      i % settings.priorityLevels,
+			rb:        draupnir.NewRingBuffer(cfg.BufferSize, settings.groups...),
+			times:     &timeIndex{},
+			replays:   make(map[string]int64),
+			paused:    make(map[string]bool),
+			pausedLag: int64(cfg.PausedLag),
+			dedup:     newDedupWindow(cfg.DedupWindow),
+			txns:      newPartitionTxns(),
+			expired:   &expiredOffsets{offsets: make(map[int64]bool)},
+		}
+		p.dedup.byID.Store(settings.dedupIDs)
+		p.rb.SetOverflowPolicy(settings.overflowPolicy, settings.overflowTimeout)
+		p.rb.SetWaitStrategy(waiter)
+		if cfg.DataDir != "" {
+			p.dir = filepath.Join(cfg.DataDir, name, strconv.Itoa(i))
+			if err := p.open(cfg.Log); err != nil {
+				t.close()
+				return nil, err
+			}
+		}
+		t.partitions[i] = p
+	}
+	for _, group := range settings.paused {
+		t.setPaused(group, true)
+	}
+	return t, nil
+}
+
+// config returns the topic's current configuration, which must not be modified
+func (t *topic) config() *topicConfig {
+	return t.cfg.Load()
+}
+
+// alterConfig changes the named fields of the topic's configuration to those in pc, provided
+// validate accepts the result, and returns the new configuration
+func (t *topic) alterConfig(pc *messaging.TopicConfig, fields []string, defaults TopicDefaults, validate func(topicConfig) error) (topicConfig, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	config, err := t.config().alter(pc, fields, defaults)
+	if err != nil {
+		return config, err
+	}
+	if err := validate(config); err != nil {
+		return config, err
+	}
+	for _, p := range t.partitions {
+		p.rb.SetOverflowPolicy(config.overflowPolicy, config.overflowTimeout)
+		p.dedup.byID.Store(config.dedupIDs)
+	}
+	t.cfg.Store(&config)
+	return config, nil
+}
+
+// depth sums the unread messages of every partition
+func (t *topic) depth() int64 {
+	var depth int64
+	for _, p := range t.partitions {
+		depth += p.rb.Depth()
+	}
+	return depth
+}
+
+// fetch delivers up to batchSize messages through a cursor from the given partitions: expired
+// leases first, then new messages in partition order, the lanes of each partition shared out by
+// the topic's priority policy. Every message handed out stays leased until it is acknowledged, and
+// is redelivered once its visibility timeout passes. New messages that do not match filter are
+// skipped as consumed; redeliveries already matched when first delivered. Nothing is delivered
+// while the cursor is out of range on one of the partitions. Transaction markers are never
+// delivered; read_committed cursors stop before the first message of an open transaction and skip
+// those of aborted ones.
+func (t *topic) fetch(partitions []*partition, cursor string, batchSize int, filter *maat.Filter, committed bool) ([]*messaging.Message, error) {
+	for _, p := range partitions {
+		if err := p.checkRange(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	lt := t.leaseTable(cursor)
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	now := time.Now()
+	config := t.config()
+	messages, exhausted, lapsed := lt.redeliver(partitions, batchSize, now, config.visibilityTimeout, config.maxDeliveries, config.messageTTL)
+	t.deadLetterExhausted(cursor, exhausted)
+
+	// take leases up to n new messages of a partition, reporting whether it ran out of them
+	take := func(p *partition, n int) (drained bool) {
+		want := len(messages) + n
+		for len(messages) < want {
+			limit := int64(math.MaxInt64)
+			if committed {
+				limit = p.txns.lastStable()
+			}
+			batch, first := p.read(cursor, want-len(messages), limit)
+			if len(batch) == 0 {
+				return true
+			}
+			for i, msg := range batch {
+				if msg.GetTransactionMarker() != "" || (committed && msg.GetTransactionId() != "" && p.txns.isAborted(msg.GetTransactionId())) {
+					continue
+				}
+				if !filter.Match(filterInput(msg)) {
+					continue
+				}
+				if expired(msg, now, config.messageTTL) {
+					lapsed = append(lapsed, &lease{msg: msg, partition: p, offset: first + int64(i)})
+					continue
+				}
+				messages = append(messages, lt.lease(msg, p, first+int64(i), now.Add(config.visibilityTimeout)))
+			}
+		}
+		return false
+	}
+
+	// Consumers own the lanes of a partition together, so they come in runs of t.levels
+	for i := 0; i < len(partitions) && len(messages) < batchSize; i += t.levels {
+		lanes := partitions[i:min(i+t.levels, len(partitions))]
+		if len(lanes) == 1 {
+			take(lanes[0], batchSize-len(messages))
+			continue
+		}
+		turns := lt.laneTurns(lanes[0].index, config)
+		turns.start()
+		for len(messages) < batchSize {
+			lane, quota, ok := turns.next()
+			if !ok {
+				break
+			}
+			n := batchSize - len(messages)
+			if quota > 0 {
+				n = min(n, quota)
+			}
+			before := len(messages)
+			drained := take(lanes[lane], n)
+			turns.served(len(messages)-before, drained)
+		}
+	}
+	t.discardExpired(cursor, lapsed, config)
+	return messages, nil
+}
+
+// filterInput exposes a message to filter expressions
+func filterInput(msg *messaging.Message) maat.Input {
+	return maat.Input{
+		ID:            msg.GetId(),
+		Type:          msg.GetType(),
+		Key:           msg.GetKey(),
+		ContentType:   msg.GetContentType(),
+		CorrelationID: msg.GetCorrelationId(),
+		CausationID:   msg.GetCausationId(),
+		ProducerID:    msg.GetProducerId(),
+		Headers:       msg.GetHeaders(),
+		Payload:       msg.GetPayload(),
+	}
+}
+
+// compileFilter compiles a consumer's filter expression; an empty expression matches everything
+func compileFilter(expr string) (*maat.Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	filter, err := maat.Compile(expr)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter expression: %v", err)
+	}
+	return filter, nil
+}
+
+// leaseTable returns the in-flight deliveries of a cursor
+func (t *topic) leaseTable(cursor string) *leaseTable {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lt, exists := t.leases[cursor]
+	if !exists {
+		lt = newLeaseTable()
+		t.leases[cursor] = lt
+	}
+	return lt
+}
+
+// leaseKeys resolves the positions of delivered messages, as named in acks and nacks
+func (t *topic) leaseKeys(positions []*messaging.MessagePosition) ([]leaseKey, error) {
+	keys := make([]leaseKey, 0, len(positions))
+	for _, pos := range positions {
+		p, err := t.lane(int(pos.GetPartition()), int(pos.GetPriority()))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, leaseKey{partition: p.id, offset: pos.GetOffset()})
+	}
+	return keys, nil
+}
+
+// cursorFor resolves the cursor a consumer reads through, failing if the group is not registered
+func (t *topic) cursorFor(group, member string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.groups[group]; !exists {
+		return "", status.Errorf(codes.NotFound, "consumer group %q is not registered on topic %q", group, t.name)
+	}
+	if t.strategy == strategyBroadcast {
+		return memberCursor(group, member), nil
+	}
+	return group, nil
+}
+
+// committedOffsets returns the offset to persist for each consumer group on a partition: its read
+// cursor, held back before its oldest unacknowledged message so that message is redelivered after
+// a restart. Leases in acked count as acknowledged.
+func (t *topic) committedOffsets(p *partition, acked map[*lease]bool) map[string]int64 {
+	offsets := p.readPositions()
+	for name, offset := range offsets {
+		if strings.Contains(name, "/") {
+			delete(offsets, name) // Broadcast members resume from their group's cursor
+			continue
+		}
+		t.mu.Lock()
+		lt := t.leases[name]
+		t.mu.Unlock()
+		if lt == nil {
+			continue
+		}
+		if oldest, ok := lt.oldest(p, acked); ok && oldest-1 < offset {
+			offsets[name] = oldest - 1
+		}
+	}
+	return offsets
+}
+
+// forgetPassed drops what each partition remembers about messages that every consumer, and the
+// ring buffer, have moved past: the aborted transactions and the offsets found expired. Consumers
+// seeking back relearn the aborted transactions from the log.
+func (t *topic) forgetPassed() {
+	t.mu.Lock()
+	tables := make([]*leaseTable, 0, len(t.leases))
+	for _, lt := range t.leases {
+		tables = append(tables, lt)
+	}
+	t.mu.Unlock()
+
+	for _, p := range t.partitions {
+		// Lease tables are locked before p.mu, so the oldest leases are found first
+		passed := p.rb.WriteCursor() - int64(p.rb.Size())
+		for _, lt := range tables {
+			if oldest, ok := lt.oldest(p, nil); ok {
+				passed = min(passed, oldest)
+			}
+		}
+		p.mu.Lock()
+		for _, last := range p.readPositions() {
+			passed = min(passed, last+1)
+		}
+		p.txns.prune(passed)
+		p.expired.prune(passed)
+		p.mu.Unlock()
+	}
+}
+
+// wait blocks, per the topic's wait strategy, until one of the partitions has a message for the
+// cursor (one no open transaction holds back, if committed), the topic is deleted or ctx is done
+func (t *topic) wait(ctx context.Context, partitions []*partition, cursor string, committed bool) bool {
+	return t.waiter.WaitFor(ctx, func() bool {
+		if t.isDeleted() {
+			return true
+		}
+		for _, p := range partitions {
+			if p.rb.Available(cursor) && (!committed || p.rb.ReadCursor(cursor)+1 < p.txns.lastStable()) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// register adds a consumer group with its own cursor on every partition. Returns false if the
+// group was already registered.
+func (t *topic) register(group string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.groups[group]; exists {
+		return false
+	}
+	t.groups[group] = newGroupMembers()
+	for _, p := range t.partitions {
+		p.rb.AddConsumer(group, -1)
+	}
+	return true
+}
+
+// unregister drops a consumer group with its cursors, replays and unacknowledged deliveries,
+// freeing the ring buffer slots it held. Returns false if the group was not registered.
+func (t *topic) unregister(group string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	gm, exists := t.groups[group]
+	if !exists {
+		return false
+	}
+	delete(t.groups, group)
+	if gm.paused {
+		close(gm.resumed) // Wake paused long-polls and streams so they find the group gone
+	}
+	for _, p := range t.partitions {
+		p.setPaused(group, false)
+		for _, cursor := range p.rb.Consumers() {
+			if cursor == group || strings.HasPrefix(cursor, group+"/") {
+				p.removeCursor(cursor)
+			}
+		}
+	}
+	for cursor := range t.leases {
+		if cursor == group || strings.HasPrefix(cursor, group+"/") {
+			delete(t.leases, cursor)
+		}
+	}
+	return true
+}
+
+// setPaused pauses or resumes a consumer group, reporting whether that changed anything
+func (t *topic) setPaused(group string, paused bool) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	gm, exists := t.groups[group]
+	if !exists {
+		return false, status.Errorf(codes.NotFound, "consumer group %q is not registered on topic %q", group, t.name)
+	}
+	if gm.paused == paused {
+		return false, nil
+	}
+	gm.paused = paused
+	if paused {
+		gm.resumed = make(chan struct{})
+	} else {
+		close(gm.resumed)
+	}
+	for _, p := range t.partitions {
+		p.setPaused(group, paused)
+	}
+	return true, nil
+}
+
+// pauseState reports whether a group is paused, with a channel closed once it is resumed
+func (t *topic) pauseState(group string) (bool, <-chan struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	gm, exists := t.groups[group]
+	if !exists || !gm.paused {
+		return false, nil
+	}
+	return true, gm.resumed
+}
+
+// pausedGroups returns the paused consumer groups, sorted
+func (t *topic) pausedGroups() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var names []string
+	for name, gm := range t.groups {
+		if gm.paused {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setWeight sets a consumer group's dispatch weight, 0 restoring the default, reporting whether that
+// changed anything
+func (t *topic) setWeight(group string, weight int) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	gm, exists := t.groups[group]
+	if !exists {
+		return false, status.Errorf(codes.NotFound, "consumer group %q is not registered on topic %q", group, t.name)
+	}
+	if weight <= 1 {
+		weight = 0
+	}
+	if gm.weight == weight {
+		return false, nil
+	}
+	gm.weight = weight
+	return true, nil
+}
+
+// groupWeights returns the dispatch weights of the consumer groups that set one
+func (t *topic) groupWeights() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var weights map[string]int
+	for name, gm := range t.groups {
+		if gm.weight > 0 {
+			if weights == nil {
+				weights = make(map[string]int)
+			}
+			weights[name] = gm.weight
+		}
+	}
+	return weights
+}
+
+// groupNames returns the registered consumer groups, sorted
+func (t *topic) groupNames() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	names := make([]string, 0, len(t.groups))
+	for name := range t.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// beginPublish counts a publish in flight so deletion waits for it, failing once the topic is
+// being deleted. Every successful call is paired with endPublish.
+func (t *topic) beginPublish() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.isDeleted() {
+		return t.errDeleted()
+	}
+	t.publishers.Add(1)
+	return nil
+}
+
+func (t *topic) endPublish() {
+	t.publishers.Done()
+}
+
+// drain starts deleting the topic: new publishes are rejected, consumers waiting on it are woken,
+// and the publishes in flight are allowed to finish. Every cursor is dropped first so producers
+// blocked on back-pressure complete instead of waiting out their overflow timeout.
+func (t *topic) drain() {
+	t.mu.Lock()
+	close(t.deleted)
+	for _, gm := range t.groups {
+		if gm.paused {
+			gm.paused = false
+			close(gm.resumed)
+		}
+	}
+	t.mu.Unlock()
+	t.waiter.Signal()
+
+	for _, p := range t.partitions {
+		for _, cursor := range p.rb.Consumers() {
+			p.removeCursor(cursor)
+		}
+	}
+	t.publishers.Wait()
+}
+
+// isDeleted reports whether DeleteTopic has started tearing the topic down
+func (t *topic) isDeleted() bool {
+	select {
+	case <-t.deleted:
+		return true
+	default:
+		return false
+	}
+}
+
+// errDeleted is the status returned to clients still using a deleted topic
+func (t *topic) errDeleted() error {
+	return status.Errorf(codes.NotFound, "topic %q was deleted", t.name)
+}
+
+// memberCursor names the cursor a broadcast member reads through
+func memberCursor(group, member string) string {
+	return group + "/" + member
+}
+
+// close flushes and closes the partition logs
+func (t *topic) close() error {
+	err := t.checkpoint()
+	if cerr := t.dlq.close(); err == nil {
+		err = cerr
+	}
+	if cerr := t.scheduled.close(); err == nil {
+		err = cerr
+	}
+	for _, p := range t.partitions {
+		if p == nil {
+			continue
+		}
+		if cerr := p.close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// checkpoint persists the committed consumer group offsets of every partition
+func (t *topic) checkpoint() error {
+	var err error
+	for _, p := range t.partitions {
+		if p == nil {
+			continue
+		}
+		if cerr := p.checkpoint(t.committedOffsets(p, nil)); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// assign marks member as alive in the group and returns the partitions it reads, starting from a
+// rotating position, along with the cursor to read them through. On round_robin topics partitions
+// are spread over the sorted member IDs so each partition has exactly one reader per group and
+// per-key ordering is preserved; on broadcast topics every member reads every partition.
+func (t *topic) assign(group, member string) ([]*partition, string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	gm, exists := t.groups[group]
+	if !exists {
+		return nil, "", status.Errorf(codes.NotFound, "consumer group %q is not registered on topic %q", group, t.name)
+	}
+
+	now := time.Now()
+	_, known := gm.lastSeen[member]
+	gm.lastSeen[member] = now
+	members := make([]string, 0, len(gm.lastSeen))
+	for id, seen := range gm.lastSeen {
+		if now.Sub(seen) > memberSessionTimeout {
+			delete(gm.lastSeen, id)
+			if t.strategy == strategyBroadcast {
+				for _, p := range t.partitions {
+					p.removeCursor(memberCursor(group, id))
+				}
+				delete(t.leases, memberCursor(group, id))
+			}
+			continue
+		}
+		members = append(members, id)
+	}
+	sort.Strings(members)
+
+	cursor := group
+	var owned []*partition
+	if t.strategy == strategyBroadcast {
+		// New members start where the group's slowest member is
+		cursor = memberCursor(group, member)
+		for _, p := range t.partitions {
+			if !known {
+				p.addMemberCursor(cursor, group)
+			}
+			t.advanceGroup(p, group, members)
+		}
+		owned = t.partitions
+	} else {
+		// The lanes of a partition go to the same member, so it can weigh them against each other
+		index := sort.SearchStrings(members, member)
+		for i, p := range t.partitions {
+			if (i/t.levels)%len(members) == index {
+				owned = append(owned, p)
+			}
+		}
+	}
+	if len(owned) == 0 {
+		return nil, cursor, nil
+	}
+
+	start := gm.next % (len(owned) / t.levels) * t.levels
+	gm.next++
+	rotated := make([]*partition, 0, len(owned))
+	rotated = append(rotated, owned[start:]...)
+	return append(rotated, owned[:start]...), cursor, nil
+}
+
+// advanceGroup moves a broadcast group's own cursor up to its slowest live member, so the group
+// stops holding back producers for messages every member has read
+func (t *topic) advanceGroup(p *partition, group string, members []string) {
+	p.cursorMu.Lock()
+	defer p.cursorMu.Unlock()
+
+	cursors := p.rb.ReadCursors()
+	slowest, found, replaying := int64(0), false, false
+	for _, member := range members {
+		cursor := memberCursor(group, member)
+		seq, ok := cursors[cursor]
+		if !ok {
+			continue
+		}
+		if next, r := p.replays[cursor]; r {
+			seq, replaying = next-1, true
+		}
+		if !found || seq < slowest {
+			slowest, found = seq, true
+		}
+	}
+	if !found {
+		return
+	}
+
+	// While members replay from the log the group's position is tracked as a replay too, so it
+	// is checkpointed and handed to new members; once they have all caught up it rejoins the
+	// ring buffer at the slowest of them
+	if _, r := p.replays[group]; r {
+		if replaying {
+			p.replays[group] = slowest + 1
+			return
+		}
+		delete(p.replays, group)
+		p.rb.SetReadCursor(group, slowest)
+		return
+	}
+	p.rb.AdvanceReadCursor(group, slowest)
+}
+
+// addMemberCursor starts a broadcast member's cursor where its group is, replaying from the log
+// if the group is
+func (p *partition) addMemberCursor(cursor, group string) {
+	p.cursorMu.Lock()
+	defer p.cursorMu.Unlock()
+	p.rb.AddConsumer(cursor, p.rb.ReadCursor(group))
+	if next, ok := p.replays[group]; ok {
+		p.replays[cursor] = next
+	}
+}
+
+// setPaused marks a consumer group paused or not on the partition
+func (p *partition) setPaused(group string, paused bool) {
+	p.cursorMu.Lock()
+	defer p.cursorMu.Unlock()
+	if paused {
+		p.paused[group] = true
+	} else {
+		delete(p.paused, group)
+	}
+}
+
+// removeCursor drops a cursor from the ring buffer and any replay it had in progress
+func (p *partition) removeCursor(cursor string) {
+	p.cursorMu.Lock()
+	defer p.cursorMu.Unlock()
+	p.rb.RemoveConsumer(cursor)
+	delete(p.replays, cursor)
+}
+
+// getTopic looks up a topic by name, returning a NotFound status if it was never created
+func (s *Server) getTopic(name string) (*topic, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, exists := s.topics[name]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "topic %q not found", name)
+	}
+	return t, nil
+}
@@ -0,0 +1,317 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/draupnir"
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+)
+
+// Cleanup policies, deciding how a topic gets rid of old messages
+const (
+	cleanupDelete  = "delete"  // Messages past the retention limits are deleted
+	cleanupCompact = "compact" // Only the newest message per key is kept
+)
+
+const (
+	defaultMaxMessageBytes = 1 << 20
+	defaultDeleteRetention = 24 * time.Hour
+)
+
+// TopicDefaults are the settings a topic takes when CreateTopic leaves them unset. Zero limits
+// mean unlimited.
+type TopicDefaults struct {
+	Partitions        int
+	OverflowPolicy    draupnir.OverflowPolicy
+	OverflowTimeout   time.Duration
+	VisibilityTimeout time.Duration
+	MaxDeliveries     int32
+	Retention         time.Duration // Age after which messages are deleted
+	RetentionBytes    int64         // Per-partition size beyond which the oldest messages are deleted
+	MaxMessageBytes   int           // Largest encoded message Publish accepts
+	CleanupPolicy     string
+	DeleteRetention   time.Duration // How long compacted topics keep tombstones
+}
+
+// topicConfig is the resolved configuration of a topic; zero limits mean unlimited. A topic's
+// config is replaced wholesale when altered, never modified in place.
+type topicConfig struct {
+	partitions        int
+	partitioner       string
+	overflowPolicy    draupnir.OverflowPolicy
+	overflowTimeout   time.Duration
+	waitStrategy      string
+	visibilityTimeout time.Duration
+	maxDeliveries     int32
+	schemaName        string
+	schemaVersion     string
+	retention         time.Duration
+	retentionBytes    int64
+	maxMessageBytes   int
+	cleanupPolicy     string
+	deleteRetention   time.Duration
+	dedupIDs          bool
+	messageTTL        time.Duration // Time to live of messages that set none
+	deadLetterExpired bool
+	priorityLevels    int // Lanes per partition
+	priorityPolicy    string
+	priorityWeights   []int // Per lane, lowest first; the caller must not modify them
+}
+
+// topicConfigField resolves one TopicConfig field into a topicConfig, falling back to the broker
+// defaults when the field is unset
+type topicConfigField struct {
+	name  string
+	fixed bool // Only set when the topic is created
+	apply func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error
+}
+
+var topicConfigFields = []topicConfigField{
+	{name: "partitions", fixed: true, apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		switch n := pc.GetPartitions(); {
+		case n < 0:
+			return errors.New("partition count must be positive")
+		case n == 0:
+			c.partitions = max(d.Partitions, 1)
+		default:
+			c.partitions = int(n)
+		}
+		return nil
+	}},
+	{name: "partitioner", fixed: true, apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		c.partitioner = pc.GetPartitioner()
+		if c.partitioner == "" {
+			c.partitioner = "hash"
+		}
+		_, err := newPartitioner(c.partitioner)
+		return err
+	}},
+	{name: "overflow_policy", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		if pc.GetOverflowPolicy() == "" {
+			c.overflowPolicy = d.OverflowPolicy
+			return nil
+		}
+		policy, err := draupnir.ParseOverflowPolicy(pc.GetOverflowPolicy())
+		c.overflowPolicy = policy
+		return err
+	}},
+	{name: "overflow_timeout_ms", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		timeout, err := duration("overflow_timeout_ms", pc.GetOverflowTimeoutMs(), d.OverflowTimeout)
+		c.overflowTimeout = timeout
+		return err
+	}},
+	{name: "wait_strategy", fixed: true, apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		c.waitStrategy = pc.GetWaitStrategy()
+		if c.waitStrategy == "" {
+			c.waitStrategy = "blocking"
+		}
+		_, err := draupnir.ParseWaitStrategy(c.waitStrategy)
+		return err
+	}},
+	{name: "visibility_timeout_ms", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		timeout, err := duration("visibility_timeout_ms", pc.GetVisibilityTimeoutMs(), d.VisibilityTimeout)
+		c.visibilityTimeout = timeout
+		return err
+	}},
+	{name: "max_deliveries", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		n, err := limit("max_deliveries", int64(pc.GetMaxDeliveries()), int64(d.MaxDeliveries))
+		c.maxDeliveries = int32(n)
+		return err
+	}},
+	{name: "schema_name", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		c.schemaName = pc.GetSchemaName()
+		return nil
+	}},
+	{name: "schema_version", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		c.schemaVersion = pc.GetSchemaVersion()
+		return nil
+	}},
+	{name: "retention_ms", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		ms, err := limit("retention_ms", pc.GetRetentionMs(), d.Retention.Milliseconds())
+		c.retention = time.Duration(ms) * time.Millisecond
+		return err
+	}},
+	{name: "retention_bytes", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		n, err := limit("retention_bytes", pc.GetRetentionBytes(), d.RetentionBytes)
+		c.retentionBytes = n
+		return err
+	}},
+	{name: "max_message_bytes", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		n, err := limit("max_message_bytes", int64(pc.GetMaxMessageBytes()), int64(d.MaxMessageBytes))
+		c.maxMessageBytes = int(n)
+		return err
+	}},
+	{name: "cleanup_policy", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		switch policy := pc.GetCleanupPolicy(); policy {
+		case "":
+			c.cleanupPolicy = d.CleanupPolicy
+		case cleanupDelete, cleanupCompact:
+			c.cleanupPolicy = policy
+		default:
+			return fmt.Errorf("cleanup policy must be %q or %q", cleanupDelete, cleanupCompact)
+		}
+		return nil
+	}},
+	{name: "delete_retention_ms", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		window, err := duration("delete_retention_ms", pc.GetDeleteRetentionMs(), d.DeleteRetention)
+		c.deleteRetention = window
+		return err
+	}},
+	{name: "dedup_ids", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		c.dedupIDs = pc.GetDedupIds()
+		return nil
+	}},
+	{name: "message_ttl_ms", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		ms, err := limit("message_ttl_ms", pc.GetMessageTtlMs(), 0)
+		c.messageTTL = time.Duration(ms) * time.Millisecond
+		return err
+	}},
+	{name: "dead_letter_expired", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		c.deadLetterExpired = pc.GetDeadLetterExpired()
+		return nil
+	}},
+	{name: "priority_levels", fixed: true, apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		switch n := pc.GetPriorityLevels(); {
+		case n < 0 || n > maxPriorityLevels:
+			return fmt.Errorf("priority levels must be between 1 and %d", maxPriorityLevels)
+		case n == 0:
+			c.priorityLevels = 1
+		default:
+			c.priorityLevels = int(n)
+		}
+		return nil
+	}},
+	{name: "priority_policy", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		switch policy := pc.GetPriorityPolicy(); policy {
+		case "":
+			c.priorityPolicy = priorityWeighted
+		case priorityStrict, priorityWeighted, priorityMLFQ:
+			c.priorityPolicy = policy
+		default:
+			return fmt.Errorf("priority policy must be %q, %q or %q", priorityWeighted, priorityStrict, priorityMLFQ)
+		}
+		return nil
+	}},
+	{name: "priority_weights", apply: func(c *topicConfig, pc *messaging.TopicConfig, d TopicDefaults) error {
+		weights := pc.GetPriorityWeights()
+		if len(weights) == 0 {
+			c.priorityWeights = defaultPriorityWeights(c.priorityLevels)
+			return nil
+		}
+		if len(weights) != c.priorityLevels {
+			return fmt.Errorf("priority weights must give one weight for each of the %d priority levels", c.priorityLevels)
+		}
+		c.priorityWeights = make([]int, len(weights))
+		for lane, weight := range weights {
+			if weight < 1 {
+				return errors.New("priority weights must be positive")
+			}
+			c.priorityWeights[lane] = int(weight)
+		}
+		return nil
+	}},
+}
+
+// newTopicConfig resolves the configuration requested for a new topic against the broker defaults
+func newTopicConfig(pc *messaging.TopicConfig, d TopicDefaults) (topicConfig, error) {
+	var c topicConfig
+	for _, f := range topicConfigFields {
+		if err := f.apply(&c, pc, d); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// alter returns a copy of the configuration with the named fields taken from pc
+func (c topicConfig) alter(pc *messaging.TopicConfig, fields []string, d TopicDefaults) (topicConfig, error) {
+	if len(fields) == 0 {
+		return c, errors.New("no config fields to change")
+	}
+	for _, name := range fields {
+		f, ok := lookupTopicConfigField(name)
+		if !ok {
+			return c, fmt.Errorf("unknown config field %q", name)
+		}
+		if f.fixed {
+			return c, fmt.Errorf("%s cannot be changed once the topic is created", name)
+		}
+		if err := f.apply(&c, pc, d); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func lookupTopicConfigField(name string) (topicConfigField, bool) {
+	for _, f := range topicConfigFields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return topicConfigField{}, false
+}
+
+// proto returns the configuration as reported by the API, with unlimited limits as -1
+func (c topicConfig) proto() *messaging.TopicConfig {
+	weights := make([]int32, len(c.priorityWeights))
+	for lane, weight := range c.priorityWeights {
+		weights[lane] = int32(weight)
+	}
+	return &messaging.TopicConfig{
+		Partitions:          int32(c.partitions),
+		Partitioner:         c.partitioner,
+		OverflowPolicy:      c.overflowPolicy.String(),
+		OverflowTimeoutMs:   c.overflowTimeout.Milliseconds(),
+		WaitStrategy:        c.waitStrategy,
+		VisibilityTimeoutMs: c.visibilityTimeout.Milliseconds(),
+		MaxDeliveries:       int32(unlimited(int64(c.maxDeliveries))),
+		SchemaName:          c.schemaName,
+		SchemaVersion:       c.schemaVersion,
+		RetentionMs:         unlimited(c.retention.Milliseconds()),
+		RetentionBytes:      unlimited(c.retentionBytes),
+		MaxMessageBytes:     int32(unlimited(int64(c.maxMessageBytes))),
+		CleanupPolicy:       c.cleanupPolicy,
+		DeleteRetentionMs:   c.deleteRetention.Milliseconds(),
+		DedupIds:            c.dedupIDs,
+		MessageTtlMs:        unlimited(c.messageTTL.Milliseconds()),
+		DeadLetterExpired:   c.deadLetterExpired,
+		PriorityLevels:      int32(c.priorityLevels),
+		PriorityPolicy:      c.priorityPolicy,
+		PriorityWeights:     weights,
+	}
+}
+
+// limit resolves a limit from the API: 0 takes the default, -1 means unlimited (0 internally)
+func limit(name string, v, def int64) (int64, error) {
+	switch {
+	case v == 0:
+		return def, nil
+	case v == -1:
+		return 0, nil
+	case v < 0:
+		return 0, fmt.Errorf("%s must be positive, or -1 for unlimited", name)
+	}
+	return v, nil
+}
+
+// unlimited reports an internal limit to the API, where no limit is -1
+func unlimited(v int64) int64 {
+	if v == 0 {
+		return -1
+	}
+	return v
+}
+
+// duration resolves a timeout in milliseconds from the API, 0 taking the default
+func duration(name string, ms int64, def time.Duration) (time.Duration, error) {
+	if ms < 0 {
+		return 0, fmt.Errorf("%s must not be negative", name)
+	}
+	if ms == 0 {
+		return def, nil
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
@@ -0,0 +1,665 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	maxTransactionTimeout = 15 * time.Minute    // Upper bound on BeginTransactionRequest.timeout_ms
+	stagedOffsetsFile     = "transactions.json" // Consumer offsets of the commits underway
+)
+
+// Transaction markers, written to every partition a transaction published to when it ends
+const (
+	markerCommit = "commit"
+	markerAbort  = "abort"
+)
+
+// Isolation levels of Consume and Stream
+const (
+	readUncommitted = "read_uncommitted"
+	readCommitted   = "read_committed"
+)
+
+// Transaction states
+const (
+	txnOpen      = "open"
+	txnCommitted = "committed"
+	txnAborted   = "aborted"
+)
+
+// transaction groups messages published to any number of topics so read_committed consumers see
+// all of them or none. Its messages are written to their partitions as they are published; when it
+// ends, a commit or abort marker follows them in each partition.
+type transaction struct {
+	id         string
+	mu         sync.Mutex // Held by publishes in the transaction, so it cannot end halfway through one
+	state      string
+	timer      *time.Timer           // Aborts the transaction once its timeout passes
+	partitions map[*partition]*topic // Partitions the transaction published to
+}
+
+// transactions holds the open transactions of the broker, and with a data directory the consumer
+// group offsets of the commits underway. Those are staged before the commit markers are written,
+// so a broker stopped halfway through a commit finishes it with the deliveries acknowledged.
+type transactions struct {
+	mu   sync.Mutex
+	byID map[string]*transaction
+
+	stageMu sync.Mutex
+	path    string                    // Where staged offsets are kept; empty in memory
+	staged  map[string][]stagedOffset // Offsets to resume from once each transaction commits
+}
+
+// stagedOffset is the last offset a consumer group consumed on a partition, counting the deliveries
+// a committing transaction acknowledged
+type stagedOffset struct {
+	Topic     string `json:"topic"`
+	Group     string `json:"group"`
+	Partition int    `json:"partition"`
+	Priority  int    `json:"priority"`
+	Offset    int64  `json:"offset"`
+}
+
+// claim marks a message as published in the transaction, or in none when txn is nil, overwriting
+// whatever transaction fields the producer set
+func (txn *transaction) claim(msg *messaging.Message) {
+	msg.TransactionId, msg.TransactionMarker = "", ""
+	if txn != nil {
+		msg.TransactionId = txn.id
+	}
+}
+
+// enlist records that the transaction publishes to a partition; the caller holds txn.mu
+func (txn *transaction) enlist(t *topic, p *partition) {
+	if txn != nil {
+		txn.partitions[p] = t
+	}
+}
+
+// BeginTransaction starts a transaction that aborts by itself unless committed within its timeout
+func (s *Server) BeginTransaction(ctx context.Context, req *messaging.BeginTransactionRequest) (*messaging.BeginTransactionResponse, error) {
+	if req.GetTimeoutMs() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "timeout_ms must not be negative")
+	}
+	timeout := s.cfg.TransactionTimeout
+	if req.GetTimeoutMs() > 0 {
+		timeout = time.Duration(req.GetTimeoutMs()) * time.Millisecond
+	}
+	if timeout <= 0 || timeout > maxTransactionTimeout {
+		timeout = maxTransactionTimeout
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, status.Errorf(codes.Internal, "generating transaction ID: %v", err)
+	}
+	txn := &transaction{id: hex.EncodeToString(raw), state: txnOpen, partitions: make(map[*partition]*topic)}
+
+	s.txns.mu.Lock()
+	s.txns.byID[txn.id] = txn
+	txn.timer = time.AfterFunc(timeout, func() {
+		if err := s.endTransaction(txn, markerAbort, nil); err == nil {
+			log.Printf("Aborted transaction %s: not committed within %v", txn.id, timeout)
+		}
+	})
+	s.txns.mu.Unlock()
+
+	log.Printf("Began transaction %s with a timeout of %v", txn.id, timeout)
+	return &messaging.BeginTransactionResponse{Success: true, TransactionId: txn.id}, nil
+}
+
+// CommitTransaction makes the messages of a transaction visible to read_committed consumers and
+// acknowledges the deliveries it consumed
+func (s *Server) CommitTransaction(ctx context.Context, req *messaging.CommitTransactionRequest) (*messaging.CommitTransactionResponse, error) {
+	txn, err := s.transaction(req.GetTransactionId())
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve every acknowledgement before ending anything, so a bad one leaves the transaction open
+	tables := make([]*leaseTable, len(req.GetAcknowledgements()))
+	keys := make([][]leaseKey, len(req.GetAcknowledgements()))
+	var inputs map[*topic]map[string]bool // Consumer groups acknowledging, by topic, with a data directory
+	for i, ack := range req.GetAcknowledgements() {
+		t, err := s.getTopic(ack.GetTopic())
+		if err != nil {
+			return nil, err
+		}
+		if s.cfg.DataDir != "" {
+			if inputs == nil {
+				inputs = make(map[*topic]map[string]bool)
+			}
+			if inputs[t] == nil {
+				inputs[t] = make(map[string]bool)
+			}
+			inputs[t][ack.GetConsumerGroup()] = true
+		}
+		cursor, err := t.cursorFor(ack.GetConsumerGroup(), ack.GetConsumerId())
+		if err != nil {
+			return nil, err
+		}
+		if keys[i], err = t.leaseKeys(ack.GetPositions()); err != nil {
+			return nil, err
+		}
+		tables[i] = t.leaseTable(cursor)
+	}
+
+	// Where the consumer groups resume once the acknowledgements apply is staged before the markers
+	// are written, and the acknowledgements only apply after, so no checkpoint holds them unless
+	// the commit went through: a restart finishes the commit from the staged offsets if it did
+	err = s.endTransaction(txn, markerCommit, func() {
+		if len(inputs) == 0 || len(txn.partitions) == 0 {
+			return // Without markers the checkpoint alone commits the acknowledgements
+		}
+		acking := make(map[*lease]bool)
+		for i, ack := range req.GetAcknowledgements() {
+			for _, l := range tables[i].matching(ack.GetMessageIds(), keys[i]) {
+				acking[l] = true
+			}
+		}
+		if err := s.txns.stage(txn.id, consumedOffsets(inputs, acking)); err != nil {
+			log.Printf("Failed to stage the offsets of transaction %s: %v", txn.id, err)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	acked := 0
+	for i, ack := range req.GetAcknowledgements() {
+		acked += tables[i].ack(ack.GetMessageIds(), keys[i])
+	}
+	if len(inputs) > 0 {
+		checkpointed := true
+		for t := range inputs {
+			if err := t.checkpoint(); err != nil {
+				checkpointed = false
+				log.Printf("Failed to checkpoint topic %s after committing transaction %s: %v", t.name, txn.id, err)
+			}
+		}
+		// Until the checkpoints hold the acknowledgements, a restart takes them from the staged offsets
+		if checkpointed {
+			if err := s.txns.unstage(txn.id); err != nil {
+				log.Printf("Failed to unstage the offsets of transaction %s: %v", txn.id, err)
+			}
+		}
+	}
+	log.Printf("Committed transaction %s, acknowledging %d messages", txn.id, acked)
+	return &messaging.CommitTransactionResponse{Success: true}, nil
+}
+
+// consumedOffsets returns where the given consumer groups resume on every partition of their topics
+// once the leases in acking are acknowledged
+func consumedOffsets(inputs map[*topic]map[string]bool, acking map[*lease]bool) []stagedOffset {
+	var offsets []stagedOffset
+	for t, groups := range inputs {
+		for _, p := range t.partitions {
+			if p == nil {
+				continue
+			}
+			for group, offset := range t.committedOffsets(p, acking) {
+				if groups[group] {
+					offsets = append(offsets, stagedOffset{Topic: t.name, Group: group, Partition: p.index, Priority: p.lane, Offset: offset})
+				}
+			}
+		}
+	}
+	return offsets
+}
+
+// AbortTransaction hides the messages of a transaction from read_committed consumers
+func (s *Server) AbortTransaction(ctx context.Context, req *messaging.AbortTransactionRequest) (*messaging.AbortTransactionResponse, error) {
+	txn, err := s.transaction(req.GetTransactionId())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.endTransaction(txn, markerAbort, nil); err != nil {
+		return nil, err
+	}
+	log.Printf("Aborted transaction %s", txn.id)
+	return &messaging.AbortTransactionResponse{Success: true}, nil
+}
+
+// transaction looks up an open transaction
+func (s *Server) transaction(id string) (*transaction, error) {
+	s.txns.mu.Lock()
+	defer s.txns.mu.Unlock()
+	txn, ok := s.txns.byID[id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "transaction %q does not exist or has already ended", id)
+	}
+	return txn, nil
+}
+
+// joinTransaction returns the open transaction a publish belongs to, locked so it cannot end until
+// the caller unlocks txn.mu, or nil when id is empty
+func (s *Server) joinTransaction(id string) (*transaction, error) {
+	if id == "" {
+		return nil, nil
+	}
+	txn, err := s.transaction(id)
+	if err != nil {
+		return nil, err
+	}
+	txn.mu.Lock()
+	if txn.state != txnOpen {
+		txn.mu.Unlock()
+		return nil, status.Errorf(codes.FailedPrecondition, "transaction %s has already been %s", id, txn.state)
+	}
+	return txn, nil
+}
+
+// endTransaction writes a commit or abort marker to every partition the transaction published to.
+// Settle, when set, runs once the transaction can no longer end otherwise, before any marker.
+func (s *Server) endTransaction(txn *transaction, marker string, settle func()) error {
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	if txn.state != txnOpen {
+		return status.Errorf(codes.FailedPrecondition, "transaction %s has already been %s", txn.id, txn.state)
+	}
+	txn.timer.Stop()
+	txn.state = txnAborted
+	if marker == markerCommit {
+		txn.state = txnCommitted
+	}
+	s.txns.mu.Lock()
+	delete(s.txns.byID, txn.id)
+	s.txns.mu.Unlock()
+	if settle != nil {
+		settle()
+	}
+
+	enlisted := make([]*partition, 0, len(txn.partitions))
+	for p := range txn.partitions {
+		enlisted = append(enlisted, p)
+	}
+	sort.Slice(enlisted, func(i, j int) bool {
+		ti, tj := txn.partitions[enlisted[i]], txn.partitions[enlisted[j]]
+		if ti.name != tj.name {
+			return ti.name < tj.name
+		}
+		return enlisted[i].id < enlisted[j].id
+	})
+	for _, p := range enlisted {
+		t := txn.partitions[p]
+		if err := t.beginPublish(); err != nil {
+			continue // Deleted along with the messages
+		}
+		p.endTransaction(txn.id, marker)
+		t.endPublish()
+	}
+	return nil
+}
+
+// endTransaction appends the marker ending a transaction. Should that fail the partition still
+// treats the transaction as ended, so read_committed consumers are not held up for good; with a
+// log, the next start settles it from the markers the other partitions got.
+func (p *partition) endTransaction(id, marker string) {
+	if _, err := p.append(&messaging.Message{TransactionId: id, TransactionMarker: marker}); err != nil {
+		log.Printf("Failed to write %s marker of transaction %s to partition %d: %v", marker, id, p.id, err)
+		p.mu.Lock()
+		p.settleTransaction(id, marker, p.rb.WriteCursor())
+		p.mu.Unlock()
+		p.rb.WaitStrategy().Signal()
+	}
+}
+
+// settleTransaction records the marker ending a transaction at offset and, when it aborted, lets
+// its messages be published again. The caller holds p.mu.
+func (p *partition) settleTransaction(id, marker string, offset int64) {
+	p.txns.end(id, marker, offset)
+	if marker == markerAbort {
+		p.dedup.evict(id)
+	}
+}
+
+// stage durably records where consumer groups resume once a transaction commits
+func (ts *transactions) stage(id string, offsets []stagedOffset) error {
+	ts.stageMu.Lock()
+	defer ts.stageMu.Unlock()
+	if ts.path == "" {
+		return nil
+	}
+	ts.staged[id] = offsets
+	return ts.saveStaged()
+}
+
+// unstage forgets the staged offsets of a transaction once checkpoints hold them
+func (ts *transactions) unstage(id string) error {
+	ts.stageMu.Lock()
+	defer ts.stageMu.Unlock()
+	if _, ok := ts.staged[id]; !ok {
+		return nil
+	}
+	delete(ts.staged, id)
+	return ts.saveStaged()
+}
+
+// saveStaged persists the staged offsets; the caller holds ts.stageMu
+func (ts *transactions) saveStaged() error {
+	raw, err := json.Marshal(ts.staged)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(ts.path, raw)
+}
+
+// loadStaged reads the offsets staged by commits the broker stopped in the middle of
+func (ts *transactions) loadStaged(path string) error {
+	ts.stageMu.Lock()
+	defer ts.stageMu.Unlock()
+	ts.path = path
+	ts.staged = make(map[string][]stagedOffset)
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, &ts.staged)
+}
+
+// stopTimers keeps open transactions from timing out after the broker closes; they are aborted
+// when it starts again
+func (ts *transactions) stopTimers() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, txn := range ts.byID {
+		txn.timer.Stop()
+	}
+}
+
+// recoverTransactions rebuilds the transaction state of every partition log and ends the
+// transactions the broker stopped in the middle of: committed if any partition holds their commit
+// marker, as a commit had begun, and aborted otherwise. Consumer groups move on past the
+// deliveries the committed ones acknowledged.
+func (s *Server) recoverTransactions() error {
+	if err := s.txns.loadStaged(filepath.Join(s.cfg.DataDir, stagedOffsetsFile)); err != nil {
+		return fmt.Errorf("reading staged offsets: %w", err)
+	}
+	committed := make(map[string]bool)
+	for _, t := range s.topics {
+		for _, p := range t.partitions {
+			ids, err := p.scanTransactions()
+			if err != nil {
+				return fmt.Errorf("topic %s partition %d: %w", t.name, p.id, err)
+			}
+			for _, id := range ids {
+				committed[id] = true
+			}
+		}
+	}
+	for _, t := range s.topics {
+		for _, p := range t.partitions {
+			for _, id := range p.txns.openIDs() {
+				marker := markerAbort
+				if committed[id] {
+					marker = markerCommit
+				}
+				p.endTransaction(id, marker)
+				log.Printf("Recovered transaction %s on topic %s partition %d: %s", id, t.name, p.id, marker)
+			}
+		}
+	}
+
+	resumed := make(map[*topic]bool)
+	for id, offsets := range s.txns.staged {
+		if !committed[id] {
+			continue // Aborted, so its deliveries go out again
+		}
+		for _, o := range offsets {
+			t, ok := s.topics[o.Topic]
+			if !ok {
+				continue
+			}
+			p, err := t.lane(o.Partition, o.Priority)
+			if err != nil {
+				continue
+			}
+			p.advanceCursor(o.Group, o.Offset)
+			resumed[t] = true
+		}
+		log.Printf("Recovered the acknowledgements of transaction %s", id)
+	}
+	for t := range resumed {
+		if err := t.checkpoint(); err != nil {
+			return fmt.Errorf("topic %s: %w", t.name, err)
+		}
+	}
+	for id := range s.txns.staged {
+		if err := s.txns.unstage(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// advanceCursor moves a cursor on to the message after offset, unless it already read that far
+func (p *partition) advanceCursor(cursor string, offset int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if last, ok := p.readPositions()[cursor]; ok && last < offset {
+		p.seekCursor(cursor, offset+1)
+	}
+}
+
+// scanTransactions reads the transaction state of the partition from its whole log, returning the
+// transactions with a commit marker
+func (p *partition) scanTransactions() ([]string, error) {
+	var committed []string
+	end := p.log.NextOffset()
+	var decodeErr error
+	err := p.log.ReadFrom(p.log.StartOffset(), func(offset int64, record []byte) bool {
+		if offset >= end {
+			return false
+		}
+		msg := &messaging.Message{}
+		if decodeErr = proto.Unmarshal(record, msg); decodeErr != nil {
+			return false
+		}
+		if id := msg.GetTransactionId(); id != "" {
+			p.txns.track(msg, offset)
+			if msg.GetTransactionMarker() == markerCommit {
+				committed = append(committed, id)
+			}
+		}
+		return true
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	return committed, err
+}
+
+// partitionTxns tracks the transactions of one partition, so read_committed consumers can stop
+// before the first open one and skip those that aborted
+type partitionTxns struct {
+	mu      sync.Mutex
+	open    map[string]int64 // First offset of each open transaction
+	aborted map[string]int64 // Offset of the abort marker of each aborted transaction still needed
+	pruned  int64            // Aborted transactions with a marker before this offset are forgotten
+}
+
+func newPartitionTxns() *partitionTxns {
+	return &partitionTxns{open: make(map[string]int64), aborted: make(map[string]int64)}
+}
+
+// track records a transactional message or marker at offset
+func (pt *partitionTxns) track(msg *messaging.Message, offset int64) {
+	id := msg.GetTransactionId()
+	if marker := msg.GetTransactionMarker(); marker != "" {
+		pt.end(id, marker, offset)
+		return
+	}
+	pt.begin(id, offset)
+}
+
+// begin records the first offset of a transaction in the partition, returning false if it already
+// published there
+func (pt *partitionTxns) begin(id string, offset int64) bool {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	if _, ok := pt.open[id]; ok {
+		return false
+	}
+	pt.open[id] = offset
+	return true
+}
+
+// forget undoes begin for messages that were never written
+func (pt *partitionTxns) forget(id string) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	delete(pt.open, id)
+}
+
+// end records the marker ending a transaction at offset
+func (pt *partitionTxns) end(id, marker string, offset int64) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	delete(pt.open, id)
+	if marker == markerAbort {
+		pt.aborted[id] = offset
+	}
+}
+
+// lastStable returns the offset of the first message of the oldest open transaction, before which
+// every transaction has ended, or math.MaxInt64 when none is open
+func (pt *partitionTxns) lastStable() int64 {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	stable := int64(math.MaxInt64)
+	for _, offset := range pt.open {
+		stable = min(stable, offset)
+	}
+	return stable
+}
+
+// isAborted reports whether a transaction aborted
+func (pt *partitionTxns) isAborted(id string) bool {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	_, ok := pt.aborted[id]
+	return ok
+}
+
+// openIDs returns the open transactions
+func (pt *partitionTxns) openIDs() []string {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	ids := make([]string, 0, len(pt.open))
+	for id := range pt.open {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// prune forgets aborted transactions whose marker is before offset, as no consumer reads their
+// messages again unless it seeks back
+func (pt *partitionTxns) prune(offset int64) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	for id, marker := range pt.aborted {
+		if marker < offset {
+			delete(pt.aborted, id)
+		}
+	}
+	pt.pruned = max(pt.pruned, offset)
+}
+
+// recall restores the aborted transactions prune forgot from offset on, given their markers
+func (pt *partitionTxns) recall(markers map[string]int64, offset int64) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	for id, marker := range markers {
+		pt.aborted[id] = marker
+	}
+	pt.pruned = min(pt.pruned, offset)
+}
+
+// prunedBefore returns the offset before which aborted transactions may have been forgotten
+func (pt *partitionTxns) prunedBefore() int64 {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return pt.pruned
+}
+
+// recallAborted relearns from the log the aborted transactions with a marker at or after offset,
+// before a cursor moves back to read their messages again. The caller holds p.mu.
+func (p *partition) recallAborted(offset int64) {
+	pruned := p.txns.prunedBefore()
+	if p.log == nil || offset >= pruned {
+		return
+	}
+	markers, err := p.abortMarkers(offset, pruned)
+	if err != nil {
+		log.Printf("Failed to read the aborted transactions of partition %d: %v", p.id, err)
+		return
+	}
+	p.txns.recall(markers, offset)
+}
+
+// abortMarkers returns the offset of every abort marker in the log from offset up to end, by
+// transaction
+func (p *partition) abortMarkers(offset, end int64) (map[string]int64, error) {
+	markers := make(map[string]int64)
+	var decodeErr error
+	err := p.log.ReadFrom(max(offset, p.log.StartOffset()), func(offset int64, record []byte) bool {
+		if offset >= end {
+			return false
+		}
+		msg := &messaging.Message{}
+		if decodeErr = proto.Unmarshal(record, msg); decodeErr != nil {
+			return false
+		}
+		if msg.GetTransactionMarker() == markerAbort {
+			markers[msg.GetTransactionId()] = offset
+		}
+		return true
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	return markers, err
+}
+
+// dropAborted forgets aborted transactions compaction removed along with their marker
+func (pt *partitionTxns) dropAborted(ids []string) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	for _, id := range ids {
+		delete(pt.aborted, id)
+	}
+}
+
+// isolationLevel parses the isolation level of a Consume or Stream request, returning whether it
+// reads committed messages only
+func isolationLevel(level string) (bool, error) {
+	switch level {
+	case "", readUncommitted:
+		return false, nil
+	case readCommitted:
+		return true, nil
+	}
+	return false, status.Errorf(codes.InvalidArgument, "isolation level must be %q or %q, not %q", readUncommitted, readCommitted, level)
+}
@@ -0,0 +1,233 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"github.com/a1mart/kafkaesque/internal/server"
+)
+
+func begin(t *testing.T, b *broker) string {
+	t.Helper()
+	resp, err := b.BeginTransaction(context.Background(), &messaging.BeginTransactionRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.GetTransactionId()
+}
+
+func abort(t *testing.T, b *broker, id string) {
+	t.Helper()
+	resp, err := b.AbortTransaction(context.Background(), &messaging.AbortTransactionRequest{TransactionId: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetSuccess() {
+		t.Fatalf("aborting transaction %s: %s", id, resp.GetError())
+	}
+}
+
+func commit(t *testing.T, b *broker, id string) {
+	t.Helper()
+	resp, err := b.CommitTransaction(context.Background(), &messaging.CommitTransactionRequest{TransactionId: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetSuccess() {
+		t.Fatalf("committing transaction %s: %s", id, resp.GetError())
+	}
+}
+
+// TestReadCommitted ensures read_committed consumers wait for an open transaction, then see its
+// messages once it commits and never those of an aborted one, while read_uncommitted consumers
+// see everything as published
+func TestReadCommitted(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", nil)
+		register(t, b, "orders", "billing")
+		register(t, b, "orders", "audit")
+		committed := &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 10, IsolationLevel: "read_committed"}
+		uncommitted := &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "audit", BatchSize: 10}
+
+		txn := begin(t, b)
+		publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: "committed"}, TransactionId: txn})
+		publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: "plain"}})
+		if got := consume(t, b, committed); len(got) != 0 {
+			t.Fatalf("read_committed consumer got %v during an open transaction", ids(got))
+		}
+		commit(t, b, txn)
+		got := consume(t, b, committed)
+		if fmt.Sprint(ids(got)) != "[committed plain]" {
+			t.Fatalf("after commit got %v, want [committed plain]", ids(got))
+		}
+		for _, msg := range got {
+			ack(t, b, "orders", "billing", msg)
+		}
+
+		txn = begin(t, b)
+		publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: "aborted"}, TransactionId: txn})
+		abort(t, b, txn)
+		publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: "after"}})
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+		if got := ids(consume(t, b, committed)); fmt.Sprint(got) != "[after]" {
+			t.Fatalf("after abort got %v, want [after]", got)
+		}
+		if got := ids(consume(t, b, uncommitted)); fmt.Sprint(got) != "[committed plain aborted after]" {
+			t.Fatalf("read_uncommitted consumer got %v, want every message", got)
+		}
+	})
+}
+
+// TestTransactionTimeout ensures a transaction not committed within its timeout is aborted
+func TestTransactionTimeout(t *testing.T) {
+	b := start(t, server.DefaultConfig())
+	createTopic(t, b, "orders", nil)
+	register(t, b, "orders", "billing")
+
+	resp, err := b.BeginTransaction(context.Background(), &messaging.BeginTransactionRequest{TimeoutMs: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn := resp.GetTransactionId()
+	publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: "late"}, TransactionId: txn})
+	time.Sleep(150 * time.Millisecond)
+
+	if resp, err := b.CommitTransaction(context.Background(), &messaging.CommitTransactionRequest{TransactionId: txn}); err == nil && resp.GetSuccess() {
+		t.Fatal("committed a transaction past its timeout")
+	}
+	publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: "after"}})
+	got := ids(consume(t, b, &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 10, IsolationLevel: "read_committed"}))
+	if fmt.Sprint(got) != "[after]" {
+		t.Fatalf("got %v, want [after]", got)
+	}
+}
+
+// TestRetryAbortedTransaction ensures a message whose transaction aborted may be published again
+// under the same sequence number, and is still a duplicate once that retry is written
+func TestRetryAbortedTransaction(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", nil)
+		msg := func() *messaging.Message {
+			return &messaging.Message{Id: "order-1", ProducerId: "producer", Sequence: 1}
+		}
+
+		txn := begin(t, b)
+		first := publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: msg(), TransactionId: txn})
+		abort(t, b, txn)
+		if cfg.DataDir != "" {
+			b = b.restart(t, cfg)
+		}
+
+		txn = begin(t, b)
+		retried := publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: msg(), TransactionId: txn})
+		if retried.GetDuplicate() {
+			t.Fatalf("retry of an aborted message is a duplicate of offset %d", retried.GetOffset())
+		}
+		if retried.GetOffset() <= first.GetOffset() {
+			t.Fatalf("retry written at offset %d, want after %d", retried.GetOffset(), first.GetOffset())
+		}
+		again := publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: msg(), TransactionId: txn})
+		if !again.GetDuplicate() || again.GetOffset() != retried.GetOffset() {
+			t.Fatalf("second retry: duplicate %v at offset %d, want a duplicate of offset %d", again.GetDuplicate(), again.GetOffset(), retried.GetOffset())
+		}
+	})
+}
+
+// TestSeekBackPastAbortedTransaction ensures read_committed consumers still skip an aborted
+// transaction after the broker forgot it, once everything moved past it, and they seek back
+func TestSeekBackPastAbortedTransaction(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		cfg.BufferSize = 8
+		cfg.RetentionInterval = time.Millisecond
+		b := start(t, cfg)
+		createTopic(t, b, "orders", nil)
+		register(t, b, "orders", "billing")
+
+		txn := begin(t, b)
+		publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: "aborted"}, TransactionId: txn})
+		abort(t, b, txn)
+		consumeReq := &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 10, IsolationLevel: "read_committed"}
+		for i := 0; i < 10; i++ {
+			publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: fmt.Sprintf("order-%d", i)}})
+			for _, msg := range consume(t, b, consumeReq) {
+				ack(t, b, "orders", "billing", msg)
+			}
+		}
+		time.Sleep(20 * time.Millisecond) // Several retention passes
+
+		// In memory the ring buffer wrapped past offset 0, so seek back to the earliest left
+		if _, err := b.Seek(context.Background(), &messaging.SeekRequest{Topic: "orders", ConsumerGroup: "billing", Position: &messaging.SeekRequest_Timestamp{Timestamp: 0}}); err != nil {
+			t.Fatal(err)
+		}
+		for _, msg := range consume(t, b, consumeReq) {
+			if msg.GetId() == "aborted" {
+				t.Fatalf("read_committed consumer got a message of an aborted transaction at offset %d", msg.GetOffset())
+			}
+		}
+	})
+}
+
+// TestCommitAcknowledges ensures committing a transaction acknowledges the deliveries it names, and
+// a restart keeps them acknowledged even when the broker stopped before checkpointing them
+func TestCommitAcknowledges(t *testing.T) {
+	forEachStore(t, func(t *testing.T, cfg server.Config) {
+		b := start(t, cfg)
+		createTopic(t, b, "orders", &messaging.TopicConfig{VisibilityTimeoutMs: 100})
+		createTopic(t, b, "invoices", nil)
+		register(t, b, "orders", "billing")
+		register(t, b, "invoices", "audit")
+		for i := 1; i <= 2; i++ {
+			publish(t, b, &messaging.PublishRequest{Topic: "orders", Message: &messaging.Message{Id: fmt.Sprintf("order-%d", i)}})
+		}
+		consumeReq := &messaging.ConsumeRequest{Topic: "orders", ConsumerGroup: "billing", BatchSize: 10}
+		orders := consume(t, b, consumeReq)
+		if len(orders) != 2 {
+			t.Fatalf("got %v, want both orders", ids(orders))
+		}
+
+		txn := begin(t, b)
+		publish(t, b, &messaging.PublishRequest{Topic: "invoices", Message: &messaging.Message{Id: "invoice-1"}, TransactionId: txn})
+		// Keep the checkpoint of orders from being written, as if the broker stopped first
+		blocker := filepath.Join(cfg.DataDir, "orders", "0", "offsets.json.tmp")
+		if cfg.DataDir != "" {
+			if err := os.Mkdir(blocker, 0o755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		resp, err := b.CommitTransaction(context.Background(), &messaging.CommitTransactionRequest{
+			TransactionId:    txn,
+			Acknowledgements: []*messaging.AckRequest{{Topic: "orders", ConsumerGroup: "billing", Positions: []*messaging.MessagePosition{position(orders[0])}}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.GetSuccess() {
+			t.Fatalf("committing: %s", resp.GetError())
+		}
+
+		if cfg.DataDir != "" {
+			b.stop()
+			if err := os.Remove(blocker); err != nil {
+				t.Fatal(err)
+			}
+			b = start(t, cfg)
+		} else {
+			time.Sleep(150 * time.Millisecond)
+		}
+		if got := ids(consume(t, b, consumeReq)); fmt.Sprint(got) != "[order-2]" {
+			t.Fatalf("got %v again, want only the order the commit left unacknowledged", got)
+		}
+		if got := ids(consume(t, b, &messaging.ConsumeRequest{Topic: "invoices", ConsumerGroup: "audit", BatchSize: 10, IsolationLevel: "read_committed"})); fmt.Sprint(got) != "[invoice-1]" {
+			t.Fatalf("invoices got %v, want [invoice-1]", got)
+		}
+	})
+}
@@ -0,0 +1,374 @@
+package urd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyncPolicy controls when appended records are fsynced to disk
+type SyncPolicy int
+
+const (
+	SyncAlways   SyncPolicy = iota // fsync before every Append returns
+	SyncInterval                   // fsync in the background every Options.SyncInterval
+	SyncNever                      // leave flushing to the operating system
+)
+
+// ParseSyncPolicy resolves "always", "interval" or "never"
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	switch s {
+	case "always":
+		return SyncAlways, nil
+	case "interval", "":
+		return SyncInterval, nil
+	case "never":
+		return SyncNever, nil
+	default:
+		return 0, fmt.Errorf("unknown fsync policy %q", s)
+	}
+}
+
+// Options configures a Log
+type Options struct {
+	SegmentBytes       int64         // Roll to a new segment once the active one reaches this size
+	IndexIntervalBytes int64         // Bytes of records between sparse index entries
+	Sync               SyncPolicy    // When to fsync
+	SyncInterval       time.Duration // Period of background fsyncs under SyncInterval
+}
+
+// DefaultOptions returns the options used when none are given
+func DefaultOptions() Options {
+	return Options{
+		SegmentBytes:       64 << 20,
+		IndexIntervalBytes: 4 << 10,
+		Sync:               SyncInterval,
+		SyncInterval:       time.Second,
+	}
+}
+
+// Log is an append-only, segmented log of opaque records addressed by offset
+type Log struct {
+	dir      string
+	opts     Options
+	mu       sync.RWMutex
+	segments []*segment // Ordered by base offset; the last one is active
+	cleanMu  sync.Mutex // Serializes compaction and deletion of sealed segments
+	dirty    bool
+	closed   chan struct{}
+	wg       sync.WaitGroup
+}
+
+// Open opens the log stored in dir, creating the directory if needed and recovering
+// the end of the active segment after an unclean shutdown
+func Open(dir string, opts Options) (*Log, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var bases []int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, cleanedSuffix) {
+			// Left behind by a compaction that never finished; the original is intact
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, logSuffix) {
+			continue
+		}
+		base, err := strconv.ParseInt(strings.TrimSuffix(name, logSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+	if len(bases) == 0 {
+		bases = append(bases, 0)
+	}
+
+	l := &Log{dir: dir, opts: opts, closed: make(chan struct{})}
+	for _, base := range bases {
+		s, err := openSegment(dir, base)
+		if err != nil {
+			l.closeSegments()
+			return nil, err
+		}
+		l.segments = append(l.segments, s)
+	}
+
+	if opts.Sync == SyncInterval && opts.SyncInterval > 0 {
+		l.wg.Add(1)
+		go l.syncLoop()
+	}
+	return l, nil
+}
+
+// Dir returns the directory backing the log
+func (l *Log) Dir() string {
+	return l.dir
+}
+
+// Append writes records to the log and returns the offset assigned to the first one
+func (l *Log) Append(records ...[]byte) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	first := l.active().nextOffset
+	for len(records) > 0 {
+		active := l.active()
+		if active.size >= l.opts.SegmentBytes {
+			if err := l.roll(); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		// Fill the active segment up to its size limit, always writing at least one record
+		n := 0
+		size := active.size
+		for n < len(records) && (n == 0 || size+headerSize+int64(len(records[n])) <= l.opts.SegmentBytes) {
+			size += headerSize + int64(len(records[n]))
+			n++
+		}
+		if err := active.append(records[:n], l.opts.IndexIntervalBytes); err != nil {
+			return 0, err
+		}
+		records = records[n:]
+	}
+
+	if l.opts.Sync == SyncAlways {
+		if err := l.active().sync(); err != nil {
+			return 0, err
+		}
+	} else {
+		l.dirty = true
+	}
+	return first, nil
+}
+
+// ReadFrom calls fn with every record at or after offset, in order, until fn returns false
+func (l *Log) ReadFrom(offset int64, fn func(offset int64, record []byte) bool) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	i := sort.Search(len(l.segments), func(i int) bool {
+		return l.segments[i].baseOffset > offset
+	})
+	if i > 0 {
+		i--
+	}
+	for ; i < len(l.segments); i++ {
+		stopped, err := l.segments[i].read(offset, fn)
+		if err != nil {
+			return err
+		}
+		if stopped {
+			return nil
+		}
+	}
+	return nil
+}
+
+// StartOffset returns the first offset still held by the log
+func (l *Log) StartOffset() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.segments[0].baseOffset
+}
+
+// NextOffset returns the offset the next appended record will receive
+func (l *Log) NextOffset() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.active().nextOffset
+}
+
+// SegmentInfo describes one segment of a log
+type SegmentInfo struct {
+	BaseOffset int64 // First offset held
+	NextOffset int64 // Offset after the last record held
+	Size       int64 // Bytes of records
+}
+
+// Segments describes the segments of the log, oldest first; the last one is active
+func (l *Log) Segments() []SegmentInfo {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	infos := make([]SegmentInfo, len(l.segments))
+	for i, s := range l.segments {
+		infos[i] = SegmentInfo{BaseOffset: s.baseOffset, NextOffset: s.nextOffset, Size: s.size}
+	}
+	return infos
+}
+
+// DeleteBefore removes the sealed segments that hold nothing at or after offset and returns the
+// bytes of records removed. The active segment is never removed, so StartOffset only advances to
+// the base of the oldest segment kept.
+func (l *Log) DeleteBefore(offset int64) (int64, error) {
+	l.cleanMu.Lock()
+	defer l.cleanMu.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var reclaimed int64
+	for len(l.segments) > 1 && l.segments[0].nextOffset <= offset {
+		s := l.segments[0]
+		if err := s.remove(); err != nil {
+			return reclaimed, err
+		}
+		reclaimed += s.size
+		l.segments = l.segments[1:]
+	}
+	return reclaimed, nil
+}
+
+// Compact rewrites the sealed segments to hold only the records keep returns true for, and returns
+// the number of records removed and the bytes reclaimed. Kept records keep their offsets, leaving
+// gaps that reads skip over. Appends and reads carry on while segments are rewritten.
+func (l *Log) Compact(keep func(offset int64, record []byte) bool) (int64, int64, error) {
+	l.cleanMu.Lock()
+	defer l.cleanMu.Unlock()
+
+	// Sealed segments are never written again, so they can be copied without holding l.mu
+	l.mu.RLock()
+	sealed := append([]*segment(nil), l.segments[:len(l.segments)-1]...)
+	l.mu.RUnlock()
+
+	var removed, reclaimed int64
+	for i, s := range sealed {
+		cleaned, n, err := s.compact(keep, l.opts.IndexIntervalBytes)
+		if err != nil {
+			return removed, reclaimed, err
+		}
+		if cleaned == nil {
+			continue
+		}
+		l.mu.Lock()
+		err = l.swap(i, cleaned)
+		l.mu.Unlock()
+		if err != nil {
+			return removed, reclaimed, err
+		}
+		removed += n
+		reclaimed += s.size - cleaned.size
+	}
+	return removed, reclaimed, nil
+}
+
+// swap replaces the sealed segment at i with its cleaned copy; the caller holds l.mu and l.cleanMu
+func (l *Log) swap(i int, cleaned *segment) error {
+	old := l.segments[i]
+	err := cleaned.close()
+	if cerr := old.close(); err == nil {
+		err = cerr
+	}
+	// A segment without an index is scanned from its start, so removing the old index first keeps
+	// the segment readable whichever rename a crash interrupts
+	for _, step := range []func() error{
+		func() error { return os.Remove(old.index.Name()) },
+		func() error { return os.Rename(cleaned.file.Name(), old.file.Name()) },
+		func() error { return os.Rename(cleaned.index.Name(), old.index.Name()) },
+	} {
+		if err == nil {
+			err = step()
+		}
+	}
+
+	// Reopen whichever files are in place, so a failed swap leaves the segment readable
+	s, oerr := openSegment(l.dir, old.baseOffset)
+	if oerr != nil {
+		return oerr
+	}
+	s.nextOffset = old.nextOffset // The offsets compacted away at its end still belong to it
+	l.segments[i] = s
+	return err
+}
+
+// Sync flushes the active segment to disk
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dirty = false
+	return l.active().sync()
+}
+
+// Close syncs and closes every segment
+func (l *Log) Close() error {
+	close(l.closed)
+	l.wg.Wait()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var err error
+	if l.opts.Sync != SyncNever {
+		err = l.active().sync()
+	}
+	if cerr := l.closeSegments(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (l *Log) active() *segment {
+	return l.segments[len(l.segments)-1]
+}
+
+// roll seals the active segment and starts a new one at the next offset
+func (l *Log) roll() error {
+	active := l.active()
+	if l.opts.Sync != SyncNever {
+		if err := active.sync(); err != nil {
+			return err
+		}
+	}
+	s, err := openSegment(l.dir, active.nextOffset)
+	if err != nil {
+		return err
+	}
+	l.segments = append(l.segments, s)
+	return nil
+}
+
+func (l *Log) syncLoop() {
+	defer l.wg.Done()
+	ticker := time.NewTicker(l.opts.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.closed:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			if l.dirty {
+				l.active().sync()
+				l.dirty = false
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+func (l *Log) closeSegments() error {
+	var err error
+	for _, s := range l.segments {
+		if cerr := s.close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,234 @@
+package urd_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/a1mart/kafkaesque/internal/urd"
+)
+
+func testOptions() urd.Options {
+	opts := urd.DefaultOptions()
+	opts.SegmentBytes = 256
+	opts.IndexIntervalBytes = 64
+	opts.Sync = urd.SyncNever
+	return opts
+}
+
+func readAll(t *testing.T, l *urd.Log, offset int64) []string {
+	t.Helper()
+	var records []string
+	err := l.ReadFrom(offset, func(o int64, record []byte) bool {
+		records = append(records, string(record))
+		return true
+	})
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	return records
+}
+
+// TestAppendAndRead ensures offsets are assigned in order and reads start at any offset across segments.
+func TestAppendAndRead(t *testing.T) {
+	l, err := urd.Open(t.TempDir(), testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for i := 0; i < 50; i++ {
+		offset, err := l.Append([]byte(fmt.Sprintf("record-%d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if offset != int64(i) {
+			t.Fatalf("Expected offset %d, got %d", i, offset)
+		}
+	}
+
+	records := readAll(t, l, 37)
+	if len(records) != 13 || records[0] != "record-37" || records[12] != "record-49" {
+		t.Errorf("Unexpected records from offset 37: %v", records)
+	}
+}
+
+// TestReopen ensures a log recovers its end offset and contents after being closed.
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	l, err := urd.Open(dir, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Append([]byte("a"), []byte("b"), []byte("c")); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	l, err = urd.Open(dir, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if l.NextOffset() != 3 {
+		t.Errorf("Expected next offset 3, got %d", l.NextOffset())
+	}
+	if offset, _ := l.Append([]byte("d")); offset != 3 {
+		t.Errorf("Expected append at offset 3, got %d", offset)
+	}
+	if records := readAll(t, l, 0); len(records) != 4 {
+		t.Errorf("Expected 4 records, got %v", records)
+	}
+}
+
+// TestTornWrite ensures a partially written record is truncated on recovery.
+func TestTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	l, err := urd.Open(dir, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Append([]byte("complete"))
+	l.Close()
+
+	f, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("%020d.log", 0)), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0})
+	f.Close()
+
+	l, err = urd.Open(dir, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if l.NextOffset() != 1 {
+		t.Errorf("Expected torn record to be dropped, next offset %d", l.NextOffset())
+	}
+	if offset, _ := l.Append([]byte("next")); offset != 1 {
+		t.Errorf("Expected append at offset 1, got %d", offset)
+	}
+	if records := readAll(t, l, 0); len(records) != 2 || records[1] != "next" {
+		t.Errorf("Unexpected records after recovery: %v", records)
+	}
+}
+
+// TestDeleteBefore ensures only whole sealed segments below the offset are removed, and that the
+// log keeps its start across a reopen.
+func TestDeleteBefore(t *testing.T) {
+	dir := t.TempDir()
+	l, err := urd.Open(dir, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		l.Append([]byte(fmt.Sprintf("record-%d", i)))
+	}
+	segments := l.Segments()
+	if len(segments) < 3 {
+		t.Fatalf("Expected several segments, got %v", segments)
+	}
+
+	// An offset inside the second segment removes only the first
+	reclaimed, err := l.DeleteBefore(segments[1].BaseOffset + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reclaimed != segments[0].Size {
+		t.Errorf("Expected %d bytes reclaimed, got %d", segments[0].Size, reclaimed)
+	}
+	if l.StartOffset() != segments[1].BaseOffset {
+		t.Errorf("Expected start offset %d, got %d", segments[1].BaseOffset, l.StartOffset())
+	}
+
+	// The active segment survives even when every offset is below the cut
+	if _, err := l.DeleteBefore(l.NextOffset()); err != nil {
+		t.Fatal(err)
+	}
+	active := segments[len(segments)-1]
+	if l.StartOffset() != active.BaseOffset {
+		t.Errorf("Expected only the active segment at %d to remain, start is %d", active.BaseOffset, l.StartOffset())
+	}
+	l.Close()
+
+	l, err = urd.Open(dir, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if records := readAll(t, l, 0); len(records) != int(50-active.BaseOffset) || records[0] != fmt.Sprintf("record-%d", active.BaseOffset) {
+		t.Errorf("Unexpected records after reopen: %v", records)
+	}
+}
+
+// TestCompact ensures compaction drops records from sealed segments only, keeps the offsets of the
+// rest, and that the gaps it leaves survive a reopen and further appends.
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	l, err := urd.Open(dir, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		l.Append([]byte(fmt.Sprintf("record-%d", i)))
+	}
+	active := l.Segments()[len(l.Segments())-1]
+
+	// Drop every offset not divisible by 3
+	removed, reclaimed, err := l.Compact(func(offset int64, record []byte) bool {
+		return offset%3 == 0
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for i := int64(0); i < 50; i++ {
+		if i%3 == 0 || i >= active.BaseOffset {
+			want = append(want, fmt.Sprintf("record-%d", i))
+		}
+	}
+	if removed != 50-int64(len(want)) || reclaimed <= 0 {
+		t.Errorf("Expected %d records removed with bytes reclaimed, got %d and %d bytes", 50-len(want), removed, reclaimed)
+	}
+
+	check := func(l *urd.Log) {
+		t.Helper()
+		var got []string
+		err := l.ReadFrom(0, func(o int64, record []byte) bool {
+			if string(record) != fmt.Sprintf("record-%d", o) {
+				t.Fatalf("Record %q read at offset %d", record, o)
+			}
+			got = append(got, string(record))
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+		// Reading from inside a gap starts at the next record kept
+		if records := readAll(t, l, 4); records[0] != "record-6" {
+			t.Errorf("Expected a read from offset 4 to start at record-6, got %v", records[0])
+		}
+	}
+	check(l)
+	l.Close()
+
+	l, err = urd.Open(dir, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	check(l)
+	if offset, err := l.Append([]byte("record-50")); err != nil || offset != 50 {
+		t.Errorf("Expected the next append at offset 50, got %d (%v)", offset, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2*len(l.Segments()) {
+		t.Errorf("Expected only segment files to remain, got %d entries", len(entries))
+	}
+}
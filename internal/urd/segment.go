@@ -0,0 +1,311 @@
+package urd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	headerSize     = 16 // offset (8) + length (4) + crc (4)
+	indexEntrySize = 8  // relative offset (4) + position (4)
+	logSuffix      = ".log"
+	indexSuffix    = ".index"
+	cleanedSuffix  = ".cleaned" // Compacted copy of a segment, not yet swapped in
+	maxCompactRun  = 1024       // Records compaction buffers before writing them out
+)
+
+var errCorrupt = errors.New("corrupt record")
+
+// indexEntry maps an offset (relative to the segment base) to its byte position in the segment file
+type indexEntry struct {
+	rel uint32
+	pos uint32
+}
+
+// segment is one log file plus its sparse offset index, named after the first offset it holds
+type segment struct {
+	baseOffset      int64
+	nextOffset      int64
+	size            int64
+	file            *os.File
+	index           *os.File
+	entries         []indexEntry
+	bytesSinceIndex int64
+}
+
+func segmentPath(dir string, baseOffset int64, suffix string) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", baseOffset, suffix))
+}
+
+// openSegment opens (or creates) the segment starting at baseOffset and recovers its end,
+// truncating any torn record left behind by a crash
+func openSegment(dir string, baseOffset int64) (*segment, error) {
+	file, err := os.OpenFile(segmentPath(dir, baseOffset, logSuffix), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(segmentPath(dir, baseOffset, indexSuffix), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	s := &segment{baseOffset: baseOffset, nextOffset: baseOffset, file: file, index: index}
+	if err := s.recover(); err != nil {
+		s.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// recover loads the sparse index and scans forward from its last entry to find the end of the log
+func (s *segment) recover() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	fileSize := info.Size()
+
+	raw, err := io.ReadAll(io.NewSectionReader(s.index, 0, 1<<62))
+	if err != nil {
+		return err
+	}
+	for i := 0; i+indexEntrySize <= len(raw); i += indexEntrySize {
+		e := indexEntry{
+			rel: binary.BigEndian.Uint32(raw[i:]),
+			pos: binary.BigEndian.Uint32(raw[i+4:]),
+		}
+		if int64(e.pos) >= fileSize {
+			break
+		}
+		s.entries = append(s.entries, e)
+	}
+
+	var pos int64
+	if len(s.entries) > 0 {
+		last := s.entries[len(s.entries)-1]
+		pos = int64(last.pos)
+		s.nextOffset = s.baseOffset + int64(last.rel)
+	}
+
+	end, next, err := s.scan(pos, fileSize, s.nextOffset, nil)
+	if err != nil && !errors.Is(err, errCorrupt) {
+		return err
+	}
+	s.nextOffset = next
+	s.size = end
+	s.bytesSinceIndex = end - pos
+
+	if end < fileSize {
+		if err := s.file.Truncate(end); err != nil {
+			return err
+		}
+	}
+	if err := s.index.Truncate(int64(len(s.entries) * indexEntrySize)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// scan walks records from pos up to limit, calling fn for each one. Offsets must increase from
+// expected on, with gaps only where compaction removed records. It returns the byte position
+// after the last valid record and the offset following it.
+func (s *segment) scan(pos, limit, expected int64, fn func(offset int64, record []byte) bool) (int64, int64, error) {
+	r := bufio.NewReader(io.NewSectionReader(s.file, pos, limit-pos))
+	header := make([]byte, headerSize)
+	for pos < limit {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return pos, expected, errCorrupt
+		}
+		offset := int64(binary.BigEndian.Uint64(header))
+		length := int64(binary.BigEndian.Uint32(header[8:]))
+		sum := binary.BigEndian.Uint32(header[12:])
+		if offset < expected || pos+headerSize+length > limit {
+			return pos, expected, errCorrupt
+		}
+
+		record := make([]byte, length)
+		if _, err := io.ReadFull(r, record); err != nil {
+			return pos, expected, errCorrupt
+		}
+		if crc32.ChecksumIEEE(record) != sum {
+			return pos, expected, errCorrupt
+		}
+
+		pos += headerSize + length
+		expected = offset + 1
+		if fn != nil && !fn(offset, record) {
+			return pos, expected, nil
+		}
+	}
+	return pos, expected, nil
+}
+
+// append writes records to the end of the segment, adding a sparse index entry every indexInterval bytes
+func (s *segment) append(records [][]byte, indexInterval int64) error {
+	var buf []byte
+	var entries []byte
+	pos := s.size
+	offset := s.nextOffset
+	sinceIndex := s.bytesSinceIndex
+	var added []indexEntry
+
+	for _, record := range records {
+		if sinceIndex >= indexInterval || (len(s.entries) == 0 && len(added) == 0) {
+			e := indexEntry{rel: uint32(offset - s.baseOffset), pos: uint32(pos)}
+			added = append(added, e)
+			entries = binary.BigEndian.AppendUint32(entries, e.rel)
+			entries = binary.BigEndian.AppendUint32(entries, e.pos)
+			sinceIndex = 0
+		}
+
+		buf = binary.BigEndian.AppendUint64(buf, uint64(offset))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(record)))
+		buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(record))
+		buf = append(buf, record...)
+
+		n := int64(headerSize + len(record))
+		pos += n
+		sinceIndex += n
+		offset++
+	}
+
+	if _, err := s.file.WriteAt(buf, s.size); err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		if _, err := s.index.WriteAt(entries, int64(len(s.entries)*indexEntrySize)); err != nil {
+			return err
+		}
+	}
+
+	s.entries = append(s.entries, added...)
+	s.size = pos
+	s.nextOffset = offset
+	s.bytesSinceIndex = sinceIndex
+	return nil
+}
+
+// read calls fn for every record at or after offset until fn returns false.
+// It reports whether fn asked to stop.
+func (s *segment) read(offset int64, fn func(offset int64, record []byte) bool) (bool, error) {
+	if offset >= s.nextOffset {
+		return false, nil
+	}
+
+	// Find the last index entry at or before the offset
+	var pos int64
+	expected := s.baseOffset
+	i := sort.Search(len(s.entries), func(i int) bool {
+		return s.baseOffset+int64(s.entries[i].rel) > offset
+	})
+	if i > 0 {
+		pos = int64(s.entries[i-1].pos)
+		expected = s.baseOffset + int64(s.entries[i-1].rel)
+	}
+
+	stopped := false
+	_, _, err := s.scan(pos, s.size, expected, func(o int64, record []byte) bool {
+		if o < offset {
+			return true
+		}
+		if !fn(o, record) {
+			stopped = true
+			return false
+		}
+		return true
+	})
+	return stopped, err
+}
+
+// compact copies the records keep returns true for into a cleaned segment beside this one, with
+// their offsets unchanged, and returns it along with the number of records left out. It returns a
+// nil segment when every record is kept.
+func (s *segment) compact(keep func(offset int64, record []byte) bool, indexInterval int64) (*segment, int64, error) {
+	dir := filepath.Dir(s.file.Name())
+	file, err := os.OpenFile(segmentPath(dir, s.baseOffset, logSuffix+cleanedSuffix), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, 0, err
+	}
+	index, err := os.OpenFile(segmentPath(dir, s.baseOffset, indexSuffix+cleanedSuffix), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, 0, err
+	}
+	cleaned := &segment{baseOffset: s.baseOffset, nextOffset: s.baseOffset, file: file, index: index}
+
+	// Kept records are appended in runs of consecutive offsets
+	var run [][]byte
+	var removed int64
+	flush := func() error {
+		err := cleaned.append(run, indexInterval)
+		run = run[:0]
+		return err
+	}
+	var writeErr error
+	_, _, err = s.scan(0, s.size, s.baseOffset, func(offset int64, record []byte) bool {
+		if !keep(offset, record) {
+			removed++
+			return true
+		}
+		if len(run) > 0 && (offset != cleaned.nextOffset+int64(len(run)) || len(run) == maxCompactRun) {
+			if writeErr = flush(); writeErr != nil {
+				return false
+			}
+		}
+		if len(run) == 0 {
+			cleaned.nextOffset = offset
+		}
+		run = append(run, record)
+		return true
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if err == nil && len(run) > 0 {
+		err = flush()
+	}
+	if err == nil && removed > 0 {
+		err = cleaned.sync()
+	}
+	if err != nil || removed == 0 {
+		cleaned.remove()
+		return nil, 0, err
+	}
+	cleaned.nextOffset = s.nextOffset
+	return cleaned, removed, nil
+}
+
+func (s *segment) sync() error {
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	return s.index.Sync()
+}
+
+func (s *segment) close() error {
+	err := s.file.Close()
+	if ierr := s.index.Close(); err == nil {
+		err = ierr
+	}
+	return err
+}
+
+// remove closes the segment and deletes its files
+func (s *segment) remove() error {
+	if err := s.close(); err != nil {
+		return err
+	}
+	if err := os.Remove(s.file.Name()); err != nil {
+		return err
+	}
+	return os.Remove(s.index.Name())
+}
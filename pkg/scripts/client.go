@@ -20,6 +20,15 @@ func main() {
 
 	client := messaging.NewMessagingServiceClient(conn)
 
+	// Register the consumer group before publishing so it sees the message
+	_, err = client.RegisterConsumerGroup(context.Background(), &messaging.RegisterConsumerRequest{
+		ConsumerGroup: "example-group",
+		Topic:         "example-topic",
+	})
+	if err != nil {
+		log.Fatalf("Register consumer group failed: %v", err)
+	}
+
 	// Publish a message
 	message := &messaging.Message{
 		Id:      "1",
@@ -40,7 +49,7 @@ func main() {
 	// Consume messages
 	consumeReq := &messaging.ConsumeRequest{
 		Topic:         "example-topic",
-		ConsumerGroup: 1,
+		ConsumerGroup: "example-group",
 		BatchSize:     2,
 	}
 	resp, err := client.Consume(context.Background(), consumeReq)
@@ -48,7 +57,19 @@ func main() {
 		log.Fatalf("Consume failed: %v", err)
 	}
 
+	var positions []*messaging.MessagePosition
 	for _, msg := range resp.GetMessages() {
 		fmt.Printf("Consumed message: %s\n", msg.GetPayload())
+		positions = append(positions, &messaging.MessagePosition{Partition: msg.GetPartition(), Priority: msg.GetPriority(), Offset: msg.GetOffset()})
+	}
+
+	// Acknowledge so the messages are not redelivered once the visibility timeout passes
+	_, err = client.Acknowledge(context.Background(), &messaging.AckRequest{
+		Topic:         "example-topic",
+		ConsumerGroup: "example-group",
+		Positions:     positions,
+	})
+	if err != nil {
+		log.Fatalf("Acknowledge failed: %v", err)
 	}
 }
@@ -87,6 +87,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/a1mart/kafkaesque/internal/generated/messaging" // Import your generated messaging service files
 	"google.golang.org/grpc"
@@ -94,11 +95,19 @@ import (
 
 func main() {
 	if len(os.Args) < 2 {
-		log.Fatalf("Usage: go run consumer.go <topic>\n")
+		log.Fatalf("Usage: go run consumer.go <topic> [group] [consumer_id]\n")
 	}
 
 	topic := os.Args[1]
-	fmt.Printf("Running consumer for topic: %s\n", topic)
+	group := "default"
+	if len(os.Args) > 2 {
+		group = os.Args[2]
+	}
+	consumerID, _ := os.Hostname()
+	if len(os.Args) > 3 {
+		consumerID = os.Args[3]
+	}
+	fmt.Printf("Running consumer %s in group %s for topic: %s\n", consumerID, group, topic)
 
 	// Connect to the gRPC server
 	conn, err := grpc.Dial("localhost:50051", grpc.WithInsecure()) // Update if needed
@@ -109,6 +118,17 @@ func main() {
 
 	client := messaging.NewMessagingServiceClient(conn)
 
+	regResp, err := client.RegisterConsumerGroup(context.Background(), &messaging.RegisterConsumerRequest{
+		ConsumerGroup: group,
+		Topic:         topic,
+	})
+	if err != nil {
+		log.Fatalf("Register consumer group failed: %v", err)
+	}
+	if !regResp.GetSuccess() {
+		log.Fatalf("Register consumer group failed: %s", regResp.GetError())
+	}
+
 	// Set up a signal channel to gracefully shut down
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -118,15 +138,31 @@ func main() {
 		for {
 			consumeReq := &messaging.ConsumeRequest{
 				Topic:         topic,
-				ConsumerGroup: 1, // Could make this dynamic if needed
+				ConsumerGroup: group,
+				ConsumerId:    consumerID,
 				BatchSize:     2,
+				MaxWaitMs:     5000, // Long-poll instead of spinning against the server
 			}
 			resp, err := client.Consume(context.Background(), consumeReq)
 			if err != nil {
 				log.Printf("Consume failed: %v", err)
+				time.Sleep(time.Second)
 			} else {
+				var positions []*messaging.MessagePosition
 				for _, msg := range resp.GetMessages() {
 					fmt.Printf("Consumed message: %s\n", msg.GetPayload())
+					positions = append(positions, position(msg))
+				}
+				if len(positions) > 0 {
+					_, err := client.Acknowledge(context.Background(), &messaging.AckRequest{
+						Topic:         topic,
+						ConsumerGroup: group,
+						ConsumerId:    consumerID,
+						Positions:     positions,
+					})
+					if err != nil {
+						log.Printf("Acknowledge failed: %v", err)
+					}
 				}
 			}
 		}
@@ -136,3 +172,8 @@ func main() {
 	<-sigChan
 	fmt.Println("Consumer stopped.")
 }
+
+// position names a delivered message by where it is stored, which unlike its ID is always unique
+func position(msg *messaging.Message) *messaging.MessagePosition {
+	return &messaging.MessagePosition{Partition: msg.GetPartition(), Priority: msg.GetPriority(), Offset: msg.GetOffset()}
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/a1mart/kafkaesque/internal/generated/messaging"
@@ -14,13 +17,51 @@ import (
 const serverAddr = "localhost:50051"
 
 func main() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: go run pkg/scripts/create_topic.go <topic_name> <strategy>")
+	// Settings left at zero or empty take the broker's defaults; -1 lifts a limit
+	config := &messaging.TopicConfig{}
+	partitions := flag.Int("partitions", 0, "Number of partitions")
+	flag.StringVar(&config.Partitioner, "partitioner", "", "Partitioner: hash, round_robin or sticky")
+	flag.StringVar(&config.OverflowPolicy, "overflow-policy", "", "When consumers lag a full buffer behind: block, drop_newest, drop_oldest or fail")
+	flag.Int64Var(&config.RetentionMs, "retention-ms", 0, "Delete messages older than this many milliseconds")
+	flag.Int64Var(&config.RetentionBytes, "retention-bytes", 0, "Delete the oldest messages once a partition grows beyond this many bytes")
+	maxMessageBytes := flag.Int("max-message-bytes", 0, "Reject messages larger than this many bytes")
+	maxDeliveries := flag.Int("max-deliveries", 0, "Dead-letter messages after this many delivery attempts")
+	flag.StringVar(&config.SchemaName, "schema", "", "Registered schema published payloads must match")
+	flag.StringVar(&config.SchemaVersion, "schema-version", "", "Schema version to validate against (default latest)")
+	flag.StringVar(&config.CleanupPolicy, "cleanup-policy", "", "delete or compact")
+	flag.Int64Var(&config.DeleteRetentionMs, "delete-retention-ms", 0, "On compacted topics, keep tombstones this many milliseconds")
+	flag.BoolVar(&config.DedupIds, "dedup-ids", false, "Drop messages whose ID was published recently")
+	flag.Int64Var(&config.MessageTtlMs, "message-ttl-ms", 0, "Skip messages not consumed within this many milliseconds, unless they set their own ttl_ms")
+	flag.BoolVar(&config.DeadLetterExpired, "dead-letter-expired", false, "Move expired messages to the dead-letter queue instead of discarding them")
+	priorityLevels := flag.Int("priority-levels", 0, "Priority lanes per partition")
+	flag.StringVar(&config.PriorityPolicy, "priority-policy", "", "How consumers share reads between lanes: weighted_round_robin, strict or mlfq")
+	priorityWeights := flag.String("priority-weights", "", "Comma-separated messages per round of each lane under weighted_round_robin, lowest first")
+	flag.Usage = func() {
+		fmt.Println("Usage: go run pkg/scripts/create_topic.go [flags] <topic_name> <strategy>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		flag.Usage()
 		os.Exit(1)
 	}
+	topicName := flag.Arg(0)
+	strategy := flag.Arg(1)
 
-	topicName := os.Args[1]
-	strategy := os.Args[2]
+	config.Partitions = int32(*partitions)
+	config.MaxMessageBytes = int32(*maxMessageBytes)
+	config.MaxDeliveries = int32(*maxDeliveries)
+	config.PriorityLevels = int32(*priorityLevels)
+	if *priorityWeights != "" {
+		for _, w := range strings.Split(*priorityWeights, ",") {
+			weight, err := strconv.Atoi(strings.TrimSpace(w))
+			if err != nil {
+				log.Fatalf("Invalid priority weight %q", w)
+			}
+			config.PriorityWeights = append(config.PriorityWeights, int32(weight))
+		}
+	}
 
 	conn, err := grpc.Dial(serverAddr, grpc.WithInsecure())
 	if err != nil {
@@ -36,6 +77,7 @@ func main() {
 	req := &messaging.CreateTopicRequest{
 		Topic:    topicName,
 		Strategy: strategy,
+		Config:   config,
 	}
 
 	resp, err := client.CreateTopic(ctx, req)
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/a1mart/kafkaesque/internal/generated/messaging"
+	"google.golang.org/grpc"
+)
+
+const serverAddr = "localhost:50051"
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("Usage: go run ./pkg/scripts/delete_topic <topic_name>")
+		os.Exit(1)
+	}
+	topicName := os.Args[1]
+
+	conn, err := grpc.Dial(serverAddr, grpc.WithInsecure())
+	if err != nil {
+		log.Fatalf("Failed to connect to gRPC server: %v", err)
+	}
+	defer conn.Close()
+
+	client := messaging.NewAdminServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := client.DeleteTopic(ctx, &messaging.DeleteTopicRequest{Topic: topicName})
+	if err != nil {
+		log.Fatalf("Failed to delete topic: %v", err)
+	}
+
+	if resp.Success {
+		fmt.Printf("Successfully deleted topic: %s\n", topicName)
+	} else {
+		fmt.Printf("Failed to delete topic: %s. Error: %s\n", topicName, resp.Error)
+	}
+}
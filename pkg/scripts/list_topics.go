@@ -32,6 +32,6 @@ func main() {
 
 	fmt.Println("Existing Topics:")
 	for _, topic := range resp.Topics {
-		fmt.Printf("- %s (Strategy: %s)\n", topic.Topic, topic.Strategy)
+		fmt.Printf("- %s (Strategy: %s, Depth: %d)\n", topic.Topic, topic.Strategy, topic.Depth)
 	}
 }
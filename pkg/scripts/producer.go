@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -13,11 +14,21 @@ import (
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		log.Fatalf("Usage: go run producer.go <topic>\n")
+	// Lines are sent in batches: once batch-size of them are waiting, or linger after the first
+	batchSize := flag.Int("batch-size", 100, "Most messages to publish in one request")
+	linger := flag.Duration("linger", 50*time.Millisecond, "Longest a message waits for its batch to fill")
+	priority := flag.Int("priority", 0, "Priority lane to publish to, on topics with priority levels")
+	flag.Usage = func() {
+		fmt.Println("Usage: go run producer.go [flags] <topic>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
 	}
 
-	topic := os.Args[1]
+	topic := flag.Arg(0)
 	fmt.Printf("Running producer for topic: %s\n", topic)
 
 	// Connect to the gRPC server
@@ -29,38 +40,65 @@ func main() {
 
 	client := messaging.NewMessagingServiceClient(conn)
 
-	// Set up the input scanner to read messages
-	scanner := bufio.NewScanner(os.Stdin)
-	fmt.Println("Enter messages to publish to the topic. Type 'exit' to stop.")
-
-	// Loop to accept messages from the user
-	for {
-		fmt.Print("Message: ")
-		scanner.Scan()
-		messageText := scanner.Text()
-
-		if messageText == "exit" {
-			break
+	// Read lines in the background so a batch can be sent while waiting for input
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if scanner.Text() == "exit" {
+				return
+			}
+			lines <- scanner.Text()
 		}
+	}()
+	fmt.Println("Enter messages to publish to the topic. Type 'exit' to stop.")
 
-		// Create message to publish
-		message := &messaging.Message{
-			Id:      fmt.Sprintf("%d", time.Now().UnixNano()),
-			Type:    "test", // or any type you want to assign
-			Payload: []byte(messageText),
+	var batch []*messaging.Message
+	timer := time.NewTimer(*linger)
+	timer.Stop()
+	flush := func() {
+		if len(batch) == 0 {
+			return
 		}
-		publishReq := &messaging.PublishRequest{
-			Topic:   topic,
-			Message: message,
+		resp, err := client.PublishBatch(context.Background(), &messaging.PublishBatchRequest{
+			Topic:    topic,
+			Messages: batch,
+		})
+		switch {
+		case err != nil:
+			log.Printf("Publish failed: %v", err)
+		case !resp.GetSuccess():
+			log.Printf("Published %d messages: %s", len(batch), resp.GetError())
+		default:
+			fmt.Printf("Published %d messages!\n", len(batch))
 		}
+		batch = nil
+	}
 
-		// Publish the message
-		_, err := client.Publish(context.Background(), publishReq)
-		if err != nil {
-			log.Printf("Publish failed: %v", err)
-		} else {
-			fmt.Println("Message published!")
+	for {
+		select {
+		case text, ok := <-lines:
+			if !ok {
+				flush()
+				fmt.Println("Producer stopped.")
+				return
+			}
+			batch = append(batch, &messaging.Message{
+				Id:       fmt.Sprintf("%d", time.Now().UnixNano()),
+				Type:     "test", // or any type you want to assign
+				Payload:  []byte(text),
+				Priority: int32(*priority),
+			})
+			if len(batch) == 1 {
+				timer.Reset(*linger)
+			}
+			if len(batch) >= *batchSize {
+				timer.Stop()
+				flush()
+			}
+		case <-timer.C:
+			flush()
 		}
 	}
-	fmt.Println("Producer stopped.")
 }